@@ -2,8 +2,12 @@ package main
 
 import (
 	"BecomeOverMan/internal/handlers"
+	"BecomeOverMan/internal/jobs"
+	"context"
 	"log"
 	"log/slog"
+	"os"
+	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 
@@ -21,7 +25,11 @@ import (
 func main() {
 	slog.SetLogLoggerLevel(slog.LevelDebug) // Включаем DEBUG-логирование
 
-	db, err := sqlx.Connect("postgres", config.Cfg.DatabaseURL)
+	if err := config.LoadEconomy(config.Get().EconomyConfigPath); err != nil {
+		log.Fatal("Failed to load economy config:", err)
+	}
+
+	db, err := sqlx.Connect("postgres", config.Get().DatabaseURL)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -33,9 +41,32 @@ func main() {
 	userRepo := repositories.NewUserRepository(db)
 	userService := services.NewUserService(userRepo)
 
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	ledgerService := services.NewLedgerService(ledgerRepo)
+
+	notificationRepo := repositories.NewNotificationRepository(db)
+	notificationService := services.NewNotificationService(notificationRepo)
+
 	questRepo := repositories.NewQuestRepository(db)
 	questService := services.NewQuestService(questRepo, userRepo)
 
+	// CLI-подкоманды (без запуска HTTP-сервера)
+	if len(os.Args) > 1 && os.Args[1] == "questpack" {
+		if err := runQuestPackCommand(questService, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Фоновые задачи по расписанию
+	jobs.Start(context.Background(),
+		jobs.Job{Name: "event-statuses", Interval: time.Minute, Run: questService.RefreshEventStatuses},
+		jobs.Job{Name: "expired-quests", Interval: time.Hour, Run: questService.FailExpiredQuests},
+		jobs.Job{Name: "progress-decay", Interval: time.Hour, Run: questService.RunDecay},
+		jobs.Job{Name: "shared-quest-invites", Interval: 5 * time.Minute, Run: questService.ExpireSharedQuestInvites},
+		jobs.Job{Name: "duels", Interval: time.Minute, Run: questService.ProcessDuels},
+	)
+
 	r := gin.Default()
 	// Настройка CORS
 	r.Use(cors.New(cors.Config{
@@ -50,6 +81,20 @@ func main() {
 
 		handlers.RegisterUserRoutes(r, userService)
 		handlers.RegisterQuestRoutes(r, questService)
+		handlers.RegisterLedgerRoutes(r, ledgerService)
+		handlers.RegisterGiftRoutes(r, questService)
+		handlers.RegisterDailyRewardRoutes(r, questService)
+		handlers.RegisterItemRoutes(r, questService)
+		handlers.RegisterEventRoutes(r, questService)
+		handlers.RegisterEconomyRoutes(r, questService)
+		handlers.RegisterDecayRoutes(r, questService)
+		handlers.RegisterTeamRoutes(r, questService)
+		handlers.RegisterLeaderboardRoutes(r, questService)
+		handlers.RegisterSocialRoutes(r, questService)
+		handlers.RegisterDuelRoutes(r, questService)
+		handlers.RegisterNotificationRoutes(r, notificationService)
+
+		handlers.RegisterAdminRoutes(r, questService, ledgerService)
 	}
 
 	if err := r.Run("0.0.0.0:8080"); err != nil {
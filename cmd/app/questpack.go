@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"BecomeOverMan/internal/services"
+)
+
+const questPackUsage = `usage:
+  app questpack export [-format json|yaml] [-o file]
+  app questpack import [-format json|yaml] [-dry-run] file`
+
+// runQuestPackCommand - CLI для экспорта/импорта каталога квестов
+func runQuestPackCommand(questService *services.QuestService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(questPackUsage)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("questpack export", flag.ContinueOnError)
+		format := fs.String("format", services.DefaultQuestPackFormat, "output format: json or yaml")
+		output := fs.String("o", "", "output file (stdout by default)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		f, err := services.ParseQuestPackFormat(*format)
+		if err != nil {
+			return err
+		}
+
+		pack, err := questService.ExportQuestPack(ctx)
+		if err != nil {
+			return err
+		}
+
+		data, err := services.EncodeQuestPack(pack, f)
+		if err != nil {
+			return err
+		}
+
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(*output, data, 0o644)
+
+	case "import":
+		fs := flag.NewFlagSet("questpack import", flag.ContinueOnError)
+		format := fs.String("format", services.DefaultQuestPackFormat, "input format: json or yaml")
+		dryRun := fs.Bool("dry-run", false, "validate and show what would change without writing")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New(questPackUsage)
+		}
+
+		f, err := services.ParseQuestPackFormat(*format)
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		if fs.Arg(0) == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(fs.Arg(0))
+		}
+		if err != nil {
+			return err
+		}
+
+		pack, err := services.DecodeQuestPack(data, f)
+		if err != nil {
+			return err
+		}
+
+		report, err := questService.ImportQuestPack(ctx, pack, *dryRun)
+		if err != nil {
+			return err
+		}
+
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+
+		if report.HasErrors() {
+			return fmt.Errorf("quest pack has validation errors")
+		}
+		return nil
+
+	default:
+		return errors.New(questPackUsage)
+	}
+}
@@ -51,4 +51,20 @@ INSERT INTO quest_tasks (quest_id, task_id, task_order) VALUES
 (3, 9, 1),
 (3, 10, 2),
 (3, 11, 3),
-(3, 12, 4);
\ No newline at end of file
+(3, 12, 4);
+
+-- Каталог предметов магазина
+INSERT INTO items (code, name, description, price, effect_type, effect_value, duration_hours) VALUES
+('streak_freeze', 'Заморозка серии', 'Сохраняет серию ежедневных наград, если пропущен день (тратится автоматически)', 50, 'streak_freeze', 0, 0),
+('xp_booster_small', 'Малый бустер опыта', 'x1.5 опыта за задачи и квесты в течение 2 часов', 80, 'xp_booster', 150, 2),
+('xp_booster_large', 'Большой бустер опыта', 'x2 опыта за задачи и квесты в течение 24 часов', 300, 'xp_booster', 200, 24),
+('timer_extender', 'Продление таймера', 'Добавляет 24 часа к сроку начатого квеста', 100, 'timer_extender', 24, 0);
+
+-- Достижения команд
+INSERT INTO team_achievements (code, name, description, metric, threshold) VALUES
+('team_first_quest', 'Первый шаг', 'Участники команды завершили первый квест', 'quests_completed', 1),
+('team_quests_50', 'Слаженная команда', 'Участники команды завершили 50 квестов', 'quests_completed', 50),
+('team_xp_1000', 'Общий опыт', 'Команда накопила 1000 опыта', 'xp_pool', 1000),
+('team_xp_10000', 'Легион', 'Команда накопила 10000 опыта', 'xp_pool', 10000),
+('team_members_5', 'Отряд', 'В команде 5 участников', 'members', 5),
+('team_goal_1', 'Общая цель', 'Команда выполнила первый командный квест', 'team_quests_completed', 1);
@@ -14,7 +14,39 @@ DROP TABLE IF EXISTS quests CASCADE;
 DROP TABLE IF EXISTS users CASCADE;
 DROP TABLE IF EXISTS categories CASCADE;
 DROP TABLE IF EXISTS friends CASCADE;
+DROP TABLE IF EXISTS shared_quest_members CASCADE;
 DROP TABLE IF EXISTS shared_quests CASCADE;
+DROP TABLE IF EXISTS quest_reviews CASCADE;
+DROP TABLE IF EXISTS quest_chain_items CASCADE;
+DROP TABLE IF EXISTS quest_chains CASCADE;
+DROP TABLE IF EXISTS quest_templates CASCADE;
+DROP TABLE IF EXISTS gifts CASCADE;
+DROP TABLE IF EXISTS notifications CASCADE;
+DROP TABLE IF EXISTS daily_reward_claims CASCADE;
+DROP TABLE IF EXISTS user_daily_rewards CASCADE;
+DROP TABLE IF EXISTS user_inventory CASCADE;
+DROP TABLE IF EXISTS user_active_effects CASCADE;
+DROP TABLE IF EXISTS items CASCADE;
+DROP TABLE IF EXISTS event_scores CASCADE;
+DROP TABLE IF EXISTS event_quests CASCADE;
+DROP TABLE IF EXISTS events CASCADE;
+DROP TABLE IF EXISTS user_decay_history CASCADE;
+DROP TABLE IF EXISTS task_completion_reviews CASCADE;
+DROP TABLE IF EXISTS user_blocks CASCADE;
+DROP TABLE IF EXISTS team_quests CASCADE;
+DROP TABLE IF EXISTS team_unlocked_achievements CASCADE;
+DROP TABLE IF EXISTS team_achievements CASCADE;
+DROP TABLE IF EXISTS team_members CASCADE;
+DROP TABLE IF EXISTS teams CASCADE;
+DROP TABLE IF EXISTS user_xp_periods CASCADE;
+DROP TABLE IF EXISTS activity_events CASCADE;
+DROP TABLE IF EXISTS user_privacy_settings CASCADE;
+DROP TABLE IF EXISTS social_reactions CASCADE;
+DROP TABLE IF EXISTS social_comments CASCADE;
+DROP TABLE IF EXISTS duels CASCADE;
+
+-- Триграммы для нечеткого поиска пользователей по имени
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 -- Удаление типов
 DROP TYPE IF EXISTS category_name CASCADE;
@@ -47,11 +79,20 @@ CREATE TABLE users (
 
     current_streak INT DEFAULT 0,
     longest_streak INT DEFAULT 0,
+    quests_completed INT NOT NULL DEFAULT 0,
+
+    leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE, -- пользователь скрыт из таблиц лидеров
+
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA, для "дня пользователя" (ежедневные награды)
 
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    last_active_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    last_active_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_decay_at TIMESTAMP -- последнее затухание прогресса из-за неактивности
 );
 
+-- Поиск пользователей по имени (префикс и нечеткое совпадение)
+CREATE INDEX idx_users_username_trgm ON users USING gin (lower(username) gin_trgm_ops);
+
 -- Таблица задач
 CREATE TABLE tasks (
     id SERIAL PRIMARY KEY,
@@ -79,6 +120,7 @@ CREATE TABLE user_coin_transactions (
 
     transaction_type VARCHAR(50) NOT NULL, -- 'earned', 'spent', 'bonus'
     amount INT NOT NULL,
+    balance_after INT,                      -- баланс пользователя после применения транзакции
     
     description TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -86,6 +128,8 @@ CREATE TABLE user_coin_transactions (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+CREATE INDEX idx_user_coin_transactions_user_created ON user_coin_transactions(user_id, created_at);
+
 -- Достижения
 CREATE TABLE achievements (
     id SERIAL PRIMARY KEY,
@@ -124,7 +168,17 @@ CREATE TABLE quests (
     is_sequential BOOLEAN DEFAULT FALSE,   -- Нужно ли выполнять по порядку
     reward_xp INT NOT NULL,
     reward_coin INT NOT NULL,
-    time_limit_hours INT DEFAULT 0          -- Ограничение по времени (опционально)
+    time_limit_hours INT DEFAULT 0,         -- Ограничение по времени (опционально)
+    -- стабильный ключ квеста в quest pack (для идемпотентного импорта); выдается при создании квеста
+    pack_key VARCHAR(255) UNIQUE NOT NULL DEFAULT ('quest-' || substr(md5(random()::text || clock_timestamp()::text), 1, 16)),
+
+    -- Кооперативные механики совместного квеста (в процентах от reward_xp/reward_coin)
+    coop_bonus_percent INT NOT NULL DEFAULT 100 CHECK (coop_bonus_percent >= 100),          -- все участники выполнили квест...
+    coop_bonus_window_hours INT NOT NULL DEFAULT 0 CHECK (coop_bonus_window_hours >= 0),    -- ...в пределах стольких часов друг от друга (0 - без ограничения)
+    coop_partial_reward_percent INT NOT NULL DEFAULT 100
+        CHECK (coop_partial_reward_percent BETWEEN 0 AND 100),                             -- участнику, не выполнившему все задачи (quorum/pool)
+    coop_fail_penalty_percent INT NOT NULL DEFAULT 0
+        CHECK (coop_fail_penalty_percent BETWEEN 0 AND 100)                                -- штраф выполнившим, если кто-то из партнеров не справился
 );
 
 
@@ -144,6 +198,7 @@ CREATE TABLE user_tasks (
 
     is_confirmed BOOL DEFAULT FALSE NOT NULL,            -- прежнее поле
     completed_at TIMESTAMP,                              -- прежнее поле
+    reward_held BOOLEAN NOT NULL DEFAULT FALSE,          -- выполнение на проверке anti-cheat, награда не начислена
     xp_gained INT NOT NULL DEFAULT 0,
     coin_gained INT NOT NULL DEFAULT 0,
 
@@ -178,7 +233,8 @@ CREATE TABLE user_quests (
     expires_at TIMESTAMP,
 
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE
+    FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE,
+    CONSTRAINT unique_user_quest UNIQUE (user_id, quest_id)
 );
 
 -- friends
@@ -192,12 +248,431 @@ CREATE TABLE friends (
     UNIQUE(user_id, friend_id)
 );
 
--- Совместные квесты
+-- Одна запись на пару пользователей независимо от направления (встречные заявки не дублируются)
+CREATE UNIQUE INDEX idx_friends_pair ON friends (LEAST(user_id, friend_id), GREATEST(user_id, friend_id));
+
+-- Совместные (групповые) квесты: владелец приглашает друзей,
+-- квест стартует у всех принявших, когда ответили все приглашенные
 CREATE TABLE shared_quests (
     id SERIAL PRIMARY KEY,
     quest_id INTEGER NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
-    user1_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    user2_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    status VARCHAR(50) DEFAULT 'active',
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(50) DEFAULT 'pending',             -- 'pending', 'active', 'completed', 'declined', 'cancelled', 'expired'
+    completion_rule VARCHAR(20) NOT NULL DEFAULT 'all', -- 'all', 'quorum', 'pool'
+    quorum INT,                                       -- для 'quorum': сколько участников должны выполнить все задачи
+    price INT NOT NULL DEFAULT 0,                     -- цена места на момент создания (для возвратов)
+    pay_for_all BOOLEAN NOT NULL DEFAULT FALSE,       -- владелец оплачивает места всех участников
+    expires_at TIMESTAMP,                             -- срок ответа на приглашения
+    started_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_shared_quests_pending ON shared_quests(expires_at) WHERE status = 'pending';
+
+-- Участники совместного квеста (включая владельца)
+CREATE TABLE shared_quest_members (
+    shared_quest_id INTEGER NOT NULL REFERENCES shared_quests(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'invited',    -- 'invited', 'accepted', 'declined', 'expired', 'withdrawn'
+    paid_by INTEGER REFERENCES users(id) ON DELETE SET NULL, -- кто оплатил место (NULL - не оплачено)
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (shared_quest_id, user_id)
+);
+
+CREATE INDEX idx_shared_quest_members_user ON shared_quest_members(user_id);
+
+-- Отзывы и оценки квестов (после завершения или провала)
+CREATE TABLE quest_reviews (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id INTEGER NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    difficulty_rating INT NOT NULL CHECK (difficulty_rating BETWEEN 1 AND 5), -- воспринимаемая сложность
+    review_text TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, quest_id)
+);
+
+CREATE INDEX idx_quest_reviews_quest_id ON quest_reviews(quest_id);
+
+-- Цепочки квестов (продолжения: Champion Wake Up -> Champion Wake Up II -> ...)
+CREATE TABLE quest_chains (
+    id SERIAL PRIMARY KEY,
+    pack_key VARCHAR(255) UNIQUE NOT NULL,
+    title VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE quest_chain_items (
+    id SERIAL PRIMARY KEY,
+    chain_id INTEGER NOT NULL REFERENCES quest_chains(id) ON DELETE CASCADE,
+    quest_id INTEGER NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    UNIQUE(chain_id, position),
+    UNIQUE(chain_id, quest_id)
+);
+
+-- Параметризованные шаблоны квестов ("вставать в {{wake_time}} {{days}} дней")
+CREATE TABLE quest_templates (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,               -- с плейсхолдерами {{param}}
+    description TEXT NOT NULL DEFAULT '',
+    category VARCHAR(255) NOT NULL,
+    rarity VARCHAR(255) NOT NULL,
+    difficulty INT NOT NULL DEFAULT 0,
+    is_sequential BOOLEAN NOT NULL DEFAULT TRUE,
+    params_json JSONB NOT NULL DEFAULT '[]',   -- [{name, type, min, max, default, options}]
+    tasks_json JSONB NOT NULL DEFAULT '[]',    -- [{title, description, ..., base_xp_formula, base_coin_formula, repeat_formula}]
+    price_formula VARCHAR(255) NOT NULL DEFAULT '',
+    reward_xp_formula VARCHAR(255) NOT NULL,
+    reward_coin_formula VARCHAR(255) NOT NULL,
+    time_limit_hours_formula VARCHAR(255) NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Подарки друзьям (монеты или квест)
+CREATE TABLE gifts (
+    id SERIAL PRIMARY KEY,
+    sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,                  -- 'coins', 'quest'
+    amount INT NOT NULL DEFAULT 0,              -- монеты (для квеста - его цена)
+    quest_id INTEGER REFERENCES quests(id) ON DELETE SET NULL,
+    message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_gifts_sender_created ON gifts(sender_id, created_at);
+
+-- Уведомления пользователей
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    payload JSONB,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_created ON notifications(user_id, created_at DESC);
+
+-- Каталог предметов магазина
+CREATE TABLE items (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price INT NOT NULL CHECK (price >= 0),
+    effect_type VARCHAR(50) NOT NULL,           -- 'streak_freeze', 'xp_booster', 'timer_extender'
+    effect_value INT NOT NULL DEFAULT 0,        -- xp_booster: множитель в процентах (150 = x1.5), timer_extender: часы
+    duration_hours INT NOT NULL DEFAULT 0,      -- xp_booster: время действия
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Инвентарь пользователя (предметы по коду: streak_freeze и т.д.)
+CREATE TABLE user_inventory (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_code VARCHAR(100) NOT NULL,
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (user_id, item_code)
+);
+
+-- Действующие эффекты использованных предметов (бустеры опыта)
+CREATE TABLE user_active_effects (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_code VARCHAR(100) NOT NULL,
+    effect_type VARCHAR(50) NOT NULL,
+    effect_value INT NOT NULL,
+    starts_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_user_active_effects_user ON user_active_effects(user_id, effect_type, expires_at);
+
+-- Состояние календаря ежедневных наград
+CREATE TABLE user_daily_rewards (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    current_day INT NOT NULL DEFAULT 0,         -- последний полученный день календаря (1..N)
+    last_claim_date DATE,                       -- локальная дата пользователя
+    last_claimed_at TIMESTAMPTZ,                -- момент последнего получения (UTC)
+    last_claim_timezone VARCHAR(64)             -- часовой пояс, в котором была получена награда
+);
+
+-- История получения ежедневных наград
+CREATE TABLE daily_reward_claims (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    claim_date DATE NOT NULL,                   -- локальная дата пользователя
+    calendar_day INT NOT NULL,
+    reward_kind VARCHAR(50) NOT NULL,           -- 'coins', 'xp', 'item'
+    amount INT NOT NULL,
+    item_code VARCHAR(100),
+    protections_used INT NOT NULL DEFAULT 0,    -- сколько streak_freeze потрачено на пропущенные дни
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, claim_date)
+);
+
+-- Сезонные события
+CREATE TABLE events (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    xp_multiplier INT NOT NULL DEFAULT 100 CHECK (xp_multiplier >= 100),     -- в процентах
+    coin_multiplier INT NOT NULL DEFAULT 100 CHECK (coin_multiplier >= 100), -- в процентах
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',                         -- 'scheduled', 'active', 'ended'
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_events_status ON events(status);
+
+-- Эксклюзивные квесты события (квест может принадлежать только одному событию)
+CREATE TABLE event_quests (
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    quest_id INTEGER NOT NULL UNIQUE REFERENCES quests(id) ON DELETE CASCADE,
+    PRIMARY KEY (event_id, quest_id)
+);
+
+-- Очки пользователей в событии (для таблицы лидеров)
+CREATE TABLE event_scores (
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    xp_earned INT NOT NULL DEFAULT 0,
+    coins_earned INT NOT NULL DEFAULT 0,
+    quests_completed INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX idx_event_scores_leaderboard ON event_scores(event_id, xp_earned DESC);
+
+-- История затухания прогресса (чтобы пользователь видел, за что потерял уровень/опыт)
+CREATE TABLE user_decay_history (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL,                -- 'inactivity', 'failed_quest'
+    quest_id INTEGER REFERENCES quests(id) ON DELETE SET NULL,
+    xp_lost INT NOT NULL DEFAULT 0,
+    level_before INT NOT NULL,
+    level_after INT NOT NULL,
+    attribute_changes JSONB,                    -- например {"health_level": -1}
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_decay_history_user_created ON user_decay_history(user_id, created_at DESC);
+
+-- Подозрительные выполнения задач (anti-cheat), награда удерживается до решения администратора
+CREATE TABLE task_completion_reviews (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id INTEGER NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    flags TEXT[] NOT NULL,                      -- 'completions_per_hour', 'min_gap', 'faster_than_duration', 'previously_rejected'
+    xp_amount INT NOT NULL,                     -- удержанная награда
+    coin_amount INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'approved', 'rejected'
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    comment TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_completion_reviews_status ON task_completion_reviews(status, created_at);
+CREATE INDEX idx_task_completion_reviews_user_task ON task_completion_reviews(user_id, task_id);
+
+-- Блокировки пользователей: заблокированный не может отправлять заявки в друзья,
+-- приглашать в совместные квесты и не попадает в рекомендации (в обе стороны)
+CREATE TABLE user_blocks (
+    blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_user_blocks_blocked ON user_blocks(blocked_id);
+
+-- Команды (гильдии)
+CREATE TABLE teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_code VARCHAR(32) NOT NULL UNIQUE,
+    xp_pool BIGINT NOT NULL DEFAULT 0,          -- опыт, накопленный участниками за квесты
+    quests_completed INT NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
+
+-- Участники команды (пользователь может состоять только в одной команде)
+CREATE TABLE team_members (
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member', -- 'owner', 'officer', 'member'
+    xp_contributed BIGINT NOT NULL DEFAULT 0,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, user_id)
+);
+
+-- Достижения команд: открываются, когда метрика команды достигает порога
+CREATE TABLE team_achievements (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    metric VARCHAR(50) NOT NULL,                -- 'xp_pool', 'quests_completed', 'members', 'team_quests_completed'
+    threshold BIGINT NOT NULL
+);
+
+CREATE TABLE team_unlocked_achievements (
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    achievement_id INTEGER NOT NULL REFERENCES team_achievements(id) ON DELETE CASCADE,
+    unlocked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, achievement_id)
+);
+
+-- Командные квесты: цель - чтобы target_completions участников завершили квест
+CREATE TABLE team_quests (
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    quest_id INTEGER NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    target_completions INT NOT NULL DEFAULT 1,
+    completions INT NOT NULL DEFAULT 0,
+    added_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (team_id, quest_id)
+);
+
+-- Заработанный опыт и завершенные квесты, заранее агрегированные по периодам таблиц лидеров (UTC):
+-- period 'day' | 'week' (period_start - понедельник) | 'all' (period_start = 0001-01-01)
+CREATE TABLE user_xp_periods (
+    period VARCHAR(10) NOT NULL,
+    period_start DATE NOT NULL,
+    category VARCHAR(255) NOT NULL DEFAULT '', -- '' - все категории и опыт вне квестов
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    xp_earned INT NOT NULL DEFAULT 0,
+    quests_completed INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (period, period_start, category, user_id)
+);
+
+-- Страница таблицы лидеров за период читается по индексу в порядке (score DESC, user_id)
+CREATE INDEX idx_user_xp_periods_xp ON user_xp_periods(period, period_start, category, xp_earned DESC, user_id);
+CREATE INDEX idx_user_xp_periods_quests ON user_xp_periods(period, period_start, category, quests_completed DESC, user_id);
+
+-- Таблицы лидеров за все время читают users по индексу
+CREATE INDEX idx_users_leaderboard_xp ON users(xp_points DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_level ON users(level DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_streak ON users(current_streak DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_quests ON users(quests_completed DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_health ON users(health_level DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_mental_health ON users(mental_health_level DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_intelligence ON users(intelligence_level DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_charisma ON users(charisma_level DESC, id) WHERE NOT leaderboard_opt_out;
+CREATE INDEX idx_users_leaderboard_willpower ON users(willpower_level DESC, id) WHERE NOT leaderboard_opt_out;
+
+-- Лента активности: события пользователя, которые видят его друзья
+CREATE TABLE activity_events (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL, -- 'task_completed', 'quest_completed', 'level_up', 'achievement_unlocked', 'quest_purchased', 'item_purchased'
+    payload JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_activity_events_user_created ON activity_events(user_id, created_at DESC);
+
+-- Настройки приватности: какие события видят друзья и кому видны поля профиля
+-- (нет строки - значения по умолчанию)
+CREATE TABLE user_privacy_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    share_tasks BOOLEAN NOT NULL DEFAULT TRUE,
+    share_quests BOOLEAN NOT NULL DEFAULT TRUE,
+    share_level_ups BOOLEAN NOT NULL DEFAULT TRUE,
+    share_achievements BOOLEAN NOT NULL DEFAULT TRUE,
+    share_purchases BOOLEAN NOT NULL DEFAULT FALSE,
+
+    -- видимость профиля и его полей: 'public', 'friends', 'private'
+    profile_visibility VARCHAR(20) NOT NULL DEFAULT 'public',
+    stats_visibility VARCHAR(20) NOT NULL DEFAULT 'public',      -- уровень, опыт, серии, число квестов
+    attributes_visibility VARCHAR(20) NOT NULL DEFAULT 'public', -- уровни веток
+    badges_visibility VARCHAR(20) NOT NULL DEFAULT 'public',     -- достижения
+    quests_visibility VARCHAR(20) NOT NULL DEFAULT 'friends',    -- завершенные квесты
+
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Реакции (kudos/cheer) и комментарии к событиям ленты и выполненным задачам совместных квестов.
+-- target_type: 'activity' - activity_events.id, 'task' - user_tasks.id
+CREATE TABLE social_reactions (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- 'kudos', 'cheer'
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (target_type, target_id, user_id, kind)
+);
+
+CREATE INDEX idx_social_reactions_target ON social_reactions(target_type, target_id);
+
+CREATE TABLE social_comments (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_social_comments_target ON social_comments(target_type, target_id, created_at);
+
+-- У target_id нет внешнего ключа: реакции и комментарии удаляются вместе с объектом триггерами
+-- (в том числе при каскадном удалении событий и задач вместе с пользователем или квестом)
+CREATE OR REPLACE FUNCTION delete_social_for_target() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM social_reactions WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+    DELETE FROM social_comments WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_activity_events_social_cleanup
+    AFTER DELETE ON activity_events
+    FOR EACH ROW EXECUTE FUNCTION delete_social_for_target('activity');
+
+CREATE TRIGGER trg_user_tasks_social_cleanup
+    AFTER DELETE ON user_tasks
+    FOR EACH ROW EXECUTE FUNCTION delete_social_for_target('task');
+
+-- Дуэли между друзьями: обе стороны ставят монеты (удерживаются до итога), победитель забирает банк.
+-- kind: 'quest' - кто первым завершит квест quest_id, 'category' - больше задач категории к сроку
+CREATE TABLE duels (
+    id SERIAL PRIMARY KEY,
+    challenger_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    opponent_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    quest_id INTEGER REFERENCES quests(id) ON DELETE CASCADE,
+    category VARCHAR(255),
+    stake INT NOT NULL,
+    duration_hours INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'active', 'completed', 'declined', 'cancelled', 'expired'
+    winner_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- NULL у завершенной дуэли - ничья
+    challenger_score INT NOT NULL DEFAULT 0,
+    opponent_score INT NOT NULL DEFAULT 0,
+    respond_by TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (challenger_id <> opponent_id)
+);
+
+CREATE INDEX idx_duels_challenger ON duels(challenger_id, created_at DESC);
+CREATE INDEX idx_duels_opponent ON duels(opponent_id, created_at DESC);
+CREATE INDEX idx_duels_pending ON duels(respond_by) WHERE status = 'pending';
+CREATE INDEX idx_duels_active ON duels(ends_at) WHERE status = 'active';
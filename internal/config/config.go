@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +16,8 @@ type Config struct {
 	TokenExpireHours                int
 	APIKeyIntelligenceIO            string
 	Recommendation_Service_BASE_URL string
+	AdminUserIDs                    []int
+	EconomyConfigPath               string
 }
 
 func NewConfig() Config {
@@ -29,7 +34,44 @@ func NewConfig() Config {
 		TokenExpireHours:                24,
 		APIKeyIntelligenceIO:            os.Getenv("API_KEY_INTELLIGENCE_IO"),
 		Recommendation_Service_BASE_URL: "http://localhost:8000/api",
+		AdminUserIDs:                    parseIntList(os.Getenv("ADMIN_USER_IDS")),
+		EconomyConfigPath:               getEnv("ECONOMY_CONFIG_PATH", DefaultEconomyConfigPath),
 	}
 }
 
-var Cfg = NewConfig()
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// parseIntList разбирает список вида "1,2,3" (невалидные элементы пропускаются)
+func parseIntList(s string) []int {
+	var result []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			log.Printf("invalid int in list %q: %v", s, err)
+			continue
+		}
+		result = append(result, n)
+	}
+	return result
+}
+
+var (
+	cfg     Config
+	cfgOnce sync.Once
+)
+
+// Get возвращает конфигурацию приложения; .env читается при первом обращении,
+// поэтому пакеты, импортирующие config, можно тестировать без него
+func Get() *Config {
+	cfgOnce.Do(func() { cfg = NewConfig() })
+	return &cfg
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Типы кривой прогрессии уровней (порог уровня L, base - level_curve.base_xp):
+//
+//	linear      - base * (L-1)
+//	quadratic   - base * (L-1)^2
+//	exponential - base * (growth^(L-1) - 1) / (growth - 1)
+const (
+	LevelCurveLinear      = "linear"
+	LevelCurveQuadratic   = "quadratic"
+	LevelCurveExponential = "exponential"
+)
+
+var levelCurveTypes = []string{LevelCurveLinear, LevelCurveQuadratic, LevelCurveExponential}
+
+// EconomyConfig - параметры прогрессии и экономики (economy.yaml).
+// Загружается при старте и может быть перечитан без перезапуска (ReloadEconomy).
+type EconomyConfig struct {
+	LevelCurve            LevelCurve   `json:"level_curve" yaml:"level_curve"`
+	MaxLevel              int          `json:"max_level" yaml:"max_level"`
+	AttributeCap          int          `json:"attribute_cap" yaml:"attribute_cap"`                       // максимум уровня ветки (health_level и т.д.)
+	AttributeGainPerQuest int          `json:"attribute_gain_per_quest" yaml:"attribute_gain_per_quest"` // рост ветки за завершенный квест ее категории
+	Quest                 QuestEconomy `json:"quest" yaml:"quest"`
+	Decay                 DecayConfig  `json:"decay" yaml:"decay"`
+	AntiCheat             AntiCheat    `json:"anti_cheat" yaml:"anti_cheat"`
+	Teams                 TeamsConfig  `json:"teams" yaml:"teams"`
+	Duels                 DuelsConfig  `json:"duels" yaml:"duels"`
+	Gifts                 GiftsConfig  `json:"gifts" yaml:"gifts"`
+}
+
+type LevelCurve struct {
+	Type   string  `json:"type" yaml:"type"`
+	BaseXP float64 `json:"base_xp" yaml:"base_xp"`
+	Growth float64 `json:"growth,omitempty" yaml:"growth"` // только для exponential
+}
+
+// QuestEconomy - соотношения цены и наград квестов и допустимые диапазоны
+type QuestEconomy struct {
+	PriceToRewardCoin  float64  `json:"price_to_reward_coin" yaml:"price_to_reward_coin"`   // price = reward_coin * k
+	RewardToTasksRatio float64  `json:"reward_to_tasks_ratio" yaml:"reward_to_tasks_ratio"` // reward_xp/coin = сумма наград задач * k
+	MaxDifficulty      int      `json:"max_difficulty" yaml:"max_difficulty"`
+	Difficulty         IntRange `json:"difficulty" yaml:"difficulty"`
+	TasksCount         IntRange `json:"tasks_count" yaml:"tasks_count"`
+	TimeLimitHours     IntRange `json:"time_limit_hours" yaml:"time_limit_hours"`
+	TaskDifficulty     IntRange `json:"task_difficulty" yaml:"task_difficulty"`
+	TaskXP             IntRange `json:"task_xp" yaml:"task_xp"`
+	TaskCoin           IntRange `json:"task_coin" yaml:"task_coin"`
+}
+
+// DecayConfig - затухание прогресса: при неактивности и за проваленные квесты
+type DecayConfig struct {
+	Enabled        bool `json:"enabled" yaml:"enabled"`
+	InactivityDays int  `json:"inactivity_days" yaml:"inactivity_days"` // сколько дней без активности до начала затухания
+	PeriodDays     int  `json:"period_days" yaml:"period_days"`         // как часто повторяется затухание, пока пользователь неактивен
+	AttributeLoss  int  `json:"attribute_loss" yaml:"attribute_loss"`   // на сколько снижается каждая ветка за период
+	AttributeFloor int  `json:"attribute_floor" yaml:"attribute_floor"` // ниже этого уровня ветки не опускаются
+
+	LevelDecay    bool `json:"level_decay" yaml:"level_decay"`         // снижать ли общий опыт (и уровень)
+	XPLossPercent int  `json:"xp_loss_percent" yaml:"xp_loss_percent"` // % опыта за период
+	LevelFloor    int  `json:"level_floor" yaml:"level_floor"`         // ниже этого уровня опыт не снижается
+
+	FailedQuestAttributeLoss int `json:"failed_quest_attribute_loss" yaml:"failed_quest_attribute_loss"` // штраф ветке категории за проваленный квест
+}
+
+// AntiCheat - правила проверки выполнения задач.
+// Нарушение жестких правил (min_*, max_*) отклоняет выполнение, срабатывание flag_* - отправляет его
+// на проверку администратору с удержанием награды.
+type AntiCheat struct {
+	Enabled               bool    `json:"enabled" yaml:"enabled"`
+	MinSecondsSinceStart  int     `json:"min_seconds_since_start" yaml:"min_seconds_since_start"`   // от начала квеста (или scheduled_start задачи)
+	MinDurationRatio      float64 `json:"min_duration_ratio" yaml:"min_duration_ratio"`             // доля duration задачи, раньше которой выполнить нельзя
+	MaxCompletionsPerHour int     `json:"max_completions_per_hour" yaml:"max_completions_per_hour"` // 0 - без лимита
+
+	FlagCompletionsPerHour int     `json:"flag_completions_per_hour" yaml:"flag_completions_per_hour"` // 0 - не проверять
+	FlagMinGapSeconds      int     `json:"flag_min_gap_seconds" yaml:"flag_min_gap_seconds"`           // между двумя выполнениями подряд
+	FlagDurationRatio      float64 `json:"flag_duration_ratio" yaml:"flag_duration_ratio"`             // быстрее этой доли duration - подозрительно
+}
+
+// TeamsConfig - ограничения команд и пополнение командного пула опыта
+type TeamsConfig struct {
+	MaxMembers    int `json:"max_members" yaml:"max_members"`
+	XPPoolPercent int `json:"xp_pool_percent" yaml:"xp_pool_percent"` // какой % опыта участника за квест идет в пул команды
+}
+
+// DuelsConfig - ставки и сроки дуэлей между друзьями
+type DuelsConfig struct {
+	MinStake         int `json:"min_stake" yaml:"min_stake"`
+	MaxStake         int `json:"max_stake" yaml:"max_stake"`
+	MaxDurationHours int `json:"max_duration_hours" yaml:"max_duration_hours"` // максимальная длительность дуэли
+	AcceptHours      int `json:"accept_hours" yaml:"accept_hours"`             // сколько часов соперник может принять вызов
+}
+
+// GiftsConfig - дневные лимиты подарков на отправителя
+type GiftsConfig struct {
+	DailyCoinsLimit  int `json:"daily_coins_limit" yaml:"daily_coins_limit"`   // монет в сутки
+	DailyQuestsLimit int `json:"daily_quests_limit" yaml:"daily_quests_limit"` // квестов в сутки
+}
+
+type IntRange struct {
+	Min int `json:"min" yaml:"min"`
+	Max int `json:"max" yaml:"max"`
+}
+
+// Clamp ограничивает значение диапазоном
+func (r IntRange) Clamp(v int) int {
+	return min(max(v, r.Min), r.Max)
+}
+
+// DefaultEconomy - значения по умолчанию (совпадают с исходными захардкоженными правилами)
+func DefaultEconomy() EconomyConfig {
+	return EconomyConfig{
+		LevelCurve:            LevelCurve{Type: LevelCurveQuadratic, BaseXP: 100},
+		MaxLevel:              100,
+		AttributeCap:          10,
+		AttributeGainPerQuest: 1,
+		Quest: QuestEconomy{
+			PriceToRewardCoin:  1.5,
+			RewardToTasksRatio: 1.5,
+			MaxDifficulty:      10,
+			Difficulty:         IntRange{Min: 1, Max: 5},
+			TasksCount:         IntRange{Min: 3, Max: 7},
+			TimeLimitHours:     IntRange{Min: 24, Max: 168},
+			TaskDifficulty:     IntRange{Min: 1, Max: 3},
+			TaskXP:             IntRange{Min: 10, Max: 50},
+			TaskCoin:           IntRange{Min: 5, Max: 25},
+		},
+		Decay: DecayConfig{
+			Enabled:                  true,
+			InactivityDays:           7,
+			PeriodDays:               1,
+			AttributeLoss:            1,
+			AttributeFloor:           1,
+			LevelDecay:               false,
+			XPLossPercent:            5,
+			LevelFloor:               1,
+			FailedQuestAttributeLoss: 1,
+		},
+		AntiCheat: AntiCheat{
+			Enabled:                true,
+			MinSecondsSinceStart:   60,
+			MinDurationRatio:       0.5,
+			MaxCompletionsPerHour:  20,
+			FlagCompletionsPerHour: 10,
+			FlagMinGapSeconds:      30,
+			FlagDurationRatio:      0.8,
+		},
+		Teams: TeamsConfig{
+			MaxMembers:    20,
+			XPPoolPercent: 100,
+		},
+		Duels: DuelsConfig{
+			MinStake:         10,
+			MaxStake:         1000,
+			MaxDurationHours: 168,
+			AcceptHours:      24,
+		},
+		Gifts: GiftsConfig{
+			DailyCoinsLimit:  500,
+			DailyQuestsLimit: 3,
+		},
+	}
+}
+
+func (c *EconomyConfig) Validate() error {
+	var errs []error
+
+	if !slices.Contains(levelCurveTypes, c.LevelCurve.Type) {
+		errs = append(errs, fmt.Errorf("level_curve.type must be one of %v", levelCurveTypes))
+	}
+	if c.LevelCurve.BaseXP <= 0 {
+		errs = append(errs, errors.New("level_curve.base_xp must be > 0"))
+	}
+	if c.LevelCurve.Type == LevelCurveExponential && c.LevelCurve.Growth <= 1 {
+		errs = append(errs, errors.New("level_curve.growth must be > 1 for exponential curve"))
+	}
+	if c.MaxLevel < 1 {
+		errs = append(errs, errors.New("max_level must be >= 1"))
+	}
+	if c.AttributeCap < 1 {
+		errs = append(errs, errors.New("attribute_cap must be >= 1"))
+	}
+	if c.AttributeGainPerQuest < 0 || c.AttributeGainPerQuest > c.AttributeCap {
+		errs = append(errs, errors.New("attribute_gain_per_quest must be between 0 and attribute_cap"))
+	}
+	if c.Quest.PriceToRewardCoin < 0 || c.Quest.RewardToTasksRatio < 0 {
+		errs = append(errs, errors.New("quest ratios must be >= 0"))
+	}
+	if c.Quest.MaxDifficulty < 1 {
+		errs = append(errs, errors.New("quest.max_difficulty must be >= 1"))
+	}
+	ranges := map[string]IntRange{
+		"difficulty":       c.Quest.Difficulty,
+		"tasks_count":      c.Quest.TasksCount,
+		"time_limit_hours": c.Quest.TimeLimitHours,
+		"task_difficulty":  c.Quest.TaskDifficulty,
+		"task_xp":          c.Quest.TaskXP,
+		"task_coin":        c.Quest.TaskCoin,
+	}
+	for name, r := range ranges {
+		if r.Min < 0 || r.Min > r.Max {
+			errs = append(errs, fmt.Errorf("quest.%s: need 0 <= min <= max", name))
+		}
+	}
+
+	d := c.Decay
+	if d.InactivityDays < 1 || d.PeriodDays < 1 {
+		errs = append(errs, errors.New("decay.inactivity_days and decay.period_days must be >= 1"))
+	}
+	if d.AttributeLoss < 0 || d.FailedQuestAttributeLoss < 0 {
+		errs = append(errs, errors.New("decay attribute losses must be >= 0"))
+	}
+	if d.AttributeFloor < 0 || d.AttributeFloor > c.AttributeCap {
+		errs = append(errs, errors.New("decay.attribute_floor must be between 0 and attribute_cap"))
+	}
+	if d.XPLossPercent < 0 || d.XPLossPercent > 100 {
+		errs = append(errs, errors.New("decay.xp_loss_percent must be between 0 and 100"))
+	}
+	if d.LevelFloor < 1 || d.LevelFloor > c.MaxLevel {
+		errs = append(errs, errors.New("decay.level_floor must be between 1 and max_level"))
+	}
+
+	a := c.AntiCheat
+	if a.MinSecondsSinceStart < 0 || a.MaxCompletionsPerHour < 0 || a.FlagCompletionsPerHour < 0 || a.FlagMinGapSeconds < 0 {
+		errs = append(errs, errors.New("anti_cheat limits must be >= 0"))
+	}
+	if a.MinDurationRatio < 0 || a.MinDurationRatio > 1 || a.FlagDurationRatio < 0 || a.FlagDurationRatio > 1 {
+		errs = append(errs, errors.New("anti_cheat duration ratios must be between 0 and 1"))
+	}
+
+	if c.Teams.MaxMembers < 1 {
+		errs = append(errs, errors.New("teams.max_members must be >= 1"))
+	}
+	if c.Teams.XPPoolPercent < 0 {
+		errs = append(errs, errors.New("teams.xp_pool_percent must be >= 0"))
+	}
+
+	if c.Duels.MinStake < 1 || c.Duels.MinStake > c.Duels.MaxStake {
+		errs = append(errs, errors.New("duels: need 1 <= min_stake <= max_stake"))
+	}
+	if c.Duels.MaxDurationHours < 1 || c.Duels.AcceptHours < 1 {
+		errs = append(errs, errors.New("duels.max_duration_hours and duels.accept_hours must be >= 1"))
+	}
+
+	if c.Gifts.DailyCoinsLimit < 0 || c.Gifts.DailyQuestsLimit < 0 {
+		errs = append(errs, errors.New("gifts daily limits must be >= 0"))
+	}
+
+	// Пороги должны строго расти, иначе уровень по опыту не определяется однозначно
+	if len(errs) == 0 && c.MaxLevel > 1 && c.XPForLevel(c.MaxLevel) <= c.XPForLevel(c.MaxLevel-1) {
+		errs = append(errs, errors.New("level curve overflows before max_level"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// MaxQuestDifficulty - предел сложности квеста и его задач: не выше quest.max_difficulty
+// и максимального уровня ветки (attribute_cap), которую квест прокачивает
+func (c *EconomyConfig) MaxQuestDifficulty() int {
+	return min(c.Quest.MaxDifficulty, c.AttributeCap)
+}
+
+// XPForLevel возвращает суммарный опыт, необходимый для уровня level (для 1-го уровня - 0)
+func (c *EconomyConfig) XPForLevel(level int) int {
+	if level <= 1 {
+		return 0
+	}
+	n := float64(level - 1)
+
+	var xp float64
+	switch c.LevelCurve.Type {
+	case LevelCurveLinear:
+		xp = c.LevelCurve.BaseXP * n
+	case LevelCurveExponential:
+		g := c.LevelCurve.Growth
+		xp = c.LevelCurve.BaseXP * (math.Pow(g, n) - 1) / (g - 1)
+	default:
+		xp = c.LevelCurve.BaseXP * n * n
+	}
+
+	if xp >= math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(math.Ceil(xp))
+}
+
+// LevelForXP возвращает уровень для суммарного опыта (не выше max_level)
+func (c *EconomyConfig) LevelForXP(xp int) int {
+	level := 1
+	for level < c.MaxLevel && xp >= c.XPForLevel(level+1) {
+		level++
+	}
+	return level
+}
+
+var economy atomic.Pointer[EconomyConfig]
+
+// Economy возвращает текущую конфигурацию экономики (значения по умолчанию, если файл не загружен)
+func Economy() *EconomyConfig {
+	if c := economy.Load(); c != nil {
+		return c
+	}
+	c := DefaultEconomy()
+	return &c
+}
+
+// DefaultEconomyConfigPath - путь к конфигурации экономики, если ECONOMY_CONFIG_PATH не задан
+const DefaultEconomyConfigPath = "economy.yaml"
+
+// LoadEconomy читает и проверяет конфигурацию экономики (YAML или JSON).
+// Незаданные в файле поля берутся по умолчанию. Если нет файла по пути по умолчанию - используются
+// значения по умолчанию; явно заданный через ECONOMY_CONFIG_PATH файл обязан существовать.
+// При ошибке текущая конфигурация не меняется.
+func LoadEconomy(path string) error {
+	c := DefaultEconomy()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && path == DefaultEconomyConfigPath:
+		log.Printf("economy config %q not found, using defaults", path)
+	case err != nil:
+		return fmt.Errorf("failed to read economy config: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("failed to parse economy config %q: %w", path, err)
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid economy config %q: %w", path, err)
+	}
+
+	economy.Store(&c)
+	return nil
+}
+
+// ReloadEconomy перечитывает конфигурацию экономики из ECONOMY_CONFIG_PATH
+func ReloadEconomy() error {
+	return LoadEconomy(Get().EconomyConfigPath)
+}
@@ -0,0 +1,132 @@
+package config
+
+import "testing"
+
+func TestLevelForXP(t *testing.T) {
+	quadratic := DefaultEconomy()
+
+	linear := DefaultEconomy()
+	linear.LevelCurve = LevelCurve{Type: LevelCurveLinear, BaseXP: 50}
+	linear.MaxLevel = 10
+
+	exponential := DefaultEconomy()
+	exponential.LevelCurve = LevelCurve{Type: LevelCurveExponential, BaseXP: 100, Growth: 2}
+
+	tests := []struct {
+		name   string
+		config EconomyConfig
+		xp     int
+		want   int
+	}{
+		{name: "quadratic zero xp", config: quadratic, xp: 0, want: 1},
+		{name: "quadratic just below level 2", config: quadratic, xp: 99, want: 1},
+		{name: "quadratic level 2 threshold", config: quadratic, xp: 100, want: 2},
+		{name: "quadratic just below level 3", config: quadratic, xp: 399, want: 2},
+		{name: "quadratic level 3 threshold", config: quadratic, xp: 400, want: 3},
+		{name: "quadratic level 4", config: quadratic, xp: 1500, want: 4},
+		{name: "quadratic capped at max level", config: quadratic, xp: 1 << 30, want: 100},
+		{name: "negative xp stays at level 1", config: quadratic, xp: -10, want: 1},
+		{name: "linear threshold", config: linear, xp: 100, want: 3},
+		{name: "linear between thresholds", config: linear, xp: 149, want: 3},
+		{name: "linear capped at max level", config: linear, xp: 10_000, want: 10},
+		{name: "exponential level 2", config: exponential, xp: 100, want: 2},
+		{name: "exponential just below level 3", config: exponential, xp: 299, want: 2},
+		{name: "exponential level 3", config: exponential, xp: 300, want: 3},
+		{name: "exponential level 4", config: exponential, xp: 700, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.LevelForXP(tt.xp); got != tt.want {
+				t.Errorf("LevelForXP(%d) = %d, want %d", tt.xp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXPForLevelMatchesLevelForXP(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve LevelCurve
+	}{
+		{name: "linear", curve: LevelCurve{Type: LevelCurveLinear, BaseXP: 75}},
+		{name: "quadratic", curve: LevelCurve{Type: LevelCurveQuadratic, BaseXP: 100}},
+		{name: "exponential", curve: LevelCurve{Type: LevelCurveExponential, BaseXP: 100, Growth: 1.05}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultEconomy()
+			c.LevelCurve = tt.curve
+			if err := c.Validate(); err != nil {
+				t.Fatalf("Validate() = %v", err)
+			}
+			for level := 2; level <= c.MaxLevel; level++ {
+				threshold := c.XPForLevel(level)
+				if got := c.LevelForXP(threshold); got != level {
+					t.Fatalf("LevelForXP(XPForLevel(%d) = %d) = %d", level, threshold, got)
+				}
+				if got := c.LevelForXP(threshold - 1); got != level-1 {
+					t.Fatalf("LevelForXP(XPForLevel(%d)-1 = %d) = %d, want %d", level, threshold-1, got, level-1)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRejectsInvalidEconomy(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *EconomyConfig)
+	}{
+		{name: "unknown curve", modify: func(c *EconomyConfig) { c.LevelCurve.Type = "cubic" }},
+		{name: "non-positive base xp", modify: func(c *EconomyConfig) { c.LevelCurve.BaseXP = 0 }},
+		{name: "exponential without growth", modify: func(c *EconomyConfig) { c.LevelCurve = LevelCurve{Type: LevelCurveExponential, BaseXP: 100, Growth: 1} }},
+		{name: "exponential overflow before max level", modify: func(c *EconomyConfig) {
+			c.LevelCurve = LevelCurve{Type: LevelCurveExponential, BaseXP: 100, Growth: 2}
+		}},
+		{name: "zero max level", modify: func(c *EconomyConfig) { c.MaxLevel = 0 }},
+		{name: "attribute gain above cap", modify: func(c *EconomyConfig) { c.AttributeGainPerQuest = c.AttributeCap + 1 }},
+		{name: "negative gift limit", modify: func(c *EconomyConfig) { c.Gifts.DailyCoinsLimit = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultEconomy()
+			tt.modify(&c)
+			if err := c.Validate(); err == nil {
+				t.Error("Validate() = nil, want error")
+			}
+		})
+	}
+
+	t.Run("defaults are valid", func(t *testing.T) {
+		c := DefaultEconomy()
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validate() = %v", err)
+		}
+	})
+}
+
+func TestMaxQuestDifficulty(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxDifficulty int
+		attributeCap  int
+		want          int
+	}{
+		{name: "bounded by max difficulty", maxDifficulty: 5, attributeCap: 10, want: 5},
+		{name: "bounded by attribute cap", maxDifficulty: 10, attributeCap: 7, want: 7},
+		{name: "equal limits", maxDifficulty: 10, attributeCap: 10, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultEconomy()
+			c.Quest.MaxDifficulty, c.AttributeCap = tt.maxDifficulty, tt.attributeCap
+			if got := c.MaxQuestDifficulty(); got != tt.want {
+				t.Errorf("MaxQuestDifficulty() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler - ручки для администрирования каталога и экономики
+type AdminHandler struct {
+	questService  *services.QuestService
+	ledgerService *services.LedgerService
+}
+
+func NewAdminHandler(questService *services.QuestService, ledgerService *services.LedgerService) *AdminHandler {
+	return &AdminHandler{questService: questService, ledgerService: ledgerService}
+}
+
+// ExportQuestPack выгружает каталог квестов (?format=json|yaml)
+func (h *AdminHandler) ExportQuestPack(c *gin.Context) {
+	format, err := services.ParseQuestPackFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pack, err := h.questService.ExportQuestPack(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := services.EncodeQuestPack(pack, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "application/json"
+	if format == services.QuestPackFormatYAML {
+		contentType = "application/yaml"
+	}
+	c.Header("Content-Disposition", "attachment; filename=quest-pack."+format)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportQuestPack загружает каталог квестов из тела запроса (?format=json|yaml&dry_run=true)
+func (h *AdminHandler) ImportQuestPack(c *gin.Context) {
+	format, err := services.ParseQuestPackFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dryRun := false
+	if s := c.Query("dry_run"); s != "" {
+		dryRun, err = strconv.ParseBool(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run"})
+			return
+		}
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body: " + err.Error()})
+		return
+	}
+
+	pack, err := services.DecodeQuestPack(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.questService.ImportQuestPack(c.Request.Context(), pack, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if report.HasErrors() {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// LedgerReconciliation сверяет coin_balance пользователей с журналом транзакций и возвращает расхождения
+func (h *AdminHandler) LedgerReconciliation(c *gin.Context) {
+	report, err := h.ledgerService.Reconcile(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RegisterAdminRoutes sets up admin-only routes
+func RegisterAdminRoutes(router *gin.Engine, questService *services.QuestService, ledgerService *services.LedgerService) {
+	handler := NewAdminHandler(questService, ledgerService)
+
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(middleware.JWTAuthMiddleware(), middleware.AdminOnlyMiddleware())
+	{
+		adminGroup.GET("/quest-packs/export", handler.ExportQuestPack)
+		adminGroup.POST("/quest-packs/import", handler.ImportQuestPack)
+
+		adminGroup.POST("/quest-templates", handler.CreateQuestTemplate)
+		adminGroup.POST("/quest-templates/:templateID/instantiate", handler.InstantiateQuestTemplate)
+
+		adminGroup.GET("/ledger/reconciliation", handler.LedgerReconciliation)
+
+		adminGroup.GET("/economy", handler.GetEconomyConfig)
+		adminGroup.POST("/economy/reload", handler.ReloadEconomyConfig)
+
+		adminGroup.GET("/task-reviews", handler.GetTaskCompletionReviews)
+		adminGroup.POST("/task-reviews/:reviewID/approve", handler.ApproveTaskCompletion)
+		adminGroup.POST("/task-reviews/:reviewID/reject", handler.RejectTaskCompletion)
+
+		adminGroup.GET("/events", handler.ListEvents)
+		adminGroup.POST("/events", handler.CreateEvent)
+		adminGroup.PUT("/events/:eventID", handler.UpdateEvent)
+		adminGroup.DELETE("/events/:eventID", handler.DeleteEvent)
+
+		adminGroup.DELETE("/comments/:commentID", handler.DeleteComment)
+	}
+}
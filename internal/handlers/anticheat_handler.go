@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var completionReviewStatuses = []string{
+	models.CompletionReviewPending,
+	models.CompletionReviewApproved,
+	models.CompletionReviewRejected,
+}
+
+// GetTaskCompletionReviews - подозрительные выполнения задач (?status=pending по умолчанию, all - все)
+func (h *AdminHandler) GetTaskCompletionReviews(c *gin.Context) {
+	status := c.DefaultQuery("status", models.CompletionReviewPending)
+	if status == "all" {
+		status = ""
+	} else if !slices.Contains(completionReviewStatuses, status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, err := h.questService.GetTaskCompletionReviews(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+func (h *AdminHandler) ApproveTaskCompletion(c *gin.Context) {
+	h.resolveTaskCompletion(c, true)
+}
+
+func (h *AdminHandler) RejectTaskCompletion(c *gin.Context) {
+	h.resolveTaskCompletion(c, false)
+}
+
+func (h *AdminHandler) resolveTaskCompletion(c *gin.Context, approve bool) {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req models.ReviewTaskCompletionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	review, err := h.questService.ResolveTaskCompletionReview(c.Request.Context(), reviewID, adminID, approve, req.Comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		case errors.Is(err, repositories.ErrCompletionAlreadyReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDailyRewardStatus - календарь ежедневных наград и текущая серия пользователя
+func (h *QuestHandler) GetDailyRewardStatus(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.questService.GetDailyRewardStatus(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *QuestHandler) ClaimDailyReward(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.questService.ClaimDailyReward(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrDailyRewardAlreadyClaimed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// RegisterDailyRewardRoutes sets up the routes for daily login rewards
+func RegisterDailyRewardRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	userGroup := router.Group("/user")
+	userGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		userGroup.GET("/daily-reward", handler.GetDailyRewardStatus)
+		userGroup.POST("/daily-reward/claim", handler.ClaimDailyReward)
+	}
+}
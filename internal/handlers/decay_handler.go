@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDecayHistory - история потерь опыта и уровней веток (неактивность, проваленные квесты)
+func (h *QuestHandler) GetDecayHistory(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.questService.GetDecayHistory(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// RegisterDecayRoutes sets up the routes for progress decay history
+func RegisterDecayRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	userGroup := router.Group("/user")
+	userGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		userGroup.GET("/decay-history", handler.GetDecayHistory)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *QuestHandler) CreateDuel(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateDuelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	duelID, err := h.questService.CreateDuel(c.Request.Context(), userID, req)
+	if err != nil {
+		writeDuelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Duel challenge sent successfully", "duel_id": duelID})
+}
+
+// GetDuels - GET /duels?status=pending|active|completed|...&limit=&offset=
+func (h *QuestHandler) GetDuels(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	duels, err := h.questService.GetDuels(c.Request.Context(), userID, c.Query("status"), limit, offset)
+	if err != nil {
+		writeDuelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, duels)
+}
+
+func (h *QuestHandler) GetDuel(c *gin.Context) {
+	userID, duelID, ok := duelRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	duel, err := h.questService.GetDuel(c.Request.Context(), userID, duelID)
+	if err != nil {
+		writeDuelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, duel)
+}
+
+func (h *QuestHandler) AcceptDuel(c *gin.Context) {
+	h.handleDuelAction(c, h.questService.AcceptDuel, "Duel accepted successfully")
+}
+
+func (h *QuestHandler) DeclineDuel(c *gin.Context) {
+	h.handleDuelAction(c, h.questService.DeclineDuel, "Duel declined successfully")
+}
+
+func (h *QuestHandler) CancelDuel(c *gin.Context) {
+	h.handleDuelAction(c, h.questService.CancelDuel, "Duel cancelled successfully")
+}
+
+func (h *QuestHandler) handleDuelAction(c *gin.Context, action func(ctx context.Context, userID, duelID int) error, message string) {
+	userID, duelID, ok := duelRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := action(c.Request.Context(), userID, duelID); err != nil {
+		writeDuelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// duelRequestIDs достает текущего пользователя и :duelID, при ошибке пишет ответ
+func duelRequestIDs(c *gin.Context) (int, int, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+
+	duelID, err := strconv.Atoi(c.Param("duelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duel ID"})
+		return 0, 0, false
+	}
+
+	return userID, duelID, true
+}
+
+func writeDuelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrUserBlocked), errors.Is(err, repositories.ErrNotFriends):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrDuelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quest not found"})
+	case errors.Is(err, repositories.ErrDuelExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrNotEnoughCoins),
+		errors.Is(err, repositories.ErrInvalidDuel),
+		errors.Is(err, repositories.ErrQuestNotAvailable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func RegisterDuelRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	duelGroup := router.Group("/duels")
+	duelGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		duelGroup.POST("", handler.CreateDuel)
+		duelGroup.GET("", handler.GetDuels)
+		duelGroup.GET("/:duelID", handler.GetDuel)
+		duelGroup.POST("/:duelID/accept", handler.AcceptDuel)
+		duelGroup.POST("/:duelID/decline", handler.DeclineDuel)
+		duelGroup.DELETE("/:duelID", handler.CancelDuel)
+	}
+}
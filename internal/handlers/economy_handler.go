@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLevelCurve - пороги опыта для каждого уровня
+func (h *QuestHandler) GetLevelCurve(c *gin.Context) {
+	c.JSON(http.StatusOK, h.questService.GetLevelCurve())
+}
+
+// GetEconomyConfig - текущая конфигурация экономики
+func (h *AdminHandler) GetEconomyConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Economy())
+}
+
+// ReloadEconomyConfig перечитывает economy.yaml без перезапуска сервера
+func (h *AdminHandler) ReloadEconomyConfig(c *gin.Context) {
+	economy, levelsChanged, err := h.questService.ReloadEconomy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"config": economy, "levels_changed": levelsChanged})
+}
+
+// RegisterEconomyRoutes sets up the routes for progression info
+func RegisterEconomyRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	economyGroup := router.Group("/economy")
+	economyGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		economyGroup.GET("/levels", handler.GetLevelCurve)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEvents - активные и запланированные события
+func (h *QuestHandler) GetEvents(c *gin.Context) {
+	events, err := h.questService.GetEvents(c.Request.Context(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func (h *QuestHandler) GetEvent(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.questService.GetEvent(c.Request.Context(), eventID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+func (h *QuestHandler) GetEventLeaderboard(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.questService.GetEventLeaderboard(c.Request.Context(), eventID, limit, offset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// ListEvents - все события, включая завершенные
+func (h *AdminHandler) ListEvents(c *gin.Context) {
+	events, err := h.questService.GetEvents(c.Request.Context(), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func (h *AdminHandler) CreateEvent(c *gin.Context) {
+	h.saveEvent(c, 0)
+}
+
+func (h *AdminHandler) UpdateEvent(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	h.saveEvent(c, eventID)
+}
+
+func (h *AdminHandler) saveEvent(c *gin.Context, eventID int) {
+	var req models.EventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	event, err := h.questService.SaveEvent(c.Request.Context(), eventID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		case errors.Is(err, repositories.ErrQuestInOtherEvent),
+			errors.Is(err, repositories.ErrEventQuestNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+func (h *AdminHandler) DeleteEvent(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	if err := h.questService.DeleteEvent(c.Request.Context(), eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegisterEventRoutes sets up the routes for seasonal events
+func RegisterEventRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	eventGroup := router.Group("/events")
+	eventGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		eventGroup.GET("", handler.GetEvents)
+		eventGroup.GET("/:eventID", handler.GetEvent)
+		eventGroup.GET("/:eventID/leaderboard", handler.GetEventLeaderboard)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *QuestHandler) GiftCoins(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	friendID, err := strconv.Atoi(c.Param("friend_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid friend ID"})
+		return
+	}
+
+	var req models.GiftCoinsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	gift, err := h.questService.GiftCoins(c.Request.Context(), userID, friendID, req)
+	if err != nil {
+		writeGiftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gift)
+}
+
+func (h *QuestHandler) GiftQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	friendID, err := strconv.Atoi(c.Param("friend_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid friend ID"})
+		return
+	}
+
+	var req models.GiftQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	gift, err := h.questService.GiftQuest(c.Request.Context(), userID, friendID, req)
+	if err != nil {
+		writeGiftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gift)
+}
+
+func (h *QuestHandler) GetGifts(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gifts, err := h.questService.GetGifts(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gifts)
+}
+
+func writeGiftError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrGiftLimitExceeded):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrNotFriends),
+		errors.Is(err, repositories.ErrGiftToSelf):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrNotEnoughCoins),
+		errors.Is(err, repositories.ErrQuestAlreadyOwned),
+		errors.Is(err, repositories.ErrSharedSeatPending):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quest not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// RegisterGiftRoutes sets up the routes for gifts between friends
+func RegisterGiftRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	friendGroup := router.Group("/friends")
+	friendGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		friendGroup.GET("/gifts", handler.GetGifts)
+		friendGroup.POST("/:friend_id/gift/coins", handler.GiftCoins)
+		friendGroup.POST("/:friend_id/gift/quest", handler.GiftQuest)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetItems - каталог предметов магазина
+func (h *QuestHandler) GetItems(c *gin.Context) {
+	items, err := h.questService.GetItems(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *QuestHandler) GetInventory(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	inventory, err := h.questService.GetInventory(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	effects, err := h.questService.GetActiveEffects(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": inventory, "active_effects": effects})
+}
+
+func (h *QuestHandler) PurchaseItem(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.PurchaseItemRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	item, err := h.questService.PurchaseItem(c.Request.Context(), userID, c.Param("code"), req.Quantity)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *QuestHandler) UseItem(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.UseItemRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	result, err := h.questService.UseItem(c.Request.Context(), userID, c.Param("code"), req.QuestID)
+	if err != nil {
+		writeItemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func writeItemError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case errors.Is(err, repositories.ErrNotEnoughCoins),
+		errors.Is(err, repositories.ErrItemNotOwned),
+		errors.Is(err, repositories.ErrItemUsedAutomatic),
+		errors.Is(err, repositories.ErrQuestNotExtendable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// RegisterItemRoutes sets up the routes for the item shop and inventory
+func RegisterItemRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	itemGroup := router.Group("/items")
+	itemGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		itemGroup.GET("", handler.GetItems)
+		itemGroup.POST("/:code/purchase", handler.PurchaseItem)
+	}
+
+	userGroup := router.Group("/user")
+	userGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		userGroup.GET("/inventory", handler.GetInventory)
+		userGroup.POST("/inventory/:code/use", handler.UseItem)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLeaderboard - GET /leaderboards?metric=xp|level|streak|quests&period=day|week|all&scope=global|friends&category=
+// Пагинация по курсору: ?limit= &after_score= &after_user_id= (из next_cursor предыдущей страницы)
+func (h *QuestHandler) GetLeaderboard(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := models.LeaderboardQuery{
+		Metric:   c.Query("metric"),
+		Period:   c.Query("period"),
+		Scope:    c.Query("scope"),
+		Category: c.Query("category"),
+	}
+
+	if c.Query("after_score") != "" || c.Query("after_user_id") != "" {
+		score, errScore := strconv.ParseInt(c.Query("after_score"), 10, 64)
+		afterUserID, errUser := strconv.Atoi(c.Query("after_user_id"))
+		if errScore != nil || errUser != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor: after_score and after_user_id are required together"})
+			return
+		}
+		query.After = &models.LeaderboardCursor{Score: score, UserID: afterUserID}
+	}
+
+	board, err := h.questService.GetLeaderboard(c.Request.Context(), userID, query, limit, offset)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidLeaderboard) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// SetLeaderboardOptOut - скрыть себя из таблиц лидеров (или вернуться в них)
+func (h *QuestHandler) SetLeaderboardOptOut(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.LeaderboardOptOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := h.questService.SetLeaderboardOptOut(c.Request.Context(), userID, *req.OptOut); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leaderboard settings updated successfully", "opt_out": *req.OptOut})
+}
+
+func RegisterLeaderboardRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	leaderboardGroup := router.Group("/leaderboards")
+	leaderboardGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		leaderboardGroup.GET("", handler.GetLeaderboard)
+		leaderboardGroup.PUT("/opt-out", handler.SetLeaderboardOptOut)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	service *services.LedgerService
+}
+
+func NewLedgerHandler(service *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{service: service}
+}
+
+// GetTransactions - история транзакций пользователя.
+// Фильтры: ?type=earned|spent|bonus &reference_type=quest|task|achievement &from= &to= (RFC3339 или YYYY-MM-DD),
+// пагинация: ?limit= &offset=, выгрузка в CSV: ?format=csv
+func (h *LedgerHandler) GetTransactions(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename=transactions.csv")
+		if err := h.service.ExportTransactionsCSV(c.Request.Context(), userID, filter, c.Writer); err != nil {
+			// Выгрузка идет потоком: если строки уже отправлены, статус ответа изменить нельзя
+			if c.Writer.Written() {
+				slog.ErrorContext(c.Request.Context(), "CSV export interrupted", "error", err, "user_id", userID)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	history, err := h.service.GetTransactions(c.Request.Context(), userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func parseTransactionFilter(c *gin.Context) (models.TransactionFilter, error) {
+	var filter models.TransactionFilter
+	var err error
+
+	filter.Limit, filter.Offset, err = parsePagination(c)
+	if err != nil {
+		return filter, err
+	}
+
+	filter.TransactionType = c.Query("type")
+	if filter.TransactionType != "" && !slices.Contains(models.TransactionTypes, filter.TransactionType) {
+		return filter, errors.New("Invalid type")
+	}
+
+	filter.ReferenceType = c.Query("reference_type")
+	if filter.ReferenceType != "" && !slices.Contains(models.ReferenceTypes, filter.ReferenceType) {
+		return filter, errors.New("Invalid reference_type")
+	}
+
+	if filter.From, err = parseDateParam(c.Query("from"), false); err != nil {
+		return filter, errors.New("Invalid from")
+	}
+	if filter.To, err = parseDateParam(c.Query("to"), true); err != nil {
+		return filter, errors.New("Invalid to")
+	}
+
+	return filter, nil
+}
+
+// parseDateParam разбирает RFC3339 или YYYY-MM-DD. Для верхней границы дата без времени включает весь день.
+func parseDateParam(s string, endOfDay bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// RegisterLedgerRoutes sets up the routes for coin transactions
+func RegisterLedgerRoutes(router *gin.Engine, ledgerService *services.LedgerService) {
+	handler := NewLedgerHandler(ledgerService)
+
+	userGroup := router.Group("/user")
+	userGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		userGroup.GET("/transactions", handler.GetTransactions)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetNotifications - уведомления пользователя (?unread=true - только непрочитанные)
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, err := h.service.GetNotifications(c.Request.Context(), userID, unreadOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notificationID, err := strconv.Atoi(c.Param("notificationID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	if err := h.service.MarkRead(c.Request.Context(), userID, notificationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.MarkAllRead(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RegisterNotificationRoutes sets up the routes for user notifications
+func RegisterNotificationRoutes(router *gin.Engine, notificationService *services.NotificationService) {
+	handler := NewNotificationHandler(notificationService)
+
+	g := router.Group("/notifications")
+	g.Use(middleware.JWTAuthMiddleware())
+	{
+		g.GET("", handler.GetNotifications)
+		g.POST("/:notificationID/read", handler.MarkRead)
+		g.POST("/read-all", handler.MarkAllRead)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPublicProfile - GET /users/:id - профиль пользователя с учетом его настроек приватности
+func (h *UserHandler) GetPublicProfile(c *gin.Context) {
+	viewerID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	profile, err := h.service.GetPublicProfile(viewerID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrProfilePrivate):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// minUserSearchLength - минимальная длина запроса поиска пользователей
+const minUserSearchLength = 2
+
+// SearchUsers - GET /users/search?q=&limit=&offset= - поиск пользователей по имени (префикс и нечеткое совпадение)
+func (h *UserHandler) SearchUsers(c *gin.Context) {
+	viewerID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(query) < minUserSearchLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query must be at least 2 characters"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.service.SearchUsers(viewerID, query, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
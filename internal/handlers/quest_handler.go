@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
 	"BecomeOverMan/internal/services"
 	"BecomeOverMan/pkg/middleware"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -91,7 +93,13 @@ func (h *QuestHandler) GetQuestShopHandler(c *gin.Context) {
 		return
 	}
 
-	quests, err := h.questService.GetQuestShop(c.Request.Context(), userID)
+	sort := c.Query("sort")
+	if sort != repositories.ShopSortDefault && sort != repositories.ShopSortRating {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort, allowed: rating"})
+		return
+	}
+
+	quests, err := h.questService.GetQuestShop(c.Request.Context(), userID, sort)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -146,6 +154,10 @@ func (h *QuestHandler) PurchaseQuestHandler(c *gin.Context) {
 	}
 
 	if err := h.questService.PurchaseQuest(c.Request.Context(), userID, questID); err != nil {
+		if errors.Is(err, repositories.ErrSharedSeatPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -193,12 +205,26 @@ func (h *QuestHandler) CompleteTaskHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.questService.CompleteTask(c.Request.Context(), userID, questID, taskID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := h.questService.CompleteTask(c.Request.Context(), userID, questID, taskID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrTaskTooEarly):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrTooManyCompletions):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.Status(http.StatusOK)
+	// Выполнение засчитано, но награда удержана до проверки
+	if result.RewardHeld {
+		c.JSON(http.StatusAccepted, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 func (h *QuestHandler) CompleteQuestHandler(c *gin.Context) {
@@ -215,6 +241,10 @@ func (h *QuestHandler) CompleteQuestHandler(c *gin.Context) {
 	}
 
 	if err := h.questService.CompleteQuest(c.Request.Context(), userID, questID); err != nil {
+		if errors.Is(err, repositories.ErrSharedQuestIncomplete) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -222,27 +252,6 @@ func (h *QuestHandler) CompleteQuestHandler(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
-func (h *QuestHandler) CreateSharedQuest(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	var req models.CreateSharedQuestRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
-		return
-	}
-
-	if err := h.questService.CreateSharedQuest(userID, req.FriendID, req.QuestID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Shared quest created successfully"})
-}
-
 // Search relevant quests by title / description (поисковик - интеграция с Bert FastAPI-микросервисом)
 // без аутентификаци-авторизации
 func (h *QuestHandler) SearchQuests(c *gin.Context) {
@@ -325,7 +334,25 @@ func RegisterQuestRoutes(router *gin.Engine, questService *services.QuestService
 		questGroup.POST("/:questID/complete", handler.CompleteQuestHandler)
 		questGroup.POST("/:questID/:taskID/complete", handler.CompleteTaskHandler)
 
+		questGroup.GET("/:questID/reviews", handler.GetQuestReviews)
+		questGroup.POST("/:questID/reviews", handler.ReviewQuest)
+		questGroup.DELETE("/:questID/reviews", handler.DeleteQuestReview)
+
 		questGroup.POST("/shared", handler.CreateSharedQuest)
+		questGroup.GET("/shared/invites", handler.GetSharedQuestInvites)
+		questGroup.GET("/shared/history", handler.GetSharedQuestHistory)
+		questGroup.GET("/shared/:sharedQuestID", handler.GetSharedQuest)
+		questGroup.POST("/shared/:sharedQuestID/accept", handler.AcceptSharedQuestInvite)
+		questGroup.POST("/shared/:sharedQuestID/decline", handler.DeclineSharedQuestInvite)
+		questGroup.DELETE("/shared/:sharedQuestID", handler.CancelSharedQuest)
+		// Прежние пути приглашений (inviteID = sharedQuestID)
+		questGroup.POST("/shared/invites/:inviteID/accept", handler.AcceptSharedQuestInvite)
+		questGroup.POST("/shared/invites/:inviteID/decline", handler.DeclineSharedQuestInvite)
+		questGroup.DELETE("/shared/invites/:inviteID", handler.CancelSharedQuest)
+
+		questGroup.GET("/templates", handler.GetQuestTemplates)
+		questGroup.GET("/templates/:templateID", handler.GetQuestTemplate)
+		questGroup.POST("/templates/:templateID/instantiate", handler.InstantiateQuestTemplate)
 
 		questGroup.POST("/generate", handler.GenerateAIQuest)
 		questGroup.POST("/schedule", handler.GenerateScheduleByAI)
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *QuestHandler) GetQuestTemplates(c *gin.Context) {
+	templates, err := h.questService.GetQuestTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *QuestHandler) GetQuestTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	template, err := h.questService.GetQuestTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// InstantiateQuestTemplate создает конкретный квест из шаблона с параметрами пользователя
+func (h *QuestHandler) InstantiateQuestTemplate(c *gin.Context) {
+	instantiateQuestTemplate(c, h.questService)
+}
+
+// InstantiateQuestTemplate создает квест из шаблона (админка)
+func (h *AdminHandler) InstantiateQuestTemplate(c *gin.Context) {
+	instantiateQuestTemplate(c, h.questService)
+}
+
+// instantiateQuestTemplate - общий обработчик: параметры проверяются по границам шаблона в сервисе
+func instantiateQuestTemplate(c *gin.Context, questService *services.QuestService) {
+	templateID, err := strconv.Atoi(c.Param("templateID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var req models.InstantiateQuestTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	questID, result, err := questService.InstantiateQuestTemplate(c.Request.Context(), templateID, req.Params)
+	if err != nil {
+		var validationErr *services.TemplateValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template params", "details": validationErr.Errors})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Quest created from template",
+		"quest_id": questID,
+		"quest":    result.Quest,
+		"tasks":    result.Tasks,
+	})
+}
+
+// CreateQuestTemplate - создание шаблона квеста (админка)
+func (h *AdminHandler) CreateQuestTemplate(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var template models.QuestTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	templateID, err := h.questService.CreateQuestTemplate(c.Request.Context(), userID, &template)
+	if err != nil {
+		var validationErr *services.TemplateValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template", "details": validationErr.Errors})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template created successfully", "template_id": templateID})
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/pkg/middleware"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSharedQuest - POST /quests/shared: групповой квест с приглашением друзей
+func (h *QuestHandler) CreateSharedQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateSharedQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	sharedQuestID, err := h.questService.CreateSharedQuest(c.Request.Context(), userID, req)
+	if err != nil {
+		writeSharedQuestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Shared quest invitations sent", "shared_quest_id": sharedQuestID})
+}
+
+// GetSharedQuest - GET /quests/shared/:sharedQuestID: участники и их прогресс,
+// статусы задач каждого участника рядом друг с другом
+func (h *QuestHandler) GetSharedQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sharedQuestID, err := strconv.Atoi(sharedQuestIDParam(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shared quest ID"})
+		return
+	}
+
+	details, err := h.questService.GetSharedQuest(c.Request.Context(), userID, sharedQuestID)
+	if err != nil {
+		writeSharedQuestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// GetSharedQuestInvites - GET /quests/shared/invites?direction=incoming|outgoing
+func (h *QuestHandler) GetSharedQuestInvites(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var incoming bool
+	switch c.DefaultQuery("direction", "incoming") {
+	case "incoming":
+		incoming = true
+	case "outgoing":
+		incoming = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be incoming or outgoing"})
+		return
+	}
+
+	invites, err := h.questService.GetSharedQuestInvites(c.Request.Context(), userID, incoming)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+// GetSharedQuestHistory - GET /quests/shared/history?status=active|completed&limit=&offset=:
+// совместные квесты пользователя с партнерами и их прогрессом
+func (h *QuestHandler) GetSharedQuestHistory(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.SharedQuestStatusActive, models.SharedQuestStatusCompleted:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or completed"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.questService.GetSharedQuestHistory(c.Request.Context(), userID, status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *QuestHandler) AcceptSharedQuestInvite(c *gin.Context) {
+	h.handleSharedQuestInvite(c, h.questService.AcceptSharedQuestInvite, "Shared quest invitation accepted")
+}
+
+func (h *QuestHandler) DeclineSharedQuestInvite(c *gin.Context) {
+	h.handleSharedQuestInvite(c, h.questService.DeclineSharedQuestInvite, "Shared quest invitation declined")
+}
+
+func (h *QuestHandler) CancelSharedQuest(c *gin.Context) {
+	h.handleSharedQuestInvite(c, h.questService.CancelSharedQuest, "Shared quest cancelled")
+}
+
+func (h *QuestHandler) handleSharedQuestInvite(c *gin.Context, action func(ctx context.Context, userID, sharedQuestID int) error, message string) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sharedQuestID, err := strconv.Atoi(sharedQuestIDParam(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shared quest ID"})
+		return
+	}
+
+	if err := action(c.Request.Context(), userID, sharedQuestID); err != nil {
+		writeSharedQuestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// sharedQuestIDParam - ID совместного квеста из пути (в прежних путях приглашений это :inviteID)
+func sharedQuestIDParam(c *gin.Context) string {
+	if id := c.Param("inviteID"); id != "" {
+		return id
+	}
+	return c.Param("sharedQuestID")
+}
+
+func writeSharedQuestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrUserBlocked), errors.Is(err, repositories.ErrNotFriends):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrSharedInviteNotFound), errors.Is(err, repositories.ErrSharedQuestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quest not found"})
+	case errors.Is(err, repositories.ErrSharedInviteExists), errors.Is(err, repositories.ErrSharedQuestOwned):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrSharedInviteExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrNotEnoughCoins),
+		errors.Is(err, repositories.ErrInvalidSharedQuest),
+		errors.Is(err, repositories.ErrSharedQuestOwnerAction),
+		errors.Is(err, repositories.ErrQuestNotAvailable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
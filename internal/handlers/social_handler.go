@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *QuestHandler) AddReaction(c *gin.Context) {
+	userID, targetType, targetID, ok := socialRequestTarget(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := h.questService.AddReaction(c.Request.Context(), userID, targetType, targetID, req.Kind); err != nil {
+		writeSocialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction added successfully"})
+}
+
+func (h *QuestHandler) RemoveReaction(c *gin.Context) {
+	userID, targetType, targetID, ok := socialRequestTarget(c)
+	if !ok {
+		return
+	}
+
+	err := h.questService.RemoveReaction(c.Request.Context(), userID, targetType, targetID, c.Param("kind"))
+	if err != nil {
+		writeSocialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction removed successfully"})
+}
+
+func (h *QuestHandler) GetComments(c *gin.Context) {
+	userID, targetType, targetID, ok := socialRequestTarget(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comments, err := h.questService.GetComments(c.Request.Context(), userID, targetType, targetID, limit, offset)
+	if err != nil {
+		writeSocialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+func (h *QuestHandler) AddComment(c *gin.Context) {
+	userID, targetType, targetID, ok := socialRequestTarget(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment can't be empty"})
+		return
+	}
+
+	comment, err := h.questService.AddComment(c.Request.Context(), userID, targetType, targetID, req.Body)
+	if err != nil {
+		writeSocialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteComment - автор удаляет свой комментарий, автор объекта - любой комментарий под ним
+func (h *QuestHandler) DeleteComment(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	commentID, err := strconv.Atoi(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if err := h.questService.DeleteComment(c.Request.Context(), userID, commentID); err != nil {
+		writeSocialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// DeleteComment - модерация: администратор удаляет любой комментарий
+func (h *AdminHandler) DeleteComment(c *gin.Context) {
+	commentID, err := strconv.Atoi(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if err := h.questService.ModerateDeleteComment(c.Request.Context(), commentID); err != nil {
+		writeSocialError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// socialRequestTarget достает текущего пользователя, :targetType и :targetID, при ошибке пишет ответ
+func socialRequestTarget(c *gin.Context) (int, string, int, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, "", 0, false
+	}
+
+	targetType := c.Param("targetType")
+	if targetType != models.SocialTargetActivity && targetType != models.SocialTargetTask {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target type"})
+		return 0, "", 0, false
+	}
+
+	targetID, err := strconv.Atoi(c.Param("targetID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+		return 0, "", 0, false
+	}
+
+	return userID, targetType, targetID, true
+}
+
+func writeSocialError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrSocialTargetNotFound),
+		errors.Is(err, repositories.ErrReactionNotFound),
+		errors.Is(err, repositories.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrCommentPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// RegisterSocialRoutes - реакции и комментарии; :targetType - activity (событие ленты) или task (задача совместного квеста)
+func RegisterSocialRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	socialGroup := router.Group("/social")
+	socialGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		socialGroup.POST("/:targetType/:targetID/reactions", handler.AddReaction)
+		socialGroup.DELETE("/:targetType/:targetID/reactions/:kind", handler.RemoveReaction)
+
+		socialGroup.GET("/:targetType/:targetID/comments", handler.GetComments)
+		socialGroup.POST("/:targetType/:targetID/comments", handler.AddComment)
+		socialGroup.DELETE("/comments/:commentID", handler.DeleteComment)
+	}
+}
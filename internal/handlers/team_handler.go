@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *QuestHandler) CreateTeam(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	teamID, err := h.questService.CreateTeam(c.Request.Context(), userID, req)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Team created successfully", "team_id": teamID})
+}
+
+func (h *QuestHandler) GetMyTeam(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := h.questService.GetMyTeam(c.Request.Context(), userID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *QuestHandler) GetTeam(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.questService.GetTeam(c.Request.Context(), teamID, userID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *QuestHandler) JoinTeam(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.JoinTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	teamID, err := h.questService.JoinTeam(c.Request.Context(), userID, req.InviteCode)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined team successfully", "team_id": teamID})
+}
+
+func (h *QuestHandler) LeaveTeam(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.questService.LeaveTeam(c.Request.Context(), userID, teamID); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left team successfully"})
+}
+
+func (h *QuestHandler) GetTeamMembers(c *gin.Context) {
+	_, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.questService.GetTeamMembers(c.Request.Context(), teamID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *QuestHandler) SetTeamMemberRole(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateTeamMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := h.questService.SetTeamMemberRole(c.Request.Context(), userID, teamID, memberID, req.Role); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully"})
+}
+
+func (h *QuestHandler) RemoveTeamMember(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.questService.RemoveTeamMember(c.Request.Context(), userID, teamID, memberID); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+func (h *QuestHandler) RegenerateTeamInviteCode(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	code, err := h.questService.RegenerateTeamInviteCode(c.Request.Context(), userID, teamID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invite_code": code})
+}
+
+func (h *QuestHandler) GetTeamQuests(c *gin.Context) {
+	_, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	quests, err := h.questService.GetTeamQuests(c.Request.Context(), teamID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quests)
+}
+
+func (h *QuestHandler) AddTeamQuest(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddTeamQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if err := h.questService.AddTeamQuest(c.Request.Context(), userID, teamID, req); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Team quest added successfully"})
+}
+
+func (h *QuestHandler) RemoveTeamQuest(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	if err := h.questService.RemoveTeamQuest(c.Request.Context(), userID, teamID, questID); err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team quest removed successfully"})
+}
+
+// GetTeamProgress - сводка прогресса команды (только для участников)
+func (h *QuestHandler) GetTeamProgress(c *gin.Context) {
+	userID, teamID, ok := teamRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.questService.GetTeamProgress(c.Request.Context(), teamID, userID)
+	if err != nil {
+		writeTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// teamRequestIDs достает текущего пользователя и :teamID, при ошибке пишет ответ
+func teamRequestIDs(c *gin.Context) (int, int, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+
+	teamID, err := strconv.Atoi(c.Param("teamID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return 0, 0, false
+	}
+
+	return userID, teamID, true
+}
+
+func writeTeamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrTeamNotFound), errors.Is(err, repositories.ErrTeamQuestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quest not found"})
+	case errors.Is(err, repositories.ErrNotTeamMember), errors.Is(err, repositories.ErrTeamPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrAlreadyInTeam),
+		errors.Is(err, repositories.ErrTeamNameTaken),
+		errors.Is(err, repositories.ErrTeamQuestExists),
+		errors.Is(err, repositories.ErrTeamFull):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidInviteCode), errors.Is(err, repositories.ErrTeamOwnerSelfApply):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func RegisterTeamRoutes(router *gin.Engine, questService *services.QuestService) {
+	handler := NewQuestHandler(questService)
+
+	teamGroup := router.Group("/teams")
+	teamGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		teamGroup.POST("", handler.CreateTeam)
+		teamGroup.GET("/my", handler.GetMyTeam)
+		teamGroup.POST("/join", handler.JoinTeam)
+
+		teamGroup.GET("/:teamID", handler.GetTeam)
+		teamGroup.POST("/:teamID/leave", handler.LeaveTeam)
+		teamGroup.GET("/:teamID/progress", handler.GetTeamProgress)
+		teamGroup.POST("/:teamID/invite-code", handler.RegenerateTeamInviteCode)
+
+		teamGroup.GET("/:teamID/members", handler.GetTeamMembers)
+		teamGroup.PUT("/:teamID/members/:userID/role", handler.SetTeamMemberRole)
+		teamGroup.DELETE("/:teamID/members/:userID", handler.RemoveTeamMember)
+
+		teamGroup.GET("/:teamID/quests", handler.GetTeamQuests)
+		teamGroup.POST("/:teamID/quests", handler.AddTeamQuest)
+		teamGroup.DELETE("/:teamID/quests/:questID", handler.RemoveTeamQuest)
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
 	"BecomeOverMan/internal/services"
 	"BecomeOverMan/pkg/middleware"
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -78,12 +81,13 @@ func (h *UserHandler) AddFriend(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddFriend(userID, friendID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	status, err := h.service.AddFriend(userID, friendID)
+	if err != nil {
+		writeFriendRequestError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Friend added successfully"})
+	writeFriendRequestSent(c, status)
 }
 
 func (h *UserHandler) AddFriendByName(c *gin.Context) {
@@ -99,12 +103,95 @@ func (h *UserHandler) AddFriendByName(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddFriendByName(userID, friendName); err != nil {
+	status, err := h.service.AddFriendByName(userID, friendName)
+	if err != nil {
+		writeFriendRequestError(c, err)
+		return
+	}
+
+	writeFriendRequestSent(c, status)
+}
+
+func writeFriendRequestSent(c *gin.Context, status string) {
+	if status == models.FriendStatusAccepted {
+		c.JSON(http.StatusOK, gin.H{"message": "Friend request accepted", "status": status})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Friend request sent", "status": status})
+}
+
+func writeFriendRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrUserBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrAlreadyFriends), errors.Is(err, repositories.ErrFriendRequestExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrFriendRequestNotFound), errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// GetFriendRequests - GET /friends/requests?direction=incoming|outgoing
+func (h *UserHandler) GetFriendRequests(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var incoming bool
+	switch c.DefaultQuery("direction", "incoming") {
+	case "incoming":
+		incoming = true
+	case "outgoing":
+		incoming = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be incoming or outgoing"})
+		return
+	}
+
+	requests, err := h.service.GetFriendRequests(userID, incoming)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+func (h *UserHandler) AcceptFriendRequest(c *gin.Context) {
+	h.handleFriendRequest(c, h.service.AcceptFriendRequest, "Friend request accepted")
+}
+
+func (h *UserHandler) DeclineFriendRequest(c *gin.Context) {
+	h.handleFriendRequest(c, h.service.DeclineFriendRequest, "Friend request declined")
+}
+
+func (h *UserHandler) CancelFriendRequest(c *gin.Context) {
+	h.handleFriendRequest(c, h.service.CancelFriendRequest, "Friend request cancelled")
+}
+
+func (h *UserHandler) handleFriendRequest(c *gin.Context, action func(userID, requestID int) error, message string) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestID, err := strconv.Atoi(c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	if err := action(userID, requestID); err != nil {
+		writeFriendRequestError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Friend added successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": message})
 }
 
 func (h *UserHandler) GetFriends(c *gin.Context) {
@@ -123,6 +210,80 @@ func (h *UserHandler) GetFriends(c *gin.Context) {
 	c.JSON(http.StatusOK, friends)
 }
 
+func (h *UserHandler) RemoveFriend(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	friendID, err := strconv.Atoi(c.Param("friend_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid friend ID"})
+		return
+	}
+
+	if err := h.service.RemoveFriend(userID, friendID); err != nil {
+		if errors.Is(err, repositories.ErrNotFriends) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Friend removed successfully"})
+}
+
+func (h *UserHandler) GetBlockedUsers(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	blocked, err := h.service.GetBlockedUsers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blocked)
+}
+
+func (h *UserHandler) BlockUser(c *gin.Context) {
+	h.handleBlock(c, h.service.BlockUser, "User blocked successfully")
+}
+
+func (h *UserHandler) UnblockUser(c *gin.Context) {
+	h.handleBlock(c, h.service.UnblockUser, "User unblocked successfully")
+}
+
+func (h *UserHandler) handleBlock(c *gin.Context, action func(userID, otherID int) error, message string) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	otherID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := action(userID, otherID); err != nil {
+		if errors.Is(err, repositories.ErrNotBlocked) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -139,6 +300,27 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, profile)
 }
 
+func (h *UserHandler) UpdateTimezone(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.UpdateTimezoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.service.UpdateTimezone(userID, req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Timezone updated successfully"})
+}
+
 // RegisterUserRoutes sets up the routes for user handling with Gin
 func RegisterUserRoutes(router *gin.Engine, userService *services.UserService) {
 	handler := NewUserHandler(userService)
@@ -153,13 +335,33 @@ func RegisterUserRoutes(router *gin.Engine, userService *services.UserService) {
 	userProtectedGroup.Use(middleware.JWTAuthMiddleware())
 	{
 		userProtectedGroup.GET("/profile", handler.GetProfile)
+		userProtectedGroup.PUT("/timezone", handler.UpdateTimezone)
+		userProtectedGroup.GET("/privacy", handler.GetPrivacySettings)
+		userProtectedGroup.PUT("/privacy", handler.UpdatePrivacySettings)
+
+		userProtectedGroup.GET("/blocks", handler.GetBlockedUsers)
+		userProtectedGroup.POST("/blocks/:user_id", handler.BlockUser)
+		userProtectedGroup.DELETE("/blocks/:user_id", handler.UnblockUser)
 	}
 
 	friendGroup := router.Group("/friends")
 	friendGroup.Use(middleware.JWTAuthMiddleware())
 	{
 		friendGroup.POST("/:friend_id", handler.AddFriend)
+		friendGroup.DELETE("/:friend_id", handler.RemoveFriend)
 		friendGroup.POST("/by-name/:friend_name", handler.AddFriendByName)
 		friendGroup.GET("", handler.GetFriends)
+		friendGroup.GET("/feed", handler.GetFriendsFeed)
+		friendGroup.GET("/requests", handler.GetFriendRequests)
+		friendGroup.POST("/requests/:request_id/accept", handler.AcceptFriendRequest)
+		friendGroup.POST("/requests/:request_id/decline", handler.DeclineFriendRequest)
+		friendGroup.DELETE("/requests/:request_id", handler.CancelFriendRequest)
+	}
+
+	usersGroup := router.Group("/users")
+	usersGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		usersGroup.GET("/search", handler.SearchUsers)
+		usersGroup.GET("/:id", handler.GetPublicProfile)
 	}
 }
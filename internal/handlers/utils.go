@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination достает ?limit= и ?offset= из запроса (с дефолтами и ограничениями)
+func parsePagination(c *gin.Context) (limit, offset int, err error) {
+	limit = defaultPageLimit
+	if s := c.Query("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("Invalid limit")
+		}
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	if s := c.Query("offset"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("Invalid offset")
+		}
+	}
+
+	return limit, offset, nil
+}
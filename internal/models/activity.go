@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий ленты активности
+const (
+	ActivityTaskCompleted       = "task_completed"
+	ActivityQuestCompleted      = "quest_completed"
+	ActivityLevelUp             = "level_up"
+	ActivityAchievementUnlocked = "achievement_unlocked"
+	ActivityQuestPurchased      = "quest_purchased"
+	ActivityItemPurchased       = "item_purchased"
+)
+
+// ActivityEvent - событие в ленте друзей
+type ActivityEvent struct {
+	ID        int              `json:"id" db:"id"`
+	UserID    int              `json:"user_id" db:"user_id"`
+	Username  string           `json:"username" db:"username"`
+	Kind      string           `json:"kind" db:"kind"`
+	Payload   *json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+	SocialCounts
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Причины отправки выполнения задачи на проверку
+const (
+	CompletionFlagPerHour            = "completions_per_hour" // слишком много выполнений за час
+	CompletionFlagMinGap             = "min_gap"              // выполнения идут подряд слишком быстро
+	CompletionFlagFasterThanDuration = "faster_than_duration" // быстрее заявленной длительности задачи
+	CompletionFlagPreviouslyRejected = "previously_rejected"  // прошлое выполнение этой задачи отклонено администратором
+)
+
+// Статусы проверки выполнения
+const (
+	CompletionReviewPending  = "pending"
+	CompletionReviewApproved = "approved"
+	CompletionReviewRejected = "rejected"
+)
+
+// TaskCompletionResult - результат выполнения задачи
+type TaskCompletionResult struct {
+	XPGained   int      `json:"xp_gained"`
+	CoinGained int      `json:"coin_gained"`
+	RewardHeld bool     `json:"reward_held"`     // награда удержана до проверки администратором
+	Flags      []string `json:"flags,omitempty"` // причины проверки
+	ReviewID   *int     `json:"review_id,omitempty"`
+}
+
+// TaskCompletionReview - подозрительное выполнение задачи, ожидающее решения администратора
+type TaskCompletionReview struct {
+	ID         int            `json:"id" db:"id"`
+	UserID     int            `json:"user_id" db:"user_id"`
+	Username   string         `json:"username" db:"username"`
+	QuestID    int            `json:"quest_id" db:"quest_id"`
+	TaskID     int            `json:"task_id" db:"task_id"`
+	TaskTitle  string         `json:"task_title" db:"task_title"`
+	Flags      pq.StringArray `json:"flags" db:"flags"`
+	XPAmount   int            `json:"xp_amount" db:"xp_amount"`
+	CoinAmount int            `json:"coin_amount" db:"coin_amount"`
+	Status     string         `json:"status" db:"status"`
+	ReviewedBy *int           `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt *time.Time     `json:"reviewed_at" db:"reviewed_at"`
+	Comment    *string        `json:"comment" db:"comment"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+}
+
+type ReviewTaskCompletionRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
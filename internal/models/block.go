@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// BlockedUser - пользователь из списка блокировок
+type BlockedUser struct {
+	UserID    int       `json:"user_id" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Виды ежедневных наград
+const (
+	DailyRewardCoins = "coins"
+	DailyRewardXP    = "xp"
+	DailyRewardItem  = "item"
+)
+
+// Коды предметов инвентаря
+const (
+	ItemStreakFreeze = "streak_freeze"
+)
+
+type DailyReward struct {
+	Day      int    `json:"day"`
+	Kind     string `json:"kind"`
+	Amount   int    `json:"amount"`
+	ItemCode string `json:"item_code,omitempty"`
+}
+
+type DailyRewardClaim struct {
+	ID              int       `json:"id" db:"id"`
+	UserID          int       `json:"user_id" db:"user_id"`
+	ClaimDate       time.Time `json:"claim_date" db:"claim_date"`
+	CalendarDay     int       `json:"calendar_day" db:"calendar_day"`
+	RewardKind      string    `json:"reward_kind" db:"reward_kind"`
+	Amount          int       `json:"amount" db:"amount"`
+	ItemCode        *string   `json:"item_code" db:"item_code"`
+	ProtectionsUsed int       `json:"protections_used" db:"protections_used"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// DailyRewardStatus - календарь и состояние пользователя
+type DailyRewardStatus struct {
+	Calendar     []DailyReward `json:"calendar"`
+	CurrentDay   int           `json:"current_day"` // последний полученный день (0 - еще не получал или серия сброшена)
+	ClaimedToday bool          `json:"claimed_today"`
+	NextReward   DailyReward   `json:"next_reward"`
+	StreakAtRisk bool          `json:"streak_at_risk"` // пропущен день и нет защиты - серия начнется заново
+	Protections  int           `json:"protections"`    // количество streak_freeze в инвентаре
+	Timezone     string        `json:"timezone"`
+	LocalDate    string        `json:"local_date"`
+}
+
+// DailyRewardCalendar - месячный календарь ежедневных наград (по кругу).
+// Награды растут с каждым днем серии, каждый 7-й день - опыт, в середине месяца - заморозка серии.
+var DailyRewardCalendar = buildDailyRewardCalendar(30)
+
+func buildDailyRewardCalendar(days int) []DailyReward {
+	calendar := make([]DailyReward, 0, days)
+	for day := 1; day <= days; day++ {
+		reward := DailyReward{Day: day, Kind: DailyRewardCoins, Amount: 10 + (day-1)*2}
+		switch {
+		case day == days:
+			reward.Amount = 200
+		case day == 15:
+			reward = DailyReward{Day: day, Kind: DailyRewardItem, Amount: 1, ItemCode: ItemStreakFreeze}
+		case day%7 == 0:
+			reward = DailyReward{Day: day, Kind: DailyRewardXP, Amount: 50 * (day / 7)}
+		}
+		calendar = append(calendar, reward)
+	}
+	return calendar
+}
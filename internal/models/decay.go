@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Причины затухания прогресса
+const (
+	DecayReasonInactivity  = "inactivity"
+	DecayReasonFailedQuest = "failed_quest"
+)
+
+// DecayHistoryEntry - запись о потере опыта/уровня веток
+type DecayHistoryEntry struct {
+	ID               int              `json:"id" db:"id"`
+	UserID           int              `json:"user_id" db:"user_id"`
+	Reason           string           `json:"reason" db:"reason"`
+	QuestID          *int             `json:"quest_id" db:"quest_id"`
+	XPLost           int              `json:"xp_lost" db:"xp_lost"`
+	LevelBefore      int              `json:"level_before" db:"level_before"`
+	LevelAfter       int              `json:"level_after" db:"level_after"`
+	AttributeChanges *json.RawMessage `json:"attribute_changes" db:"attribute_changes"` // колонка ветки -> изменение
+	Description      string           `json:"description" db:"description"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Виды дуэлей
+const (
+	DuelKindQuest    = "quest"    // кто первым завершит квест
+	DuelKindCategory = "category" // кто выполнит больше задач категории к сроку
+)
+
+// Статусы дуэлей
+const (
+	DuelStatusPending   = "pending"
+	DuelStatusActive    = "active"
+	DuelStatusCompleted = "completed"
+	DuelStatusDeclined  = "declined"
+	DuelStatusCancelled = "cancelled"
+	DuelStatusExpired   = "expired"
+)
+
+type Duel struct {
+	ID              int        `json:"id" db:"id"`
+	ChallengerID    int        `json:"challenger_id" db:"challenger_id"`
+	OpponentID      int        `json:"opponent_id" db:"opponent_id"`
+	Kind            string     `json:"kind" db:"kind"`
+	QuestID         *int       `json:"quest_id,omitempty" db:"quest_id"`
+	Category        *string    `json:"category,omitempty" db:"category"`
+	Stake           int        `json:"stake" db:"stake"` // ставка каждой стороны, банк - 2 * stake
+	DurationHours   int        `json:"duration_hours" db:"duration_hours"`
+	Status          string     `json:"status" db:"status"`
+	WinnerID        *int       `json:"winner_id" db:"winner_id"` // nil у завершенной дуэли - ничья
+	ChallengerScore int        `json:"challenger_score" db:"challenger_score"`
+	OpponentScore   int        `json:"opponent_score" db:"opponent_score"`
+	RespondBy       time.Time  `json:"respond_by" db:"respond_by"`
+	StartedAt       *time.Time `json:"started_at" db:"started_at"`
+	EndsAt          *time.Time `json:"ends_at" db:"ends_at"`
+	ResolvedAt      *time.Time `json:"resolved_at" db:"resolved_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// DuelDetails - дуэль с именами участников и названием квеста
+type DuelDetails struct {
+	Duel
+	ChallengerUsername string  `json:"challenger_username" db:"challenger_username"`
+	OpponentUsername   string  `json:"opponent_username" db:"opponent_username"`
+	QuestTitle         *string `json:"quest_title,omitempty" db:"quest_title"`
+}
+
+type CreateDuelRequest struct {
+	OpponentID    int    `json:"opponent_id" binding:"required"`
+	Kind          string `json:"kind" binding:"required,oneof=quest category"`
+	QuestID       int    `json:"quest_id"` // для quest
+	Category      string `json:"category"` // для category
+	Stake         int    `json:"stake" binding:"required,min=1"`
+	DurationHours int    `json:"duration_hours" binding:"required,min=1"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Статусы сезонного события (переключаются планировщиком по starts_at/ends_at)
+const (
+	EventStatusScheduled = "scheduled"
+	EventStatusActive    = "active"
+	EventStatusEnded     = "ended"
+)
+
+// Event - сезонное событие (например "Новогодняя неделя силы воли").
+// Пока событие активно, его квесты доступны в магазине, а награды умножаются на множители.
+type Event struct {
+	ID             int       `json:"id" db:"id"`
+	Title          string    `json:"title" db:"title"`
+	Description    string    `json:"description" db:"description"`
+	StartsAt       time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt         time.Time `json:"ends_at" db:"ends_at"`
+	XPMultiplier   int       `json:"xp_multiplier" db:"xp_multiplier"`     // в процентах, 100 - без изменений
+	CoinMultiplier int       `json:"coin_multiplier" db:"coin_multiplier"` // в процентах, 100 - без изменений
+	Status         string    `json:"status" db:"status"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+
+	QuestIDs []int `json:"quest_ids" db:"-"`
+}
+
+// EventDetails - событие вместе с его эксклюзивными квестами
+type EventDetails struct {
+	Event
+	Quests []Quest `json:"quests"`
+}
+
+// EventRequest - создание/изменение события администратором
+type EventRequest struct {
+	Title          string    `json:"title" binding:"required"`
+	Description    string    `json:"description"`
+	StartsAt       time.Time `json:"starts_at" binding:"required"`
+	EndsAt         time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	XPMultiplier   int       `json:"xp_multiplier" binding:"omitempty,min=100,max=1000"`
+	CoinMultiplier int       `json:"coin_multiplier" binding:"omitempty,min=100,max=1000"`
+	QuestIDs       []int     `json:"quest_ids"`
+}
+
+// EventLeaderboardEntry - место пользователя в таблице лидеров события (по опыту, заработанному за время события)
+type EventLeaderboardEntry struct {
+	Rank            int    `json:"rank" db:"rank"`
+	UserID          int    `json:"user_id" db:"user_id"`
+	Username        string `json:"username" db:"username"`
+	XPEarned        int    `json:"xp_earned" db:"xp_earned"`
+	CoinsEarned     int    `json:"coins_earned" db:"coins_earned"`
+	QuestsCompleted int    `json:"quests_completed" db:"quests_completed"`
+}
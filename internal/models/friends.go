@@ -1,6 +1,15 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// Статусы записи в friends
+const (
+	FriendStatusPending  = "pending"
+	FriendStatusAccepted = "accepted"
+)
 
 type Friend struct {
 	ID        int       `json:"id" db:"id"`
@@ -9,18 +18,137 @@ type Friend struct {
 	Status    string    `json:"status" db:"status"`
 	Username  string    `json:"username" db:"username"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	Profile *PublicProfile `json:"profile,omitempty" db:"-"` // профиль второй стороны
 }
 
+// Статусы совместного квеста
+const (
+	SharedQuestStatusPending   = "pending" // приглашения ждут ответа
+	SharedQuestStatusActive    = "active"
+	SharedQuestStatusCompleted = "completed"
+	SharedQuestStatusDeclined  = "declined" // все приглашенные отказались
+	SharedQuestStatusCancelled = "cancelled"
+	SharedQuestStatusExpired   = "expired"
+)
+
+// Правила завершения совместного квеста
+const (
+	SharedQuestRuleAll    = "all"    // все участники выполнили все задачи
+	SharedQuestRuleQuorum = "quorum" // не меньше quorum участников выполнили все задачи
+	SharedQuestRulePool   = "pool"   // общий пул: каждая задача выполнена хотя бы одним участником
+)
+
+// Статусы участника совместного квеста
+const (
+	SharedMemberInvited  = "invited"
+	SharedMemberAccepted = "accepted"
+	SharedMemberDeclined = "declined"
+	SharedMemberExpired  = "expired"
+	// Место закрыто при старте: квест уже появился у участника другим путем
+	SharedMemberWithdrawn = "withdrawn"
+)
+
+// SharedQuest - совместный квест группы из 2-N участников
 type SharedQuest struct {
-	ID        int       `json:"id" db:"id"`
-	QuestID   int       `json:"quest_id" db:"quest_id"`
-	User1ID   int       `json:"user1_id" db:"user1_id"`
-	User2ID   int       `json:"user2_id" db:"user2_id"`
-	Status    string    `json:"status" db:"status"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID             int        `json:"id" db:"id"`
+	QuestID        int        `json:"quest_id" db:"quest_id"`
+	OwnerID        int        `json:"owner_id" db:"owner_id"`
+	Status         string     `json:"status" db:"status"`
+	CompletionRule string     `json:"completion_rule" db:"completion_rule"`
+	Quorum         *int       `json:"quorum,omitempty" db:"quorum"`
+	Price          int        `json:"price" db:"price"`
+	PayForAll      bool       `json:"pay_for_all" db:"pay_for_all"`
+	ExpiresAt      *time.Time `json:"expires_at" db:"expires_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty" db:"started_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+
+	// Устаревшие поля совместного квеста на двоих (прежний формат ответа):
+	// user1 - владелец, user2 - второй участник (только если участников двое)
+	User1ID    int  `json:"user1_id" db:"-"`
+	User2ID    *int `json:"user2_id,omitempty" db:"-"`
+	PayForBoth bool `json:"pay_for_both" db:"-"`
+}
+
+// SharedQuestMember - участник совместного квеста и его прогресс по задачам
+type SharedQuestMember struct {
+	UserID         int        `json:"user_id" db:"user_id"`
+	Username       string     `json:"username" db:"username"`
+	Status         string     `json:"status" db:"status"`
+	PaidBy         *int       `json:"paid_by,omitempty" db:"paid_by"`
+	RespondedAt    *time.Time `json:"responded_at,omitempty" db:"responded_at"`
+	TasksCompleted int        `json:"tasks_completed" db:"tasks_completed"`
+	TasksTotal     int        `json:"tasks_total" db:"tasks_total"`
+
+	// Квест участника (user_quests) - только для принявших приглашение
+	QuestStatus      *string    `json:"quest_status,omitempty" db:"quest_status"`
+	QuestStartedAt   *time.Time `json:"quest_started_at,omitempty" db:"quest_started_at"`
+	QuestCompletedAt *time.Time `json:"quest_completed_at,omitempty" db:"quest_completed_at"`
 }
 
+// SharedQuestDetails - совместный квест с участниками (приглашения и просмотр прогресса)
+type SharedQuestDetails struct {
+	SharedQuest
+	QuestTitle    string              `json:"quest_title" db:"quest_title"`
+	OwnerUsername string              `json:"owner_username" db:"owner_username"`
+	Members       []SharedQuestMember `json:"members" db:"-"`
+	// Для правила pool: сколько задач выполнено хотя бы одним участником
+	PoolTasksCompleted *int `json:"pool_tasks_completed,omitempty" db:"-"`
+	// Выполненные участниками задачи с реакциями и комментариями (только в просмотре квеста)
+	TaskCompletions []SharedTaskCompletion `json:"task_completions,omitempty" db:"-"`
+	// Задачи квеста со статусами каждого принявшего участника (только в просмотре квеста)
+	Tasks []SharedQuestTask `json:"tasks,omitempty" db:"-"`
+}
+
+// SharedQuestTask - задача совместного квеста и ее состояние у каждого участника
+type SharedQuestTask struct {
+	TaskID    int                     `json:"task_id"`
+	TaskTitle string                  `json:"task_title"`
+	TaskOrder *int                    `json:"task_order,omitempty"`
+	Members   []SharedMemberTaskState `json:"members"`
+}
+
+// SharedMemberTaskState - user_tasks участника по задаче совместного квеста
+type SharedMemberTaskState struct {
+	UserID      int        `json:"user_id" db:"user_id"`
+	UserTaskID  *int       `json:"user_task_id,omitempty" db:"user_task_id"`
+	Status      string     `json:"status" db:"status"` // not_started, если задачи у участника нет
+	RewardHeld  bool       `json:"reward_held" db:"reward_held"`
+	Deadline    *time.Time `json:"deadline,omitempty" db:"deadline"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// SharedTaskCompletion - выполненная участником задача совместного квеста
+type SharedTaskCompletion struct {
+	UserTaskID  int       `json:"user_task_id" db:"user_task_id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Username    string    `json:"username" db:"username"`
+	TaskID      int       `json:"task_id" db:"task_id"`
+	TaskTitle   string    `json:"task_title" db:"task_title"`
+	CompletedAt time.Time `json:"completed_at" db:"completed_at"`
+	SocialCounts
+}
+
+// MaxSharedQuestFriends - сколько друзей можно пригласить в один совместный квест
+const MaxSharedQuestFriends = 9
+
 type CreateSharedQuestRequest struct {
-	FriendID int `json:"friend_id" binding:"required"`
-	QuestID  int `json:"quest_id" binding:"required"`
+	FriendIDs      []int  `json:"friend_ids" binding:"omitempty,max=9,dive,min=1"`
+	QuestID        int    `json:"quest_id" binding:"required"`
+	CompletionRule string `json:"completion_rule" binding:"omitempty,oneof=all quorum pool"` // по умолчанию all
+	Quorum         int    `json:"quorum" binding:"omitempty,min=1"`                          // для quorum
+	PayForAll      bool   `json:"pay_for_all"`                                               // владелец платит за всех
+	ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1,max=168"`        // по умолчанию 48
+
+	// Устаревшие поля приглашения одного друга (прежний формат запроса), добавляются к friend_ids/pay_for_all
+	FriendID   int  `json:"friend_id" binding:"omitempty,min=1"`
+	PayForBoth bool `json:"pay_for_both"`
+}
+
+// Friends - все приглашаемые друзья с учетом устаревшего friend_id
+func (r CreateSharedQuestRequest) Friends() []int {
+	if r.FriendID == 0 || slices.Contains(r.FriendIDs, r.FriendID) {
+		return r.FriendIDs
+	}
+	return append(slices.Clone(r.FriendIDs), r.FriendID)
 }
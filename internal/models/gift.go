@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	GiftKindCoins = "coins"
+	GiftKindQuest = "quest"
+)
+
+type Gift struct {
+	ID          int       `json:"id" db:"id"`
+	SenderID    int       `json:"sender_id" db:"sender_id"`
+	RecipientID int       `json:"recipient_id" db:"recipient_id"`
+	Kind        string    `json:"kind" db:"kind"`
+	Amount      int       `json:"amount" db:"amount"` // монеты (для квеста - его цена)
+	QuestID     *int      `json:"quest_id" db:"quest_id"`
+	Message     *string   `json:"message" db:"message"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type GiftCoinsRequest struct {
+	Amount  int    `json:"amount" binding:"required,min=1"`
+	Message string `json:"message" binding:"max=500"`
+}
+
+type GiftQuestRequest struct {
+	QuestID int    `json:"quest_id" binding:"required"`
+	Message string `json:"message" binding:"max=500"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Эффекты предметов (items.effect_type)
+const (
+	ItemEffectStreakFreeze  = "streak_freeze"  // тратится автоматически при пропуске дня серии
+	ItemEffectXPBooster     = "xp_booster"     // множитель опыта на duration_hours
+	ItemEffectTimerExtender = "timer_extender" // продлевает expires_at начатого квеста
+)
+
+// Item - предмет магазина
+type Item struct {
+	Code          string `json:"code" db:"code"`
+	Name          string `json:"name" db:"name"`
+	Description   string `json:"description" db:"description"`
+	Price         int    `json:"price" db:"price"`
+	EffectType    string `json:"effect_type" db:"effect_type"`
+	EffectValue   int    `json:"effect_value" db:"effect_value"`     // xp_booster - множитель в процентах (150 = x1.5), timer_extender - часы
+	DurationHours int    `json:"duration_hours" db:"duration_hours"` // xp_booster - время действия
+	IsActive      bool   `json:"is_active" db:"is_active"`
+}
+
+// InventoryItem - предмет в инвентаре пользователя
+type InventoryItem struct {
+	Item
+	Quantity int `json:"quantity" db:"quantity"`
+}
+
+// ActiveEffect - действующий эффект от использованного предмета
+type ActiveEffect struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	ItemCode    string    `json:"item_code" db:"item_code"`
+	EffectType  string    `json:"effect_type" db:"effect_type"`
+	EffectValue int       `json:"effect_value" db:"effect_value"`
+	StartsAt    time.Time `json:"starts_at" db:"starts_at"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+}
+
+type PurchaseItemRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1,max=100"`
+}
+
+type UseItemRequest struct {
+	QuestID int `json:"quest_id"` // для timer_extender
+}
+
+// UseItemResult - результат использования предмета
+type UseItemResult struct {
+	ItemCode          string        `json:"item_code"`
+	RemainingQuantity int           `json:"remaining_quantity"`
+	Effect            *ActiveEffect `json:"effect,omitempty"`           // xp_booster
+	QuestExpiresAt    *time.Time    `json:"quest_expires_at,omitempty"` // timer_extender
+}
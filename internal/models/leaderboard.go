@@ -0,0 +1,57 @@
+package models
+
+// Метрики таблиц лидеров
+const (
+	LeaderboardMetricXP     = "xp"
+	LeaderboardMetricLevel  = "level"
+	LeaderboardMetricStreak = "streak"
+	LeaderboardMetricQuests = "quests"
+)
+
+// Периоды таблиц лидеров (day и week считаются по user_xp_periods, в UTC)
+const (
+	LeaderboardPeriodDay  = "day"
+	LeaderboardPeriodWeek = "week"
+	LeaderboardPeriodAll  = "all"
+)
+
+const (
+	LeaderboardScopeGlobal  = "global"
+	LeaderboardScopeFriends = "friends" // текущий пользователь и его друзья
+)
+
+// LeaderboardQuery - параметры таблицы лидеров; Category - ветка квестов (опционально).
+// After - курсор страницы (последняя запись предыдущей), вместо offset.
+type LeaderboardQuery struct {
+	Metric   string             `json:"metric"`
+	Period   string             `json:"period"`
+	Scope    string             `json:"scope"`
+	Category string             `json:"category,omitempty"`
+	After    *LeaderboardCursor `json:"-"`
+}
+
+// LeaderboardCursor - позиция в таблице лидеров (записи упорядочены по score DESC, user_id)
+type LeaderboardCursor struct {
+	Score  int64 `json:"score"`
+	UserID int   `json:"user_id"`
+}
+
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank" db:"rank"`
+	UserID   int    `json:"user_id" db:"user_id"`
+	Username string `json:"username" db:"username"`
+	Score    int64  `json:"score" db:"score"`
+}
+
+// Leaderboard - страница таблицы лидеров и место текущего пользователя (nil - вне таблицы или скрыт).
+// NextCursor передается в ?after_score= &after_user_id= для следующей страницы (nil - страница последняя).
+type Leaderboard struct {
+	LeaderboardQuery
+	Entries    []LeaderboardEntry `json:"entries"`
+	Me         *LeaderboardEntry  `json:"me"`
+	NextCursor *LeaderboardCursor `json:"next_cursor"`
+}
+
+type LeaderboardOptOutRequest struct {
+	OptOut *bool `json:"opt_out" binding:"required"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Типы транзакций (user_coin_transactions.transaction_type)
+const (
+	TransactionTypeEarned = "earned"
+	TransactionTypeSpent  = "spent"
+	TransactionTypeBonus  = "bonus"
+)
+
+// Типы сущностей, на которые ссылается транзакция (user_coin_transactions.reference_type)
+const (
+	ReferenceTypeQuest       = "quest"
+	ReferenceTypeTask        = "task"
+	ReferenceTypeAchievement = "achievement"
+	ReferenceTypeGift        = "gift"
+	ReferenceTypeDailyReward = "daily_reward"
+	ReferenceTypeItem        = "item"
+	ReferenceTypeDuel        = "duel"
+)
+
+var (
+	TransactionTypes = []string{TransactionTypeEarned, TransactionTypeSpent, TransactionTypeBonus}
+	ReferenceTypes   = []string{ReferenceTypeQuest, ReferenceTypeTask, ReferenceTypeAchievement, ReferenceTypeGift, ReferenceTypeDailyReward, ReferenceTypeItem, ReferenceTypeDuel}
+)
+
+type CoinTransaction struct {
+	ID              int       `json:"id" db:"id"`
+	UserID          int       `json:"user_id" db:"user_id"`
+	ReferenceType   string    `json:"reference_type" db:"reference_type"`
+	ReferenceID     *int      `json:"reference_id" db:"reference_id"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	Amount          int       `json:"amount" db:"amount"` // > 0 - начисление, < 0 - списание
+	BalanceAfter    *int      `json:"balance_after" db:"balance_after"`
+	Description     *string   `json:"description" db:"description"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+
+	ReferenceTitle *string `json:"reference_title,omitempty" db:"reference_title"` // название квеста/задачи
+}
+
+// TransactionFilter - фильтры истории транзакций (пустые поля не фильтруют)
+type TransactionFilter struct {
+	TransactionType string
+	ReferenceType   string
+	From            *time.Time
+	To              *time.Time
+	Limit           int
+	Offset          int
+}
+
+type TransactionHistory struct {
+	Items  []CoinTransaction `json:"items"`
+	Total  int               `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// LedgerDrift - расхождение coin_balance пользователя с суммой по журналу транзакций
+type LedgerDrift struct {
+	UserID      int    `json:"user_id" db:"user_id"`
+	Username    string `json:"username" db:"username"`
+	CoinBalance int    `json:"coin_balance" db:"coin_balance"`
+	LedgerSum   int    `json:"ledger_sum" db:"ledger_sum"`
+	Drift       int    `json:"drift" db:"drift"`
+}
+
+type LedgerReconciliationReport struct {
+	CheckedUsers int           `json:"checked_users"`
+	DriftedUsers int           `json:"drifted_users"`
+	TotalDrift   int           `json:"total_drift"`
+	Drifts       []LedgerDrift `json:"drifts"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы уведомлений
+const (
+	NotificationGiftCoins  = "gift_coins"
+	NotificationGiftQuest  = "gift_quest"
+	NotificationEventEnded = "event_ended"
+	NotificationDecay      = "decay"
+
+	NotificationFriendRequest  = "friend_request"
+	NotificationFriendAccepted = "friend_accepted"
+
+	NotificationSharedQuestInvite   = "shared_quest_invite"
+	NotificationSharedQuestAccepted = "shared_quest_accepted"
+	NotificationSharedQuestDeclined = "shared_quest_declined"
+	NotificationSharedQuestExpired  = "shared_quest_expired"
+	NotificationSharedQuestStarted  = "shared_quest_started"
+	NotificationSharedQuestCanceled = "shared_quest_cancelled"
+
+	NotificationTeamMemberJoined   = "team_member_joined"
+	NotificationTeamRemoved        = "team_removed"
+	NotificationTeamQuestCompleted = "team_quest_completed"
+	NotificationTeamAchievement    = "team_achievement"
+
+	NotificationReaction = "reaction"
+	NotificationComment  = "comment"
+
+	NotificationDuelChallenge = "duel_challenge"
+	NotificationDuelAccepted  = "duel_accepted"
+	NotificationDuelDeclined  = "duel_declined"
+	NotificationDuelCanceled  = "duel_cancelled"
+	NotificationDuelExpired   = "duel_expired"
+	NotificationDuelResolved  = "duel_resolved"
+
+	NotificationTaskReviewApproved = "task_review_approved"
+	NotificationTaskReviewRejected = "task_review_rejected"
+)
+
+type Notification struct {
+	ID        int              `json:"id" db:"id"`
+	UserID    int              `json:"user_id" db:"user_id"`
+	Kind      string           `json:"kind" db:"kind"`
+	Payload   *json.RawMessage `json:"payload" db:"payload"`
+	IsRead    bool             `json:"is_read" db:"is_read"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// Видимость профиля и его полей
+const (
+	VisibilityPublic  = "public"
+	VisibilityFriends = "friends" // только принятые друзья
+	VisibilityPrivate = "private" // только сам пользователь
+)
+
+// PrivacySettings - какие события пользователя видят его друзья и кому видны поля его профиля
+type PrivacySettings struct {
+	UserID            int  `json:"user_id" db:"user_id"`
+	ShareTasks        bool `json:"share_tasks" db:"share_tasks"`
+	ShareQuests       bool `json:"share_quests" db:"share_quests"`
+	ShareLevelUps     bool `json:"share_level_ups" db:"share_level_ups"`
+	ShareAchievements bool `json:"share_achievements" db:"share_achievements"`
+	SharePurchases    bool `json:"share_purchases" db:"share_purchases"`
+
+	ProfileVisibility    string `json:"profile_visibility" db:"profile_visibility"`
+	StatsVisibility      string `json:"stats_visibility" db:"stats_visibility"`
+	AttributesVisibility string `json:"attributes_visibility" db:"attributes_visibility"`
+	BadgesVisibility     string `json:"badges_visibility" db:"badges_visibility"`
+	QuestsVisibility     string `json:"quests_visibility" db:"quests_visibility"`
+
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultPrivacySettings - настройки пользователя, который их не менял (совпадают с DEFAULT в initDB.sql)
+func DefaultPrivacySettings(userID int) PrivacySettings {
+	return PrivacySettings{
+		UserID:               userID,
+		ShareTasks:           true,
+		ShareQuests:          true,
+		ShareLevelUps:        true,
+		ShareAchievements:    true,
+		ProfileVisibility:    VisibilityPublic,
+		StatsVisibility:      VisibilityPublic,
+		AttributesVisibility: VisibilityPublic,
+		BadgesVisibility:     VisibilityPublic,
+		QuestsVisibility:     VisibilityFriends,
+	}
+}
+
+// UpdatePrivacySettingsRequest - частичное обновление: не переданные поля не меняются
+type UpdatePrivacySettingsRequest struct {
+	ShareTasks        *bool `json:"share_tasks"`
+	ShareQuests       *bool `json:"share_quests"`
+	ShareLevelUps     *bool `json:"share_level_ups"`
+	ShareAchievements *bool `json:"share_achievements"`
+	SharePurchases    *bool `json:"share_purchases"`
+
+	ProfileVisibility    *string `json:"profile_visibility" binding:"omitempty,oneof=public friends private"`
+	StatsVisibility      *string `json:"stats_visibility" binding:"omitempty,oneof=public friends private"`
+	AttributesVisibility *string `json:"attributes_visibility" binding:"omitempty,oneof=public friends private"`
+	BadgesVisibility     *string `json:"badges_visibility" binding:"omitempty,oneof=public friends private"`
+	QuestsVisibility     *string `json:"quests_visibility" binding:"omitempty,oneof=public friends private"`
+}
+
+// PublicProfile - профиль пользователя, каким его видит другой пользователь.
+// Скрытые настройками приватности поля равны nil.
+type PublicProfile struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	IsFriend bool   `json:"is_friend"`
+
+	Level           *int `json:"level,omitempty"`
+	XpPoints        *int `json:"xp_points,omitempty"`
+	CurrentStreak   *int `json:"current_streak,omitempty"`
+	LongestStreak   *int `json:"longest_streak,omitempty"`
+	QuestsCompleted *int `json:"quests_completed,omitempty"`
+
+	Attributes *UserAttributes `json:"attributes,omitempty"`
+
+	// Только в просмотре одного профиля (/users/:id)
+	Badges          []Badge          `json:"badges,omitempty"`
+	CompletedQuests []CompletedQuest `json:"completed_quests,omitempty"`
+
+	HiddenFields []string `json:"hidden_fields,omitempty"` // группы полей, скрытые от текущего пользователя
+}
+
+// UserAttributes - уровни веток пользователя
+type UserAttributes struct {
+	Health       int `json:"health" db:"health_level"`
+	MentalHealth int `json:"mental_health" db:"mental_health_level"`
+	Intelligence int `json:"intelligence" db:"intelligence_level"`
+	Charisma     int `json:"charisma" db:"charisma_level"`
+	Willpower    int `json:"willpower" db:"willpower_level"`
+}
+
+// Badge - открытое достижение: личное (achievement) или команды пользователя (team)
+type Badge struct {
+	Source      string    `json:"source" db:"source"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	UnlockedAt  time.Time `json:"unlocked_at" db:"unlocked_at"`
+}
+
+type CompletedQuest struct {
+	QuestID     int       `json:"quest_id" db:"quest_id"`
+	Title       string    `json:"title" db:"title"`
+	Category    string    `json:"category" db:"category"`
+	CompletedAt time.Time `json:"completed_at" db:"completed_at"`
+}
+
+// Отношение найденного пользователя к тому, кто ищет
+const (
+	RelationNone            = "none"
+	RelationFriend          = "friend"
+	RelationRequestSent     = "request_sent"     // текущий пользователь отправил заявку
+	RelationRequestReceived = "request_received" // заявка пришла текущему пользователю
+)
+
+// UserSearchResult - пользователь из поиска по имени
+type UserSearchResult struct {
+	ID              int    `json:"id" db:"id"`
+	Username        string `json:"username" db:"username"`
+	Relation        string `json:"relation" db:"relation"`
+	FriendRequestID *int   `json:"friend_request_id,omitempty" db:"friend_request_id"` // для принятия/отмены заявки
+}
@@ -25,7 +25,33 @@ type Quest struct {
 	RewardXP       int              `json:"reward_xp" db:"reward_xp"`
 	RewardCoin     int              `json:"reward_coin" db:"reward_coin"`
 	TimeLimitHours int              `json:"time_limit_hours" db:"time_limit_hours"`
+	PackKey        *string          `json:"pack_key,omitempty" db:"pack_key"`
 	Tasks          []Task           `json:"tasks,omitempty"`
+
+	// --- механики совместного прохождения
+	QuestCoopSettings
+
+	// --- агрегаты по отзывам (заполняются не во всех запросах)
+	AvgRating           *float64 `json:"avg_rating" db:"avg_rating"`
+	AvgDifficultyRating *float64 `json:"avg_difficulty_rating" db:"avg_difficulty_rating"`
+	ReviewsCount        int      `json:"reviews_count" db:"reviews_count"`
+}
+
+// QuestCoopSettings - механики квеста при прохождении совместно (проценты от reward_xp/reward_coin)
+type QuestCoopSettings struct {
+	// Награда всем участникам, если каждый выполнил квест и между первым и последним
+	// прошло не больше CoopBonusWindowHours (0 - без ограничения)
+	CoopBonusPercent     int `json:"coop_bonus_percent" db:"coop_bonus_percent"`
+	CoopBonusWindowHours int `json:"coop_bonus_window_hours" db:"coop_bonus_window_hours"`
+	// Награда участнику, не выполнившему все задачи (квест засчитан по правилу quorum или pool)
+	CoopPartialRewardPercent int `json:"coop_partial_reward_percent" db:"coop_partial_reward_percent"`
+	// На сколько процентов уменьшается награда выполнивших, если кто-то из партнеров не справился
+	CoopFailPenaltyPercent int `json:"coop_fail_penalty_percent" db:"coop_fail_penalty_percent"`
+}
+
+// DefaultQuestCoopSettings - без кооперативных механик: каждый получает обычную награду (DEFAULT в initDB.sql)
+func DefaultQuestCoopSettings() QuestCoopSettings {
+	return QuestCoopSettings{CoopBonusPercent: 100, CoopPartialRewardPercent: 100}
 }
 
 type Task struct {
@@ -50,6 +76,7 @@ type Task struct {
 	UpdatedByAI    *bool      `json:"updated_by_ai" db:"updated_by_ai"`
 	CompletedAt    *time.Time `json:"completed_at" db:"completed_at"`
 	IsConfirmed    *bool      `json:"is_confirmed" db:"is_confirmed"`
+	RewardHeld     *bool      `json:"reward_held" db:"reward_held"` // выполнение на проверке, награда удержана
 
 	XpGained   *int `json:"xp_gained" db:"xp_gained"`     // nullable
 	CoinGained *int `json:"coin_gained" db:"coin_gained"` // nullable
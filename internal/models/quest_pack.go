@@ -0,0 +1,121 @@
+package models
+
+// QuestPackVersion - текущая версия формата quest pack
+const QuestPackVersion = 1
+
+// QuestPack - версионированный бандл каталога квестов (JSON или YAML)
+type QuestPack struct {
+	Version int              `json:"version" yaml:"version"`
+	Quests  []QuestPackQuest `json:"quests" yaml:"quests"`
+	Chains  []QuestPackChain `json:"chains,omitempty" yaml:"chains,omitempty"`
+}
+
+type QuestPackQuest struct {
+	Key            string          `json:"key" yaml:"key"`
+	Title          string          `json:"title" yaml:"title"`
+	Description    string          `json:"description" yaml:"description"`
+	Category       string          `json:"category" yaml:"category"`
+	Rarity         string          `json:"rarity" yaml:"rarity"`
+	Difficulty     int             `json:"difficulty" yaml:"difficulty"`
+	Price          int             `json:"price" yaml:"price"`
+	IsSequential   bool            `json:"is_sequential" yaml:"is_sequential"`
+	RewardXP       int             `json:"reward_xp" yaml:"reward_xp"`
+	RewardCoin     int             `json:"reward_coin" yaml:"reward_coin"`
+	TimeLimitHours int             `json:"time_limit_hours" yaml:"time_limit_hours"`
+	Conditions     map[string]any  `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Bonus          map[string]any  `json:"bonus,omitempty" yaml:"bonus,omitempty"`
+	Coop           *QuestPackCoop  `json:"coop,omitempty" yaml:"coop,omitempty"` // не задано - без кооперативных механик
+	Tasks          []QuestPackTask `json:"tasks" yaml:"tasks"`
+}
+
+// QuestPackCoop - механики совместного прохождения квеста (см. QuestCoopSettings).
+// Не заданные поля берутся из DefaultQuestCoopSettings.
+type QuestPackCoop struct {
+	BonusPercent         *int `json:"bonus_percent,omitempty" yaml:"bonus_percent,omitempty"`
+	BonusWindowHours     *int `json:"bonus_window_hours,omitempty" yaml:"bonus_window_hours,omitempty"`
+	PartialRewardPercent *int `json:"partial_reward_percent,omitempty" yaml:"partial_reward_percent,omitempty"`
+	FailPenaltyPercent   *int `json:"fail_penalty_percent,omitempty" yaml:"fail_penalty_percent,omitempty"`
+}
+
+// Settings - настройки квеста с учетом значений по умолчанию (nil - без кооперативных механик)
+func (c *QuestPackCoop) Settings() QuestCoopSettings {
+	s := DefaultQuestCoopSettings()
+	if c == nil {
+		return s
+	}
+	if c.BonusPercent != nil {
+		s.CoopBonusPercent = *c.BonusPercent
+	}
+	if c.BonusWindowHours != nil {
+		s.CoopBonusWindowHours = *c.BonusWindowHours
+	}
+	if c.PartialRewardPercent != nil {
+		s.CoopPartialRewardPercent = *c.PartialRewardPercent
+	}
+	if c.FailPenaltyPercent != nil {
+		s.CoopFailPenaltyPercent = *c.FailPenaltyPercent
+	}
+	return s
+}
+
+// NewQuestPackCoop - механики квеста для экспорта в пак (nil, если они не отличаются от значений по умолчанию)
+func NewQuestPackCoop(s QuestCoopSettings) *QuestPackCoop {
+	if s == DefaultQuestCoopSettings() {
+		return nil
+	}
+	return &QuestPackCoop{
+		BonusPercent:         &s.CoopBonusPercent,
+		BonusWindowHours:     &s.CoopBonusWindowHours,
+		PartialRewardPercent: &s.CoopPartialRewardPercent,
+		FailPenaltyPercent:   &s.CoopFailPenaltyPercent,
+	}
+}
+
+type QuestPackTask struct {
+	Order          int    `json:"order" yaml:"order"`
+	Title          string `json:"title" yaml:"title"`
+	Description    string `json:"description" yaml:"description"`
+	Difficulty     int    `json:"difficulty" yaml:"difficulty"`
+	Rarity         string `json:"rarity" yaml:"rarity"`
+	Category       string `json:"category" yaml:"category"`
+	BaseXpReward   int    `json:"base_xp_reward" yaml:"base_xp_reward"`
+	BaseCoinReward int    `json:"base_coin_reward" yaml:"base_coin_reward"`
+}
+
+// QuestPackChain - цепочка квестов (ключи квестов в порядке прохождения)
+type QuestPackChain struct {
+	Key    string   `json:"key" yaml:"key"`
+	Title  string   `json:"title" yaml:"title"`
+	Quests []string `json:"quests" yaml:"quests"`
+}
+
+// Результат импорта по каждой записи пака
+const (
+	QuestPackActionCreated = "created"
+	QuestPackActionUpdated = "updated"
+	QuestPackActionInvalid = "invalid"
+)
+
+type QuestPackEntryResult struct {
+	Kind   string   `json:"kind"` // quest / chain
+	Key    string   `json:"key"`
+	Index  int      `json:"index"`
+	Action string   `json:"action,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type QuestPackImportReport struct {
+	DryRun  bool                   `json:"dry_run"`
+	Applied bool                   `json:"applied"`
+	Entries []QuestPackEntryResult `json:"entries"`
+}
+
+// HasErrors - есть ли в отчете невалидные записи
+func (r *QuestPackImportReport) HasErrors() bool {
+	for _, e := range r.Entries {
+		if len(e.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы параметров шаблона квеста
+const (
+	TemplateParamInt    = "int"
+	TemplateParamFloat  = "float"
+	TemplateParamTime   = "time"   // "HH:MM", в формулах - минуты от полуночи
+	TemplateParamString = "string" // только из options
+)
+
+// QuestTemplate - параметризованный шаблон квеста.
+// В title/description/текстах задач используются плейсхолдеры вида {{wake_time}},
+// награды и цена задаются формулами от параметров (например "days * 20").
+type QuestTemplate struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description"`
+	Category     string `json:"category" binding:"required"`
+	Rarity       string `json:"rarity" binding:"required,oneof=free common rare epic legendary"`
+	Difficulty   int    `json:"difficulty" binding:"min=0"`
+	IsSequential bool   `json:"is_sequential"`
+
+	Params []QuestTemplateParam `json:"params" binding:"dive"`
+	Tasks  []QuestTemplateTask  `json:"tasks" binding:"required,min=1,dive"`
+
+	PriceFormula          string `json:"price_formula"`
+	RewardXPFormula       string `json:"reward_xp_formula" binding:"required"`
+	RewardCoinFormula     string `json:"reward_coin_formula" binding:"required"`
+	TimeLimitHoursFormula string `json:"time_limit_hours_formula"`
+
+	CreatedBy *int      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type QuestTemplateParam struct {
+	Name    string   `json:"name" binding:"required"`
+	Type    string   `json:"type" binding:"required,oneof=int float time string"`
+	Label   string   `json:"label,omitempty"`
+	Min     *string  `json:"min,omitempty"` // для int/float - число, для time - "HH:MM"
+	Max     *string  `json:"max,omitempty"`
+	Default *string  `json:"default,omitempty"`
+	Options []string `json:"options,omitempty"` // для string
+}
+
+type QuestTemplateTask struct {
+	Title           string `json:"title" binding:"required"`
+	Description     string `json:"description"`
+	Difficulty      int    `json:"difficulty" binding:"min=0"`
+	Rarity          string `json:"rarity" binding:"required,oneof=free common rare epic legendary"`
+	Category        string `json:"category" binding:"required"`
+	BaseXpFormula   string `json:"base_xp_formula"`
+	BaseCoinFormula string `json:"base_coin_formula"`
+	RepeatFormula   string `json:"repeat_formula,omitempty"` // сколько раз повторить задачу (например "days"), номер повтора - {{n}}
+}
+
+// QuestTemplateRow - строка quest_templates в БД
+type QuestTemplateRow struct {
+	ID                    int              `db:"id"`
+	Title                 string           `db:"title"`
+	Description           string           `db:"description"`
+	Category              string           `db:"category"`
+	Rarity                string           `db:"rarity"`
+	Difficulty            int              `db:"difficulty"`
+	IsSequential          bool             `db:"is_sequential"`
+	ParamsJson            *json.RawMessage `db:"params_json"`
+	TasksJson             *json.RawMessage `db:"tasks_json"`
+	PriceFormula          string           `db:"price_formula"`
+	RewardXPFormula       string           `db:"reward_xp_formula"`
+	RewardCoinFormula     string           `db:"reward_coin_formula"`
+	TimeLimitHoursFormula string           `db:"time_limit_hours_formula"`
+	CreatedBy             *int             `db:"created_by"`
+	CreatedAt             time.Time        `db:"created_at"`
+}
+
+type InstantiateQuestTemplateRequest struct {
+	Params map[string]any `json:"params"` // имя параметра -> значение (число или строка)
+}
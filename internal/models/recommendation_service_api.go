@@ -35,6 +35,11 @@ type RecommendationService_questToAdd struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Category    string `json:"category,omitempty"`
+
+	// агрегаты отзывов
+	AvgRating           *float64 `json:"avg_rating,omitempty"`
+	AvgDifficultyRating *float64 `json:"avg_difficulty_rating,omitempty"`
+	ReviewsCount        int      `json:"reviews_count,omitempty"`
 }
 
 type RecommendationService_AddQuests_Request struct {
@@ -46,6 +51,8 @@ type RecommendationService_AddQuests_Request struct {
 type UserWithQuestIDS struct {
 	UserID   int   `json:"user_id"`
 	QuestIDs []int `json:"quest_ids"`
+
+	QuestRatings map[int]int `json:"quest_ratings,omitempty"` // quest_id -> оценка пользователя 1-5
 }
 
 type RecommendationService_AddUsers_Request struct {
@@ -72,7 +79,7 @@ type RecommendationService_RecommendUsers_Response struct {
 }
 
 type UserProfileWithSimilarityScore struct {
-	UserProfile     UserProfile    `json:"user_profile"`
+	UserProfile     PublicProfile  `json:"user_profile"`
 	SimilarityScore float64        `json:"similarity_score"`
 	Explanation     map[string]any `json:"explanation"`
 }
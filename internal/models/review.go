@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type QuestReview struct {
+	ID               int       `json:"id" db:"id"`
+	UserID           int       `json:"user_id" db:"user_id"`
+	QuestID          int       `json:"quest_id" db:"quest_id"`
+	Username         string    `json:"username" db:"username"`
+	Rating           int       `json:"rating" db:"rating"`
+	DifficultyRating int       `json:"difficulty_rating" db:"difficulty_rating"`
+	ReviewText       *string   `json:"review_text" db:"review_text"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateQuestReviewRequest struct {
+	Rating           int    `json:"rating" binding:"required,min=1,max=5"`
+	DifficultyRating int    `json:"difficulty_rating" binding:"required,min=1,max=5"`
+	ReviewText       string `json:"review_text" binding:"max=2000"`
+}
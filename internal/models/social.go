@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Виды реакций
+const (
+	ReactionKudos = "kudos"
+	ReactionCheer = "cheer"
+)
+
+// Объекты, на которые можно реагировать и которые можно комментировать
+const (
+	SocialTargetActivity = "activity" // событие ленты (activity_events.id)
+	SocialTargetTask     = "task"     // выполненная задача совместного квеста (user_tasks.id)
+)
+
+// SocialCounts - счетчики реакций и комментариев, встраиваются в ленту и совместные квесты
+type SocialCounts struct {
+	KudosCount    int            `json:"kudos_count" db:"kudos_count"`
+	CheerCount    int            `json:"cheer_count" db:"cheer_count"`
+	CommentsCount int            `json:"comments_count" db:"comments_count"`
+	MyReactions   pq.StringArray `json:"my_reactions" db:"my_reactions"` // реакции текущего пользователя
+}
+
+type SocialComment struct {
+	ID         int       `json:"id" db:"id"`
+	TargetType string    `json:"target_type" db:"target_type"`
+	TargetID   int       `json:"target_id" db:"target_id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Username   string    `json:"username" db:"username"`
+	Body       string    `json:"body" db:"body"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type AddReactionRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=kudos cheer"`
+}
+
+type AddCommentRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=500"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// Роли участников команды
+const (
+	TeamRoleOwner   = "owner"
+	TeamRoleOfficer = "officer" // может менять код приглашения, командные квесты и исключать участников
+	TeamRoleMember  = "member"
+)
+
+// Метрики достижений команд
+const (
+	TeamMetricXPPool              = "xp_pool"
+	TeamMetricQuestsCompleted     = "quests_completed"
+	TeamMetricMembers             = "members"
+	TeamMetricTeamQuestsCompleted = "team_quests_completed"
+)
+
+type Team struct {
+	ID              int       `json:"id" db:"id"`
+	Name            string    `json:"name" db:"name"`
+	Description     string    `json:"description" db:"description"`
+	OwnerID         int       `json:"owner_id" db:"owner_id"`
+	InviteCode      *string   `json:"invite_code,omitempty" db:"invite_code"` // только для owner/officer
+	XPPool          int64     `json:"xp_pool" db:"xp_pool"`
+	QuestsCompleted int       `json:"quests_completed" db:"quests_completed"`
+	MembersCount    int       `json:"members_count" db:"members_count"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+type TeamMember struct {
+	UserID        int       `json:"user_id" db:"user_id"`
+	Username      string    `json:"username" db:"username"`
+	Role          string    `json:"role" db:"role"`
+	XPContributed int64     `json:"xp_contributed" db:"xp_contributed"`
+	JoinedAt      time.Time `json:"joined_at" db:"joined_at"`
+}
+
+type TeamAchievement struct {
+	ID          int        `json:"id" db:"id"`
+	Code        string     `json:"code" db:"code"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	Metric      string     `json:"metric" db:"metric"`
+	Threshold   int64      `json:"threshold" db:"threshold"`
+	UnlockedAt  *time.Time `json:"unlocked_at" db:"unlocked_at"` // nil - еще не открыто
+}
+
+type TeamQuest struct {
+	QuestID           int        `json:"quest_id" db:"quest_id"`
+	QuestTitle        string     `json:"quest_title" db:"quest_title"`
+	TargetCompletions int        `json:"target_completions" db:"target_completions"`
+	Completions       int        `json:"completions" db:"completions"`
+	AddedBy           *int       `json:"added_by" db:"added_by"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt       *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+// TeamDetails - команда с достижениями и ролью текущего пользователя
+type TeamDetails struct {
+	Team
+	MyRole       string            `json:"my_role,omitempty"`
+	Achievements []TeamAchievement `json:"achievements"`
+}
+
+// TeamMemberProgress - вклад участника с момента вступления (по user_quests и user_tasks)
+type TeamMemberProgress struct {
+	UserID             int    `json:"user_id" db:"user_id"`
+	Username           string `json:"username" db:"username"`
+	Role               string `json:"role" db:"role"`
+	XPContributed      int64  `json:"xp_contributed" db:"xp_contributed"`
+	QuestsCompleted    int    `json:"quests_completed" db:"quests_completed"`
+	QuestsActive       int    `json:"quests_active" db:"quests_active"`
+	TasksCompleted     int    `json:"tasks_completed" db:"tasks_completed"`
+	TasksCompletedWeek int    `json:"tasks_completed_week" db:"tasks_completed_week"`
+}
+
+type TeamProgress struct {
+	TeamID             int                  `json:"team_id"`
+	XPPool             int64                `json:"xp_pool"`
+	QuestsCompleted    int                  `json:"quests_completed"`
+	QuestsActive       int                  `json:"quests_active"`
+	TasksCompleted     int                  `json:"tasks_completed"`
+	TasksCompletedWeek int                  `json:"tasks_completed_week"`
+	Members            []TeamMemberProgress `json:"members"`
+	TeamQuests         []TeamQuest          `json:"team_quests"`
+}
+
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+type JoinTeamRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"`
+}
+
+type UpdateTeamMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=officer member"`
+}
+
+type AddTeamQuestRequest struct {
+	QuestID           int `json:"quest_id" binding:"required"`
+	TargetCompletions int `json:"target_completions" binding:"omitempty,min=1"` // по умолчанию 1
+}
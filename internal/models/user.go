@@ -21,22 +21,29 @@ type User struct {
 	CurrentStreak int `json:"current_streak" db:"current_streak"`
 	LongestStreak int `json:"longest_streak" db:"longest_streak"`
 
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	LastActiveAt time.Time `json:"last_active_at" db:"last_active_at"`
-}
+	QuestsCompleted   int  `json:"quests_completed" db:"quests_completed"`
+	LeaderboardOptOut bool `json:"leaderboard_opt_out" db:"leaderboard_opt_out"` // скрыт из таблиц лидеров
 
-type UserProfile struct {
-	ID       int    `json:"id" db:"id"`
-	Username string `json:"username" db:"username"`
-	Email    string `json:"email" db:"email"`
+	Timezone string `json:"timezone" db:"timezone"`
 
-	XpPoints    int `json:"xp_points,omitempty" db:"xp_points"`
-	CoinBalance int `json:"coin_balance,omitempty" db:"coin_balance"`
-	Level       int `json:"level,omitempty" db:"level"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	LastActiveAt time.Time  `json:"last_active_at" db:"last_active_at"`
+	LastDecayAt  *time.Time `json:"last_decay_at" db:"last_decay_at"` // последнее затухание прогресса из-за неактивности
+}
 
-	CurrentStreak int `json:"current_streak,omitempty" db:"current_streak"`
-	LongestStreak int `json:"longest_streak,omitempty" db:"longest_streak"`
+type UpdateTimezoneRequest struct {
+	Timezone string `json:"timezone" binding:"required"`
+}
+
+// LevelThreshold - опыт, необходимый для достижения уровня
+type LevelThreshold struct {
+	Level int `json:"level"`
+	XP    int `json:"xp"`
+}
 
-	CreatedAt    time.Time `json:"created_at,omitempty" db:"created_at"`
-	LastActiveAt time.Time `json:"last_active_at,omitempty" db:"last_active_at"`
+// LevelCurveInfo - пороги опыта всех уровней по текущей конфигурации экономики
+type LevelCurveInfo struct {
+	CurveType  string           `json:"curve_type"`
+	MaxLevel   int              `json:"max_level"`
+	Thresholds []LevelThreshold `json:"thresholds"`
 }
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// recordActivity добавляет событие в ленту активности пользователя.
+// Видимость для друзей определяется настройками приватности при чтении ленты.
+func recordActivity(ctx context.Context, tx sqlx.ExecerContext, userID int, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO activity_events (user_id, kind, payload) VALUES ($1, $2, $3::jsonb)
+	`, userID, kind, string(data))
+	return err
+}
+
+// activityVisibleToFriends - условие видимости события a друзьям по настройкам приватности p
+// (user_privacy_settings, LEFT JOIN; без строки действуют значения по умолчанию)
+var activityVisibleToFriends = fmt.Sprintf(`CASE a.kind
+		WHEN '%s' THEN COALESCE(p.share_tasks, TRUE)
+		WHEN '%s' THEN COALESCE(p.share_quests, TRUE)
+		WHEN '%s' THEN COALESCE(p.share_level_ups, TRUE)
+		WHEN '%s' THEN COALESCE(p.share_achievements, TRUE)
+		WHEN '%s', '%s' THEN COALESCE(p.share_purchases, FALSE)
+		ELSE FALSE
+	END`,
+	models.ActivityTaskCompleted, models.ActivityQuestCompleted, models.ActivityLevelUp,
+	models.ActivityAchievementUnlocked, models.ActivityQuestPurchased, models.ActivityItemPurchased)
+
+// GetFriendsFeed возвращает события принятых друзей, которые они разрешили показывать,
+// вместе со счетчиками реакций и комментариев
+func (r *UserRepository) GetFriendsFeed(userID, limit, offset int) ([]models.ActivityEvent, error) {
+	events := []models.ActivityEvent{}
+	err := r.db.Select(&events, `
+		SELECT a.id, a.user_id, u.username, a.kind, a.payload, a.created_at,
+			`+socialCountsColumns(models.SocialTargetActivity, "a.id", "$1")+`
+		FROM activity_events a
+		JOIN users u ON u.id = a.user_id
+		LEFT JOIN user_privacy_settings p ON p.user_id = a.user_id
+		WHERE a.user_id IN (
+			SELECT CASE WHEN user_id = $1 THEN friend_id ELSE user_id END
+			FROM friends
+			WHERE (user_id = $1 OR friend_id = $1) AND status = 'accepted'
+		)
+		AND `+activityVisibleToFriends+`
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	return events, err
+}
+
+// GetPrivacySettings возвращает настройки приватности (по умолчанию, если пользователь их не менял)
+func (r *UserRepository) GetPrivacySettings(userID int) (models.PrivacySettings, error) {
+	var settings models.PrivacySettings
+	err := r.db.Get(&settings, `SELECT * FROM user_privacy_settings WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DefaultPrivacySettings(userID), nil
+	}
+	return settings, err
+}
+
+// UpdatePrivacySettings меняет переданные настройки приватности, остальные сохраняются
+func (r *UserRepository) UpdatePrivacySettings(userID int, req models.UpdatePrivacySettingsRequest) (models.PrivacySettings, error) {
+	d := models.DefaultPrivacySettings(userID)
+
+	var settings models.PrivacySettings
+	err := r.db.Get(&settings, `
+		INSERT INTO user_privacy_settings
+			(user_id, share_tasks, share_quests, share_level_ups, share_achievements, share_purchases,
+			profile_visibility, stats_visibility, attributes_visibility, badges_visibility, quests_visibility)
+		VALUES ($1,
+			COALESCE($2, $12), COALESCE($3, $13), COALESCE($4, $14), COALESCE($5, $15), COALESCE($6, $16),
+			COALESCE($7, $17), COALESCE($8, $18), COALESCE($9, $19), COALESCE($10, $20), COALESCE($11, $21))
+		ON CONFLICT (user_id) DO UPDATE SET
+			share_tasks = COALESCE($2, user_privacy_settings.share_tasks),
+			share_quests = COALESCE($3, user_privacy_settings.share_quests),
+			share_level_ups = COALESCE($4, user_privacy_settings.share_level_ups),
+			share_achievements = COALESCE($5, user_privacy_settings.share_achievements),
+			share_purchases = COALESCE($6, user_privacy_settings.share_purchases),
+			profile_visibility = COALESCE($7, user_privacy_settings.profile_visibility),
+			stats_visibility = COALESCE($8, user_privacy_settings.stats_visibility),
+			attributes_visibility = COALESCE($9, user_privacy_settings.attributes_visibility),
+			badges_visibility = COALESCE($10, user_privacy_settings.badges_visibility),
+			quests_visibility = COALESCE($11, user_privacy_settings.quests_visibility),
+			updated_at = NOW()
+		RETURNING *
+	`, userID,
+		req.ShareTasks, req.ShareQuests, req.ShareLevelUps, req.ShareAchievements, req.SharePurchases,
+		req.ProfileVisibility, req.StatsVisibility, req.AttributesVisibility, req.BadgesVisibility, req.QuestsVisibility,
+		d.ShareTasks, d.ShareQuests, d.ShareLevelUps, d.ShareAchievements, d.SharePurchases,
+		d.ProfileVisibility, d.StatsVisibility, d.AttributesVisibility, d.BadgesVisibility, d.QuestsVisibility)
+	return settings, err
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTaskTooEarly              = errors.New("task cannot be completed yet")
+	ErrTooManyCompletions        = errors.New("too many task completions in the last hour")
+	ErrCompletionAlreadyReviewed = errors.New("task completion already reviewed")
+)
+
+// taskCompletionInfo - данные о выполнении задачи, по которым работают правила anti-cheat
+type taskCompletionInfo struct {
+	Now             time.Time  `db:"now"`
+	StartedAt       *time.Time `db:"started_at"`
+	ScheduledStart  *time.Time `db:"scheduled_start"`
+	Duration        *int       `db:"duration"`
+	LastHour        int        `db:"last_hour"`
+	LastCompletedAt *time.Time `db:"last_completed_at"`
+	LastRejectedAt  *time.Time `db:"last_rejected_at"` // последнее отклонение выполнения этой задачи
+}
+
+// checkTaskCompletion применяет правила anti-cheat к выполнению задачи.
+// Жесткие правила возвращают ошибку, подозрительные признаки - список флагов для проверки администратором.
+func checkTaskCompletion(ctx context.Context, tx *sqlx.Tx, userID, questID, taskID int) ([]string, error) {
+	// Блокируем пользователя, чтобы параллельные выполнения не обошли лимит в час
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	var info taskCompletionInfo
+	err := tx.GetContext(ctx, &info, `
+		SELECT NOW() AS now, uq.started_at, ut.scheduled_start, ut.duration,
+			(SELECT COUNT(*) FROM user_tasks
+			 WHERE user_id = $1 AND status = 'completed' AND completed_at > NOW() - INTERVAL '1 hour') AS last_hour,
+			(SELECT MAX(completed_at) FROM user_tasks
+			 WHERE user_id = $1 AND status = 'completed') AS last_completed_at,
+			(SELECT MAX(reviewed_at) FROM task_completion_reviews
+			 WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status = 'rejected') AS last_rejected_at
+		FROM user_quests uq
+		JOIN user_tasks ut ON ut.user_id = uq.user_id AND ut.quest_id = uq.quest_id
+		WHERE uq.user_id = $1 AND uq.quest_id = $2 AND ut.task_id = $3
+	`, userID, questID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluateTaskCompletion(config.Economy().AntiCheat, info)
+}
+
+// evaluateTaskCompletion - правила anti-cheat без обращения к БД.
+// Отклоненная администратором задача всегда возвращается на проверку, а минимальное время
+// отсчитывается от момента отклонения.
+func evaluateTaskCompletion(rules config.AntiCheat, info taskCompletionInfo) ([]string, error) {
+	var flags []string
+	if info.LastRejectedAt != nil {
+		flags = append(flags, models.CompletionFlagPreviouslyRejected)
+	}
+	if !rules.Enabled {
+		return flags, nil
+	}
+
+	// Время отсчитывается от начала квеста, запланированного начала задачи или отклонения (что позже)
+	start := info.Now
+	if info.StartedAt != nil {
+		start = *info.StartedAt
+	}
+	if info.ScheduledStart != nil && info.ScheduledStart.After(start) {
+		start = *info.ScheduledStart
+	}
+	if info.LastRejectedAt != nil && info.LastRejectedAt.After(start) {
+		start = *info.LastRejectedAt
+	}
+	elapsed := info.Now.Sub(start)
+
+	var duration time.Duration
+	if info.Duration != nil && *info.Duration > 0 {
+		duration = time.Duration(*info.Duration) * time.Minute
+	}
+
+	minElapsed := max(time.Duration(rules.MinSecondsSinceStart)*time.Second, time.Duration(float64(duration)*rules.MinDurationRatio))
+	if elapsed < minElapsed {
+		return nil, fmt.Errorf("%w: try again in %s", ErrTaskTooEarly, (minElapsed - elapsed).Round(time.Second))
+	}
+
+	if rules.MaxCompletionsPerHour > 0 && info.LastHour >= rules.MaxCompletionsPerHour {
+		return nil, fmt.Errorf("%w (max %d)", ErrTooManyCompletions, rules.MaxCompletionsPerHour)
+	}
+
+	if rules.FlagCompletionsPerHour > 0 && info.LastHour+1 > rules.FlagCompletionsPerHour {
+		flags = append(flags, models.CompletionFlagPerHour)
+	}
+	if rules.FlagMinGapSeconds > 0 && info.LastCompletedAt != nil &&
+		info.Now.Sub(*info.LastCompletedAt) < time.Duration(rules.FlagMinGapSeconds)*time.Second {
+		flags = append(flags, models.CompletionFlagMinGap)
+	}
+	if duration > 0 && elapsed < time.Duration(float64(duration)*rules.FlagDurationRatio) {
+		flags = append(flags, models.CompletionFlagFasterThanDuration)
+	}
+
+	return flags, nil
+}
+
+// holdTaskReward создает заявку на проверку выполнения с удержанной наградой
+func holdTaskReward(ctx context.Context, tx *sqlx.Tx, userID, questID, taskID int, flags []string, xp, coins int) (int, error) {
+	var reviewID int
+	err := tx.GetContext(ctx, &reviewID, `
+		INSERT INTO task_completion_reviews (user_id, quest_id, task_id, flags, xp_amount, coin_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, userID, questID, taskID, pq.Array(flags), xp, coins)
+	return reviewID, err
+}
+
+const queryTaskCompletionReviews = `
+	SELECT r.*, u.username, t.title AS task_title
+	FROM task_completion_reviews r
+	JOIN users u ON u.id = r.user_id
+	JOIN tasks t ON t.id = r.task_id
+`
+
+// GetTaskCompletionReviews возвращает заявки на проверку (status = "" - все)
+func (r *QuestRepository) GetTaskCompletionReviews(ctx context.Context, status string, limit, offset int) ([]models.TaskCompletionReview, error) {
+	reviews := []models.TaskCompletionReview{}
+	err := r.db.SelectContext(ctx, &reviews, queryTaskCompletionReviews+`
+		WHERE $1 = '' OR r.status = $1
+		ORDER BY r.created_at, r.id
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	return reviews, err
+}
+
+// ResolveTaskCompletionReview применяет решение администратора:
+// при одобрении начисляет удержанную награду, при отклонении возвращает задачу в active без награды
+// (следующее выполнение этой задачи снова уйдет на проверку).
+func (r *QuestRepository) ResolveTaskCompletionReview(ctx context.Context, reviewID, adminID int, approve bool, comment string) (*models.TaskCompletionReview, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var review models.TaskCompletionReview
+	err = tx.GetContext(ctx, &review, queryTaskCompletionReviews+`
+		WHERE r.id = $1
+		FOR UPDATE OF r
+	`, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != models.CompletionReviewPending {
+		return nil, ErrCompletionAlreadyReviewed
+	}
+
+	kind := models.NotificationTaskReviewApproved
+	if approve {
+		review.Status = models.CompletionReviewApproved
+
+		// Удержанная награда в таблицы лидеров событий не засчитывается
+		err = r.addXPAndCoinsWithLevelUp(tx, ctx, review.UserID, review.XPAmount, coinEntry(review.UserID, review.CoinAmount,
+			models.TransactionTypeEarned, models.ReferenceTypeTask, review.TaskID, "Completed task (approved): "+review.TaskTitle))
+		if err != nil {
+			return nil, err
+		}
+		if err := recordXPHistory(ctx, tx, review.UserID, review.QuestID, review.XPAmount, 0); err != nil {
+			return nil, err
+		}
+
+		err = recordActivity(ctx, tx, review.UserID, models.ActivityTaskCompleted, map[string]any{
+			"quest_id": review.QuestID,
+			"task_id":  review.TaskID,
+			"title":    review.TaskTitle,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_tasks SET reward_held = FALSE
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3
+		`, review.UserID, review.QuestID, review.TaskID)
+	} else {
+		review.Status = models.CompletionReviewRejected
+		kind = models.NotificationTaskReviewRejected
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_tasks
+			SET status = 'active', completed_at = NULL, reward_held = FALSE, xp_gained = 0, coin_gained = 0
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3
+		`, review.UserID, review.QuestID, review.TaskID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var commentPtr *string
+	if comment != "" {
+		commentPtr = &comment
+	}
+	err = tx.GetContext(ctx, &review.ReviewedAt, `
+		UPDATE task_completion_reviews
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW(), comment = $3
+		WHERE id = $4
+		RETURNING reviewed_at
+	`, review.Status, adminID, commentPtr, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	review.ReviewedBy = &adminID
+	review.Comment = commentPtr
+
+	err = notify(ctx, tx, review.UserID, kind, map[string]any{
+		"review_id":   review.ID,
+		"quest_id":    review.QuestID,
+		"task_id":     review.TaskID,
+		"task_title":  review.TaskTitle,
+		"xp_amount":   review.XPAmount,
+		"coin_amount": review.CoinAmount,
+		"comment":     comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, tx.Commit()
+}
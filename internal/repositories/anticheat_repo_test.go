@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+)
+
+func TestEvaluateTaskCompletion(t *testing.T) {
+	rules := config.DefaultEconomy().AntiCheat // 60s с начала, 50%/80% duration, 20/10 в час, 30s между выполнениями
+	disabled := rules
+	disabled.Enabled = false
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		v := now.Add(-d)
+		return &v
+	}
+	minutes := func(m int) *int { return &m }
+
+	tests := []struct {
+		name      string
+		rules     config.AntiCheat
+		info      taskCompletionInfo
+		wantErr   error
+		wantFlags []string
+	}{
+		{
+			name:  "clean completion",
+			rules: rules,
+			info:  taskCompletionInfo{Now: now, StartedAt: ago(time.Hour), LastHour: 2, LastCompletedAt: ago(10 * time.Minute)},
+		},
+		{
+			name:    "too soon after quest start",
+			rules:   rules,
+			info:    taskCompletionInfo{Now: now, StartedAt: ago(59 * time.Second)},
+			wantErr: ErrTaskTooEarly,
+		},
+		{
+			name:    "too soon after scheduled start",
+			rules:   rules,
+			info:    taskCompletionInfo{Now: now, StartedAt: ago(time.Hour), ScheduledStart: ago(30 * time.Second)},
+			wantErr: ErrTaskTooEarly,
+		},
+		{
+			name:    "faster than min duration ratio",
+			rules:   rules,
+			info:    taskCompletionInfo{Now: now, StartedAt: ago(29 * time.Minute), Duration: minutes(60)},
+			wantErr: ErrTaskTooEarly,
+		},
+		{
+			name:      "faster than flag duration ratio",
+			rules:     rules,
+			info:      taskCompletionInfo{Now: now, StartedAt: ago(40 * time.Minute), Duration: minutes(60)},
+			wantFlags: []string{models.CompletionFlagFasterThanDuration},
+		},
+		{
+			name:    "hourly limit reached",
+			rules:   rules,
+			info:    taskCompletionInfo{Now: now, StartedAt: ago(time.Hour), LastHour: 20},
+			wantErr: ErrTooManyCompletions,
+		},
+		{
+			name:      "hourly flag threshold exceeded",
+			rules:     rules,
+			info:      taskCompletionInfo{Now: now, StartedAt: ago(time.Hour), LastHour: 10},
+			wantFlags: []string{models.CompletionFlagPerHour},
+		},
+		{
+			name:      "completions in a row too fast",
+			rules:     rules,
+			info:      taskCompletionInfo{Now: now, StartedAt: ago(time.Hour), LastCompletedAt: ago(29 * time.Second)},
+			wantFlags: []string{models.CompletionFlagMinGap},
+		},
+		{
+			name:    "rejected task measures delay from rejection",
+			rules:   rules,
+			info:    taskCompletionInfo{Now: now, StartedAt: ago(24 * time.Hour), LastRejectedAt: ago(10 * time.Second)},
+			wantErr: ErrTaskTooEarly,
+		},
+		{
+			name:      "rejected task goes back to review",
+			rules:     rules,
+			info:      taskCompletionInfo{Now: now, StartedAt: ago(24 * time.Hour), LastRejectedAt: ago(time.Hour)},
+			wantFlags: []string{models.CompletionFlagPreviouslyRejected},
+		},
+		{
+			name:      "rejected task goes back to review with anti-cheat disabled",
+			rules:     disabled,
+			info:      taskCompletionInfo{Now: now, StartedAt: ago(time.Second), LastRejectedAt: ago(time.Second)},
+			wantFlags: []string{models.CompletionFlagPreviouslyRejected},
+		},
+		{
+			name:  "disabled anti-cheat skips all rules",
+			rules: disabled,
+			info:  taskCompletionInfo{Now: now, StartedAt: ago(time.Second), LastHour: 100, LastCompletedAt: ago(time.Second)},
+		},
+		{
+			name:  "several flags at once",
+			rules: rules,
+			info: taskCompletionInfo{
+				Now: now, StartedAt: ago(45 * time.Minute), Duration: minutes(60),
+				LastHour: 15, LastCompletedAt: ago(5 * time.Second), LastRejectedAt: ago(2 * time.Hour),
+			},
+			wantFlags: []string{
+				models.CompletionFlagPreviouslyRejected,
+				models.CompletionFlagPerHour,
+				models.CompletionFlagMinGap,
+				models.CompletionFlagFasterThanDuration,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, err := evaluateTaskCompletion(tt.rules, tt.info)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("evaluateTaskCompletion() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(flags, tt.wantFlags) {
+				t.Errorf("evaluateTaskCompletion() flags = %v, want %v", flags, tt.wantFlags)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserBlocked = errors.New("user is blocked")
+	ErrBlockSelf   = errors.New("cannot block yourself")
+	ErrNotBlocked  = errors.New("user is not blocked")
+)
+
+// isBlocked проверяет блокировку между пользователями в любую сторону
+func isBlocked(ctx context.Context, q sqlx.QueryerContext, userID, otherID int) (bool, error) {
+	var blocked bool
+	err := sqlx.GetContext(ctx, q, &blocked, `
+		SELECT EXISTS(
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`, userID, otherID)
+	return blocked, err
+}
+
+// RemoveFriend удаляет дружбу (в любом направлении)
+func (r *UserRepository) RemoveFriend(userID, friendID int) error {
+	res, err := r.db.Exec(`
+		DELETE FROM friends
+		WHERE ((user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))
+		AND status = $3`,
+		userID, friendID, models.FriendStatusAccepted)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+// BlockUser блокирует пользователя: удаляет дружбу и заявки между ними в обе стороны
+func (r *UserRepository) BlockUser(userID, blockedID int) error {
+	if userID == blockedID {
+		return ErrBlockSelf
+	}
+
+	exists, err := r.isUserExists(blockedID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("Такого пользователя не существует")
+	}
+
+	ctx := context.Background()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		userID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM friends
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)`,
+		userID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *UserRepository) UnblockUser(userID, blockedID int) error {
+	res, err := r.db.Exec(`
+		DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`,
+		userID, blockedID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// GetBlockedUsers возвращает пользователей, заблокированных userID
+func (r *UserRepository) GetBlockedUsers(userID int) ([]models.BlockedUser, error) {
+	blocked := []models.BlockedUser{}
+	err := r.db.Select(&blocked, `
+		SELECT b.blocked_id AS user_id, u.username, b.created_at
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC`,
+		userID)
+	return blocked, err
+}
+
+// GetRecommendableProfiles возвращает публичные профили кандидатов в друзья в исходном порядке,
+// исключая самого пользователя, его друзей, пользователей с блокировкой в любую сторону
+// и тех, кто скрыл профиль от не-друзей
+func (r *UserRepository) GetRecommendableProfiles(userID int, candidateIDs []int) ([]models.PublicProfile, error) {
+	if len(candidateIDs) == 0 {
+		return []models.PublicProfile{}, nil
+	}
+
+	var ids []int
+	err := r.db.Select(&ids, `
+		SELECT u.id
+		FROM users u
+		LEFT JOIN user_privacy_settings p ON p.user_id = u.id
+		WHERE u.id = ANY($2) AND u.id <> $1
+		AND COALESCE(p.profile_visibility, $4) = $4
+		AND NOT EXISTS (
+			SELECT 1 FROM friends f
+			WHERE ((f.user_id = $1 AND f.friend_id = u.id) OR (f.user_id = u.id AND f.friend_id = $1))
+			AND f.status = $3
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+		)
+		ORDER BY array_position($2, u.id)`,
+		userID, pq.Array(candidateIDs), models.FriendStatusAccepted, models.VisibilityPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetPublicProfiles(userID, ids)
+}
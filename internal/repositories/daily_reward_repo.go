@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrDailyRewardAlreadyClaimed = errors.New("daily reward already claimed today")
+)
+
+type dailyRewardState struct {
+	CurrentDay        int        `db:"current_day"`
+	LastClaimDate     *time.Time `db:"last_claim_date"`
+	LastClaimedAt     *time.Time `db:"last_claimed_at"`
+	LastClaimTimezone *string    `db:"last_claim_timezone"`
+}
+
+// userLocalDate возвращает текущую дату в часовом поясе пользователя (полночь UTC этой даты)
+func userLocalDate(timezone string, now time.Time) time.Time {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	y, m, d := now.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// planDailyReward определяет, какой день календаря будет получен сегодня.
+// Пропущенные дни покрываются заморозками серии (protections), иначе календарь начинается заново.
+// После смены часового пояса разница в днях считается в поясе, в котором была получена
+// прошлая награда: так смена пояса не дает лишних наград и не обрывает серию.
+func planDailyReward(state dailyRewardState, timezone string, now time.Time, protections int) (day, protectionsUsed int, claimedToday bool) {
+	if state.LastClaimDate == nil || state.CurrentDay == 0 {
+		return 1, 0, false
+	}
+
+	today := userLocalDate(timezone, now)
+	last := state.LastClaimDate.UTC().Truncate(24 * time.Hour)
+	if state.LastClaimedAt != nil && state.LastClaimTimezone != nil && *state.LastClaimTimezone != timezone {
+		today = userLocalDate(*state.LastClaimTimezone, now)
+		last = userLocalDate(*state.LastClaimTimezone, *state.LastClaimedAt)
+	}
+
+	diff := int(today.Sub(last).Hours() / 24)
+
+	switch {
+	case diff <= 0:
+		return state.CurrentDay, 0, true
+	case diff == 1:
+		day = state.CurrentDay + 1
+	default:
+		missed := diff - 1
+		if protections < missed {
+			return 1, 0, false
+		}
+		day, protectionsUsed = state.CurrentDay+1, missed
+	}
+
+	if day > len(models.DailyRewardCalendar) {
+		day = 1
+	}
+	return day, protectionsUsed, false
+}
+
+func (r *QuestRepository) getDailyRewardState(ctx context.Context, q sqlx.QueryerContext, userID int) (dailyRewardState, error) {
+	var state dailyRewardState
+	err := sqlx.GetContext(ctx, q, &state, `
+		SELECT current_day, last_claim_date, last_claimed_at, last_claim_timezone
+		FROM user_daily_rewards WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dailyRewardState{}, nil
+	}
+	return state, err
+}
+
+// GetDailyRewardStatus возвращает календарь и состояние серии пользователя
+func (r *QuestRepository) GetDailyRewardStatus(ctx context.Context, userID int) (*models.DailyRewardStatus, error) {
+	var timezone string
+	if err := r.db.GetContext(ctx, &timezone, `SELECT timezone FROM users WHERE id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	state, err := r.getDailyRewardState(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	protections, err := getInventoryQuantity(ctx, r.db, userID, models.ItemStreakFreeze)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := userLocalDate(timezone, now)
+	day, _, claimedToday := planDailyReward(state, timezone, now, protections)
+
+	status := &models.DailyRewardStatus{
+		Calendar:     models.DailyRewardCalendar,
+		CurrentDay:   state.CurrentDay,
+		ClaimedToday: claimedToday,
+		Protections:  protections,
+		Timezone:     timezone,
+		LocalDate:    today.Format(time.DateOnly),
+	}
+	if claimedToday {
+		day = day%len(models.DailyRewardCalendar) + 1
+	} else if day == 1 && state.CurrentDay > 0 && state.CurrentDay < len(models.DailyRewardCalendar) {
+		status.StreakAtRisk = true
+	}
+	status.NextReward = models.DailyRewardCalendar[day-1]
+
+	return status, nil
+}
+
+// ClaimDailyReward выдает награду текущего дня календаря (один раз за локальный день пользователя)
+func (r *QuestRepository) ClaimDailyReward(ctx context.Context, userID int) (*models.DailyRewardClaim, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем пользователя, чтобы параллельные запросы не получили награду дважды
+	var timezone string
+	if err := tx.GetContext(ctx, &timezone, `SELECT timezone FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	state, err := r.getDailyRewardState(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	protections, err := getInventoryQuantity(ctx, tx, userID, models.ItemStreakFreeze)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := userLocalDate(timezone, now)
+	day, protectionsUsed, claimedToday := planDailyReward(state, timezone, now, protections)
+	if claimedToday {
+		return nil, ErrDailyRewardAlreadyClaimed
+	}
+
+	if protectionsUsed > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_inventory SET quantity = quantity - $1 WHERE user_id = $2 AND item_code = $3
+		`, protectionsUsed, userID, models.ItemStreakFreeze)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reward := models.DailyRewardCalendar[day-1]
+
+	var itemCode *string
+	if reward.ItemCode != "" {
+		itemCode = &reward.ItemCode
+	}
+
+	var claim models.DailyRewardClaim
+	err = tx.GetContext(ctx, &claim, `
+		INSERT INTO daily_reward_claims
+		(user_id, claim_date, calendar_day, reward_kind, amount, item_code, protections_used)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *
+	`, userID, today.Format(time.DateOnly), day, reward.Kind, reward.Amount, itemCode, protectionsUsed)
+	if err != nil {
+		return nil, err
+	}
+
+	switch reward.Kind {
+	case models.DailyRewardCoins:
+		err = r.ledger.Apply(ctx, tx, coinEntry(userID, reward.Amount,
+			models.TransactionTypeBonus, models.ReferenceTypeDailyReward, claim.ID, fmt.Sprintf("Daily reward: day %d", day)))
+	case models.DailyRewardXP:
+		if err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, reward.Amount, models.CoinTransaction{}); err == nil {
+			err = recordXPHistory(ctx, tx, userID, 0, reward.Amount, 0)
+		}
+	case models.DailyRewardItem:
+		err = addInventoryItem(ctx, tx, userID, reward.ItemCode, reward.Amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_daily_rewards (user_id, current_day, last_claim_date, last_claimed_at, last_claim_timezone)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			current_day = EXCLUDED.current_day,
+			last_claim_date = EXCLUDED.last_claim_date,
+			last_claimed_at = EXCLUDED.last_claimed_at,
+			last_claim_timezone = EXCLUDED.last_claim_timezone
+	`, userID, day, today.Format(time.DateOnly), now.UTC(), timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET last_active_at = NOW() WHERE id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	return &claim, tx.Commit()
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"BecomeOverMan/internal/models"
+)
+
+func TestPlanDailyReward(t *testing.T) {
+	date := func(s string) *time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	instant := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	ptr := func(v time.Time) *time.Time { return &v }
+	zone := func(s string) *string { return &s }
+
+	const (
+		west = "Etc/GMT+11"         // UTC-11
+		east = "Pacific/Kiritimati" // UTC+14
+	)
+	calendarLen := len(models.DailyRewardCalendar)
+
+	tests := []struct {
+		name            string
+		state           dailyRewardState
+		timezone        string
+		now             time.Time
+		protections     int
+		wantDay         int
+		wantProtections int
+		wantClaimed     bool
+	}{
+		{
+			name:     "first claim",
+			timezone: "UTC",
+			now:      instant("2026-03-01T10:00:00Z"),
+			wantDay:  1,
+		},
+		{
+			name:        "already claimed today",
+			state:       dailyRewardState{CurrentDay: 3, LastClaimDate: date("2026-03-01")},
+			timezone:    "UTC",
+			now:         instant("2026-03-01T23:59:00Z"),
+			wantDay:     3,
+			wantClaimed: true,
+		},
+		{
+			name:     "next day continues streak",
+			state:    dailyRewardState{CurrentDay: 3, LastClaimDate: date("2026-03-01")},
+			timezone: "UTC",
+			now:      instant("2026-03-02T00:01:00Z"),
+			wantDay:  4,
+		},
+		{
+			name:            "missed days covered by protections",
+			state:           dailyRewardState{CurrentDay: 5, LastClaimDate: date("2026-03-01")},
+			timezone:        "UTC",
+			now:             instant("2026-03-04T08:00:00Z"),
+			protections:     2,
+			wantDay:         6,
+			wantProtections: 2,
+		},
+		{
+			name:        "not enough protections resets calendar",
+			state:       dailyRewardState{CurrentDay: 5, LastClaimDate: date("2026-03-01")},
+			timezone:    "UTC",
+			now:         instant("2026-03-04T08:00:00Z"),
+			protections: 1,
+			wantDay:     1,
+		},
+		{
+			name:     "calendar wraps after last day",
+			state:    dailyRewardState{CurrentDay: calendarLen, LastClaimDate: date("2026-03-01")},
+			timezone: "UTC",
+			now:      instant("2026-03-02T08:00:00Z"),
+			wantDay:  1,
+		},
+		{
+			name: "local day follows user timezone",
+			state: dailyRewardState{
+				CurrentDay: 2, LastClaimDate: date("2026-03-01"),
+				LastClaimedAt: ptr(instant("2026-03-01T12:00:00Z")), LastClaimTimezone: zone(east),
+			},
+			timezone: east,
+			now:      instant("2026-03-01T10:30:00Z"), // 2026-03-02 00:30 local
+			wantDay:  3,
+		},
+		{
+			name: "switching timezone east does not grant an extra claim",
+			state: dailyRewardState{
+				CurrentDay: 2, LastClaimDate: date("2026-02-28"),
+				LastClaimedAt: ptr(instant("2026-03-01T10:00:00Z")), LastClaimTimezone: zone(west),
+			},
+			timezone:    east,
+			now:         instant("2026-03-01T10:30:00Z"), // 2026-03-02 locally, still 2026-02-28 in the old zone
+			wantDay:     2,
+			wantClaimed: true,
+		},
+		{
+			name: "after switching timezone the day diff is counted in the old zone",
+			state: dailyRewardState{
+				CurrentDay: 2, LastClaimDate: date("2026-02-28"),
+				LastClaimedAt: ptr(instant("2026-03-01T10:00:00Z")), LastClaimTimezone: zone(west),
+			},
+			timezone:    east,
+			now:         instant("2026-03-01T11:30:00Z"), // 2026-03-01 in the old zone, 2026-03-02 locally
+			protections: 1,
+			wantDay:     3,
+		},
+		{
+			name: "switching timezone east continues streak without a freeze",
+			state: dailyRewardState{
+				CurrentDay: 2, LastClaimDate: date("2026-03-01"),
+				LastClaimedAt: ptr(instant("2026-03-01T23:00:00Z")), LastClaimTimezone: zone("UTC"),
+			},
+			timezone:    east,
+			now:         instant("2026-03-02T10:00:00Z"), // next day in UTC, 2026-03-03 locally
+			protections: 1,
+			wantDay:     3,
+		},
+		{
+			name: "switching timezone west does not repeat a claimed day",
+			state: dailyRewardState{
+				CurrentDay: 4, LastClaimDate: date("2026-03-02"),
+				LastClaimedAt: ptr(instant("2026-03-01T10:30:00Z")), LastClaimTimezone: zone(east),
+			},
+			timezone:    west,
+			now:         instant("2026-03-02T08:00:00Z"), // still 2026-03-02 in the old zone
+			wantDay:     4,
+			wantClaimed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, used, claimed := planDailyReward(tt.state, tt.timezone, tt.now, tt.protections)
+			if day != tt.wantDay || used != tt.wantProtections || claimed != tt.wantClaimed {
+				t.Errorf("planDailyReward() = (%d, %d, %t), want (%d, %d, %t)",
+					day, used, claimed, tt.wantDay, tt.wantProtections, tt.wantClaimed)
+			}
+		})
+	}
+}
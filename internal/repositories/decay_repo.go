@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// decayBatchSize - сколько неактивных пользователей обрабатывается за один запуск задачи
+const decayBatchSize = 500
+
+// decayUserState - прогресс пользователя, который может затухать
+type decayUserState struct {
+	XpPoints          int `db:"xp_points"`
+	Level             int `db:"level"`
+	HealthLevel       int `db:"health_level"`
+	MentalHealthLevel int `db:"mental_health_level"`
+	IntelligenceLevel int `db:"intelligence_level"`
+	CharismaLevel     int `db:"charisma_level"`
+	WillpowerLevel    int `db:"willpower_level"`
+}
+
+func (s *decayUserState) attributes() map[string]*int {
+	return map[string]*int{
+		"health_level":        &s.HealthLevel,
+		"mental_health_level": &s.MentalHealthLevel,
+		"intelligence_level":  &s.IntelligenceLevel,
+		"charisma_level":      &s.CharismaLevel,
+		"willpower_level":     &s.WillpowerLevel,
+	}
+}
+
+// decayValue снижает значение на loss, но не ниже floor (значения ниже floor не трогаются)
+func decayValue(value, loss, floor int) int {
+	if value <= floor {
+		return value
+	}
+	return max(value-loss, floor)
+}
+
+func lockDecayUserState(ctx context.Context, tx *sqlx.Tx, userID int) (decayUserState, error) {
+	var state decayUserState
+	err := tx.GetContext(ctx, &state, `
+		SELECT COALESCE(xp_points, 0) AS xp_points, COALESCE(level, 1) AS level,
+			COALESCE(health_level, 0) AS health_level,
+			COALESCE(mental_health_level, 0) AS mental_health_level,
+			COALESCE(intelligence_level, 0) AS intelligence_level,
+			COALESCE(charisma_level, 0) AS charisma_level,
+			COALESCE(willpower_level, 0) AS willpower_level
+		FROM users WHERE id = $1
+		FOR UPDATE
+	`, userID)
+	return state, err
+}
+
+// saveDecay сохраняет новый прогресс пользователя, запись истории и уведомление (если что-то изменилось)
+func saveDecay(ctx context.Context, tx *sqlx.Tx, userID int, before, after decayUserState, entry models.DecayHistoryEntry) error {
+	changes := map[string]int{}
+	beforeAttrs := before.attributes()
+	for column, value := range after.attributes() {
+		if diff := *value - *beforeAttrs[column]; diff != 0 {
+			changes[column] = diff
+		}
+	}
+	entry.XPLost = before.XpPoints - after.XpPoints
+	if len(changes) == 0 && entry.XPLost == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE users SET xp_points = $1, level = $2,
+			health_level = $3, mental_health_level = $4, intelligence_level = $5,
+			charisma_level = $6, willpower_level = $7
+		WHERE id = $8
+	`, after.XpPoints, after.Level, after.HealthLevel, after.MentalHealthLevel, after.IntelligenceLevel,
+		after.CharismaLevel, after.WillpowerLevel, userID)
+	if err != nil {
+		return err
+	}
+
+	attributeChanges, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	err = tx.GetContext(ctx, &entry.ID, `
+		INSERT INTO user_decay_history
+		(user_id, reason, quest_id, xp_lost, level_before, level_after, attribute_changes, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8)
+		RETURNING id
+	`, userID, entry.Reason, entry.QuestID, entry.XPLost, before.Level, after.Level, string(attributeChanges), entry.Description)
+	if err != nil {
+		return err
+	}
+
+	return notify(ctx, tx, userID, models.NotificationDecay, map[string]any{
+		"decay_id":          entry.ID,
+		"reason":            entry.Reason,
+		"description":       entry.Description,
+		"xp_lost":           entry.XPLost,
+		"level_before":      before.Level,
+		"level_after":       after.Level,
+		"attribute_changes": changes,
+	})
+}
+
+// FailExpiredQuests переводит просроченные начатые квесты в failed и, если затухание включено,
+// штрафует ветку их категории. Возвращает количество проваленных квестов.
+func (r *QuestRepository) FailExpiredQuests(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var failed []struct {
+		UserID   int    `db:"user_id"`
+		QuestID  int    `db:"quest_id"`
+		Title    string `db:"title"`
+		Category string `db:"category"`
+	}
+	err = tx.SelectContext(ctx, &failed, `
+		UPDATE user_quests uq SET status = 'failed'
+		FROM quests q
+		WHERE q.id = uq.quest_id
+		  AND uq.status = 'started'
+		  AND uq.expires_at IS NOT NULL AND uq.expires_at <= NOW()
+		RETURNING uq.user_id, uq.quest_id, q.title, q.category
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	decay := config.Economy().Decay
+	for _, f := range failed {
+		column, ok := attributeColumns[f.Category]
+		if !ok || !decay.Enabled || decay.FailedQuestAttributeLoss == 0 {
+			continue
+		}
+
+		before, err := lockDecayUserState(ctx, tx, f.UserID)
+		if err != nil {
+			return 0, err
+		}
+		after := before
+		attr := after.attributes()[column]
+		*attr = decayValue(*attr, decay.FailedQuestAttributeLoss, decay.AttributeFloor)
+
+		questID := f.QuestID
+		err = saveDecay(ctx, tx, f.UserID, before, after, models.DecayHistoryEntry{
+			Reason:      models.DecayReasonFailedQuest,
+			QuestID:     &questID,
+			Description: fmt.Sprintf("Quest failed (time limit expired): %s", f.Title),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(failed), tx.Commit()
+}
+
+// DecayInactiveUsers снижает прогресс пользователей, неактивных дольше inactivity_days
+// (не чаще раза в period_days). Возвращает количество обработанных пользователей.
+func (r *QuestRepository) DecayInactiveUsers(ctx context.Context) (int, error) {
+	decay := config.Economy().Decay
+
+	var userIDs []int
+	err := r.db.SelectContext(ctx, &userIDs, `
+		SELECT id FROM users
+		WHERE last_active_at < NOW() - make_interval(days => $1)
+		  AND (last_decay_at IS NULL OR last_decay_at < NOW() - make_interval(days => $2))
+		ORDER BY id
+		LIMIT $3
+	`, decay.InactivityDays, decay.PeriodDays, decayBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		if err := r.decayInactiveUser(ctx, userID, decay); err != nil {
+			return 0, fmt.Errorf("decay user %d: %w", userID, err)
+		}
+	}
+	return len(userIDs), nil
+}
+
+func (r *QuestRepository) decayInactiveUser(ctx context.Context, userID int, decay config.DecayConfig) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Повторно проверяем условия под блокировкой: пользователь мог зайти после выборки
+	var inactiveDays int
+	err = tx.GetContext(ctx, &inactiveDays, `
+		UPDATE users SET last_decay_at = NOW()
+		WHERE id = $1
+		  AND last_active_at < NOW() - make_interval(days => $2)
+		  AND (last_decay_at IS NULL OR last_decay_at < NOW() - make_interval(days => $3))
+		RETURNING EXTRACT(DAY FROM NOW() - last_active_at)::int
+	`, userID, decay.InactivityDays, decay.PeriodDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	before, err := lockDecayUserState(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	after := before
+	for _, attr := range after.attributes() {
+		*attr = decayValue(*attr, decay.AttributeLoss, decay.AttributeFloor)
+	}
+
+	if decay.LevelDecay {
+		economy := config.Economy()
+		floorXP := economy.XPForLevel(decay.LevelFloor)
+		after.XpPoints = decayValue(before.XpPoints, before.XpPoints*decay.XPLossPercent/100, floorXP)
+		after.Level = calculateLevel(after.XpPoints)
+	}
+
+	err = saveDecay(ctx, tx, userID, before, after, models.DecayHistoryEntry{
+		Reason:      models.DecayReasonInactivity,
+		Description: fmt.Sprintf("No activity for %d days", inactiveDays),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDecayHistory возвращает историю затухания прогресса пользователя
+func (r *QuestRepository) GetDecayHistory(ctx context.Context, userID, limit, offset int) ([]models.DecayHistoryEntry, error) {
+	history := []models.DecayHistoryEntry{}
+	err := r.db.SelectContext(ctx, &history, `
+		SELECT * FROM user_decay_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	return history, err
+}
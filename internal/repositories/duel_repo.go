@@ -0,0 +1,491 @@
+package repositories
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrInvalidDuel  = errors.New("invalid duel settings")
+	ErrDuelNotFound = errors.New("duel not found")
+	ErrDuelExpired  = errors.New("duel challenge has expired")
+)
+
+const queryDuelDetails = `
+	SELECT d.*, cu.username AS challenger_username, ou.username AS opponent_username, q.title AS quest_title
+	FROM duels d
+	JOIN users cu ON cu.id = d.challenger_id
+	JOIN users ou ON ou.id = d.opponent_id
+	LEFT JOIN quests q ON q.id = d.quest_id
+`
+
+// CreateDuel вызывает друга на дуэль. Ставка вызывающего сразу удерживается
+// и возвращается, если вызов отклонят, отменят или он истечет.
+func (r *QuestRepository) CreateDuel(ctx context.Context, d *models.Duel) (int, error) {
+	rules := config.Economy().Duels
+	if d.OpponentID == d.ChallengerID {
+		return 0, fmt.Errorf("%w: cannot challenge yourself", ErrInvalidDuel)
+	}
+	if d.Stake < rules.MinStake || d.Stake > rules.MaxStake {
+		return 0, fmt.Errorf("%w: stake must be between %d and %d", ErrInvalidDuel, rules.MinStake, rules.MaxStake)
+	}
+	if d.DurationHours > rules.MaxDurationHours {
+		return 0, fmt.Errorf("%w: duration must be at most %d hours", ErrInvalidDuel, rules.MaxDurationHours)
+	}
+	switch d.Kind {
+	case models.DuelKindQuest:
+		if d.QuestID == nil {
+			return 0, fmt.Errorf("%w: quest_id is required", ErrInvalidDuel)
+		}
+		d.Category = nil
+	case models.DuelKindCategory:
+		if d.Category == nil || attributeColumns[*d.Category] == "" {
+			return 0, fmt.Errorf("%w: unknown category", ErrInvalidDuel)
+		}
+		d.QuestID = nil
+	default:
+		return 0, fmt.Errorf("%w: unknown kind %q", ErrInvalidDuel, d.Kind)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkCanDuel(ctx, tx, d); err != nil {
+		return 0, err
+	}
+
+	var duelID int
+	err = tx.GetContext(ctx, &duelID, `
+		INSERT INTO duels (challenger_id, opponent_id, kind, quest_id, category, stake, duration_hours, respond_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		d.ChallengerID, d.OpponentID, d.Kind, d.QuestID, d.Category, d.Stake, d.DurationHours,
+		time.Now().Add(time.Duration(rules.AcceptHours)*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	d.ID = duelID
+
+	if err := r.applyDuelStake(ctx, tx, d, d.ChallengerID, -d.Stake, models.TransactionTypeSpent, "Duel stake"); err != nil {
+		return 0, err
+	}
+
+	err = notify(ctx, tx, d.OpponentID, models.NotificationDuelChallenge, map[string]any{
+		"duel_id":       duelID,
+		"challenger_id": d.ChallengerID,
+		"kind":          d.Kind,
+		"stake":         d.Stake,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return duelID, tx.Commit()
+}
+
+// checkCanDuel - участники друзья и не заблокированы; квест дуэли никто из них еще не завершал
+func checkCanDuel(ctx context.Context, tx *sqlx.Tx, d *models.Duel) error {
+	blocked, err := isBlocked(ctx, tx, d.ChallengerID, d.OpponentID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+
+	areFriends, err := areAcceptedFriends(ctx, tx, d.ChallengerID, d.OpponentID)
+	if err != nil {
+		return err
+	}
+	if !areFriends {
+		return ErrNotFriends
+	}
+
+	if d.Kind != models.DuelKindQuest {
+		return nil
+	}
+
+	if err := checkQuestAvailable(ctx, tx, *d.QuestID); err != nil {
+		return err
+	}
+
+	var completed bool
+	err = tx.GetContext(ctx, &completed, `
+		SELECT EXISTS(
+			SELECT 1 FROM user_quests
+			WHERE quest_id = $1 AND user_id IN ($2, $3) AND status = 'completed'
+		)`, *d.QuestID, d.ChallengerID, d.OpponentID)
+	if err != nil {
+		return err
+	}
+	if completed {
+		return fmt.Errorf("%w: quest already completed by a participant", ErrInvalidDuel)
+	}
+	return nil
+}
+
+// GetDuels возвращает дуэли пользователя (status - фильтр, пустой - все), новые сначала
+func (r *QuestRepository) GetDuels(ctx context.Context, userID int, status string, limit, offset int) ([]models.DuelDetails, error) {
+	duels := []models.DuelDetails{}
+	err := r.db.SelectContext(ctx, &duels, queryDuelDetails+`
+		WHERE (d.challenger_id = $1 OR d.opponent_id = $1)
+		AND ($2 = '' OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $3 OFFSET $4`,
+		userID, status, limit, offset)
+	return duels, err
+}
+
+// GetDuel возвращает дуэль, если пользователь в ней участвует
+func (r *QuestRepository) GetDuel(ctx context.Context, userID, duelID int) (*models.DuelDetails, error) {
+	var duel models.DuelDetails
+	err := r.db.GetContext(ctx, &duel, queryDuelDetails+`
+		WHERE d.id = $1 AND (d.challenger_id = $2 OR d.opponent_id = $2)`,
+		duelID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDuelNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &duel, nil
+}
+
+// AcceptDuel принимает вызов: соперник вносит ставку, дуэль стартует
+func (r *QuestRepository) AcceptDuel(ctx context.Context, userID, duelID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	d, err := lockDuel(ctx, tx, duelID, models.DuelStatusPending)
+	if err != nil {
+		return err
+	}
+	if d.OpponentID != userID {
+		return ErrDuelNotFound
+	}
+	if time.Now().After(d.RespondBy) {
+		return ErrDuelExpired
+	}
+
+	if err := checkCanDuel(ctx, tx, d); err != nil {
+		return err
+	}
+
+	if err := r.applyDuelStake(ctx, tx, d, d.OpponentID, -d.Stake, models.TransactionTypeSpent, "Duel stake"); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE duels
+		SET status = $2, started_at = NOW(), ends_at = NOW() + make_interval(hours => duration_hours)
+		WHERE id = $1`,
+		d.ID, models.DuelStatusActive)
+	if err != nil {
+		return err
+	}
+
+	err = notify(ctx, tx, d.ChallengerID, models.NotificationDuelAccepted, map[string]any{
+		"duel_id":     d.ID,
+		"opponent_id": d.OpponentID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeclineDuel - соперник отказывается, ставка возвращается вызывающему
+func (r *QuestRepository) DeclineDuel(ctx context.Context, userID, duelID int) error {
+	return r.closePendingDuel(ctx, duelID, func(d *models.Duel) (int, string, error) {
+		if d.OpponentID != userID {
+			return 0, "", ErrDuelNotFound
+		}
+		return d.ChallengerID, models.NotificationDuelDeclined, nil
+	}, models.DuelStatusDeclined)
+}
+
+// CancelDuel - вызывающий отменяет еще не принятый вызов, ставка возвращается
+func (r *QuestRepository) CancelDuel(ctx context.Context, userID, duelID int) error {
+	return r.closePendingDuel(ctx, duelID, func(d *models.Duel) (int, string, error) {
+		if d.ChallengerID != userID {
+			return 0, "", ErrDuelNotFound
+		}
+		return d.OpponentID, models.NotificationDuelCanceled, nil
+	}, models.DuelStatusCancelled)
+}
+
+// closePendingDuel закрывает непринятый вызов с возвратом ставки;
+// check проверяет права и возвращает, кого и каким уведомлением оповестить
+func (r *QuestRepository) closePendingDuel(ctx context.Context, duelID int, check func(d *models.Duel) (int, string, error), status string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	d, err := lockDuel(ctx, tx, duelID, models.DuelStatusPending)
+	if err != nil {
+		return err
+	}
+
+	notifyID, kind, err := check(d)
+	if err != nil {
+		return err
+	}
+
+	if err := r.refundPendingDuel(ctx, tx, d, status); err != nil {
+		return err
+	}
+
+	if err := notify(ctx, tx, notifyID, kind, map[string]any{"duel_id": d.ID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// refundPendingDuel возвращает ставку вызывающего и переводит дуэль в status
+func (r *QuestRepository) refundPendingDuel(ctx context.Context, tx *sqlx.Tx, d *models.Duel, status string) error {
+	if err := r.applyDuelStake(ctx, tx, d, d.ChallengerID, d.Stake, models.TransactionTypeEarned, "Duel stake refund"); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE duels SET status = $2, resolved_at = NOW() WHERE id = $1`, d.ID, status)
+	return err
+}
+
+// ExpireDuels - непринятые в срок вызовы истекают с возвратом ставки
+func (r *QuestRepository) ExpireDuels(ctx context.Context) (int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT id FROM duels WHERE status = $1 AND respond_by <= NOW()`,
+		models.DuelStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	// Каждая дуэль в своей транзакции, чтобы ошибка в одной не блокировала остальные
+	expired := 0
+	for _, id := range ids {
+		ok, err := r.expireDuel(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to expire duel", "error", err, "duel_id", id)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (r *QuestRepository) expireDuel(ctx context.Context, duelID int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	d, err := lockDuel(ctx, tx, duelID, models.DuelStatusPending)
+	if errors.Is(err, ErrDuelNotFound) {
+		return false, nil // уже обработана
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.refundPendingDuel(ctx, tx, d, models.DuelStatusExpired); err != nil {
+		return false, err
+	}
+
+	for _, userID := range []int{d.ChallengerID, d.OpponentID} {
+		if err := notify(ctx, tx, userID, models.NotificationDuelExpired, map[string]any{"duel_id": d.ID}); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// ResolveDuels подводит итоги дуэлей, срок которых истек
+func (r *QuestRepository) ResolveDuels(ctx context.Context) (int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT id FROM duels WHERE status = $1 AND ends_at <= NOW()`,
+		models.DuelStatusActive)
+	if err != nil {
+		return 0, err
+	}
+
+	// Каждая дуэль в своей транзакции, чтобы ошибка в одной не блокировала остальные
+	resolved := 0
+	for _, id := range ids {
+		ok, err := r.resolveExpiredDuel(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to resolve duel", "error", err, "duel_id", id)
+			continue
+		}
+		if ok {
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+func (r *QuestRepository) resolveExpiredDuel(ctx context.Context, duelID int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	d, err := lockDuel(ctx, tx, duelID, models.DuelStatusActive)
+	if errors.Is(err, ErrDuelNotFound) {
+		return false, nil // уже завершена
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.resolveDuel(ctx, tx, d); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// resolveQuestDuels завершает активные дуэли на квест, как только участник его завершил
+func (r *QuestRepository) resolveQuestDuels(ctx context.Context, tx *sqlx.Tx, userID, questID int) error {
+	var duels []models.Duel
+	err := tx.SelectContext(ctx, &duels, `
+		SELECT * FROM duels
+		WHERE status = $1 AND kind = $2 AND quest_id = $3
+		AND (challenger_id = $4 OR opponent_id = $4)
+		AND ends_at > NOW()
+		ORDER BY id
+		FOR UPDATE`,
+		models.DuelStatusActive, models.DuelKindQuest, questID, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range duels {
+		if err := r.resolveDuel(ctx, tx, &duels[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveDuel считает результаты за время дуэли: победитель забирает банк, при ничьей ставки возвращаются
+func (r *QuestRepository) resolveDuel(ctx context.Context, tx *sqlx.Tx, d *models.Duel) error {
+	challengerScore, err := duelScore(ctx, tx, d, d.ChallengerID)
+	if err != nil {
+		return err
+	}
+	opponentScore, err := duelScore(ctx, tx, d, d.OpponentID)
+	if err != nil {
+		return err
+	}
+
+	var winnerID *int
+	switch {
+	case challengerScore > opponentScore:
+		winnerID = &d.ChallengerID
+	case opponentScore > challengerScore:
+		winnerID = &d.OpponentID
+	}
+
+	if winnerID != nil {
+		err = r.applyDuelStake(ctx, tx, d, *winnerID, 2*d.Stake, models.TransactionTypeEarned, "Duel won")
+	} else {
+		for _, userID := range []int{d.ChallengerID, d.OpponentID} {
+			err = r.applyDuelStake(ctx, tx, d, userID, d.Stake, models.TransactionTypeEarned, "Duel draw refund")
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE duels
+		SET status = $2, winner_id = $3, challenger_score = $4, opponent_score = $5, resolved_at = NOW()
+		WHERE id = $1`,
+		d.ID, models.DuelStatusCompleted, winnerID, challengerScore, opponentScore)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range []int{d.ChallengerID, d.OpponentID} {
+		err = notify(ctx, tx, userID, models.NotificationDuelResolved, map[string]any{
+			"duel_id":          d.ID,
+			"winner_id":        winnerID,
+			"challenger_score": challengerScore,
+			"opponent_score":   opponentScore,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duelScore - результат участника с начала дуэли до ее срока:
+// для quest - 1, если квест завершен, для category - число выполненных задач категории
+func duelScore(ctx context.Context, tx *sqlx.Tx, d *models.Duel, userID int) (int, error) {
+	var score int
+	var err error
+	switch d.Kind {
+	case models.DuelKindQuest:
+		err = tx.GetContext(ctx, &score, `
+			SELECT COUNT(*) FROM user_quests
+			WHERE user_id = $1 AND quest_id = $2 AND status = 'completed'
+			AND completed_at BETWEEN $3 AND $4`,
+			userID, d.QuestID, d.StartedAt, d.EndsAt)
+	case models.DuelKindCategory:
+		err = tx.GetContext(ctx, &score, `
+			SELECT COUNT(*)
+			FROM user_tasks ut
+			JOIN quests q ON q.id = ut.quest_id
+			WHERE ut.user_id = $1 AND q.category = $2
+			AND ut.status = 'completed' AND NOT ut.reward_held
+			AND ut.completed_at BETWEEN $3 AND $4`,
+			userID, d.Category, d.StartedAt, d.EndsAt)
+	}
+	return score, err
+}
+
+func lockDuel(ctx context.Context, tx *sqlx.Tx, duelID int, status string) (*models.Duel, error) {
+	var d models.Duel
+	err := tx.GetContext(ctx, &d, `SELECT * FROM duels WHERE id = $1 AND status = $2 FOR UPDATE`, duelID, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDuelNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// applyDuelStake - движение монет дуэли через журнал транзакций (списание ставки, возврат, выигрыш)
+func (r *QuestRepository) applyDuelStake(ctx context.Context, tx *sqlx.Tx, d *models.Duel, userID, amount int, transactionType, description string) error {
+	return r.ledger.Apply(ctx, tx, coinEntry(userID, amount,
+		transactionType, models.ReferenceTypeDuel, d.ID, fmt.Sprintf("%s #%d", description, d.ID)))
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"BecomeOverMan/internal/config"
+
+	"github.com/lib/pq"
+)
+
+// RecalculateLevels пересчитывает уровни всех пользователей по текущей кривой прогрессии
+// (нужно после изменения конфигурации экономики). Возвращает число пользователей, чей уровень изменился.
+func (r *QuestRepository) RecalculateLevels(ctx context.Context) (int, error) {
+	economy := config.Economy()
+
+	// Пороги опыта уровней 1..max_level: уровень = количество порогов, не превышающих опыт
+	thresholds := make([]int64, economy.MaxLevel)
+	for i := range thresholds {
+		thresholds[i] = int64(economy.XPForLevel(i + 1))
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users u SET level = lv.level
+		FROM (
+			SELECT id, (
+				SELECT COUNT(*) FROM unnest($1::bigint[]) t WHERE t <= COALESCE(xp_points, 0)
+			) AS level
+			FROM users
+		) lv
+		WHERE lv.id = u.id AND u.level IS DISTINCT FROM lv.level
+	`, pq.Array(thresholds))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,335 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrQuestNotAvailable  = errors.New("quest is only available during its event")
+	ErrQuestInOtherEvent  = errors.New("quest already belongs to another event")
+	ErrEventQuestNotFound = errors.New("event quest not found")
+)
+
+// eventQuestHiddenCondition - квест относится к событию, которое сейчас не активно (алиас квеста - q)
+const eventQuestHiddenCondition = `
+	EXISTS (
+		SELECT 1 FROM event_quests eq
+		JOIN events e ON e.id = eq.event_id
+		WHERE eq.quest_id = q.id AND e.status <> 'active'
+	)
+`
+
+// checkQuestAvailable проверяет, что квест события можно получить прямо сейчас
+func checkQuestAvailable(ctx context.Context, q sqlx.QueryerContext, questID int) error {
+	var hidden bool
+	err := sqlx.GetContext(ctx, q, &hidden, `
+		SELECT `+eventQuestHiddenCondition+` FROM quests q WHERE q.id = $1
+	`, questID)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return ErrQuestNotAvailable
+	}
+	return nil
+}
+
+// rewardWithBonuses применяет к награде бустер опыта пользователя и множители активных событий.
+// Итоговая награда засчитывается пользователю в таблицы лидеров активных событий.
+func (r *QuestRepository) rewardWithBonuses(ctx context.Context, tx *sqlx.Tx, userID, xp, coins int, questCompleted bool) (int, int, error) {
+	xp, coins, eventIDs, err := calcRewardBonuses(ctx, tx, userID, xp, coins)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := recordEventScores(ctx, tx, eventIDs, userID, xp, coins, questCompleted); err != nil {
+		return 0, 0, err
+	}
+	return xp, coins, nil
+}
+
+// calcRewardBonuses считает награду с бустером и множителями активных событий, ничего не записывая.
+// Возвращает также ID активных событий.
+func calcRewardBonuses(ctx context.Context, tx *sqlx.Tx, userID, xp, coins int) (int, int, []int, error) {
+	xp, err := boostedXP(ctx, tx, userID, xp)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	var events []struct {
+		ID             int `db:"id"`
+		XPMultiplier   int `db:"xp_multiplier"`
+		CoinMultiplier int `db:"coin_multiplier"`
+	}
+	err = tx.SelectContext(ctx, &events, `
+		SELECT id, xp_multiplier, coin_multiplier FROM events WHERE status = 'active'
+	`)
+	if err != nil || len(events) == 0 {
+		return xp, coins, nil, err
+	}
+
+	// Множители событий не складываются - действует наибольший
+	xpPercent, coinPercent := 100, 100
+	eventIDs := make([]int, 0, len(events))
+	for _, e := range events {
+		xpPercent = max(xpPercent, e.XPMultiplier)
+		coinPercent = max(coinPercent, e.CoinMultiplier)
+		eventIDs = append(eventIDs, e.ID)
+	}
+
+	return xp * xpPercent / 100, coins * coinPercent / 100, eventIDs, nil
+}
+
+// recordEventScores засчитывает награду в таблицы лидеров событий
+func recordEventScores(ctx context.Context, tx *sqlx.Tx, eventIDs []int, userID, xp, coins int, questCompleted bool) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	questsCompleted := 0
+	if questCompleted {
+		questsCompleted = 1
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO event_scores (event_id, user_id, xp_earned, coins_earned, quests_completed)
+		SELECT unnest($1::int[]), $2, $3, $4, $5
+		ON CONFLICT (event_id, user_id) DO UPDATE SET
+			xp_earned = event_scores.xp_earned + EXCLUDED.xp_earned,
+			coins_earned = event_scores.coins_earned + EXCLUDED.coins_earned,
+			quests_completed = event_scores.quests_completed + EXCLUDED.quests_completed
+	`, pq.Array(eventIDs), userID, xp, coins, questsCompleted)
+	return err
+}
+
+// RefreshEventStatuses активирует наступившие и завершает прошедшие события.
+// Участникам завершенных событий приходит уведомление с их местом в таблице лидеров.
+func (r *QuestRepository) RefreshEventStatuses(ctx context.Context) (activated, ended int, err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	activated, ended, err = refreshEventStatuses(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return activated, ended, tx.Commit()
+}
+
+func refreshEventStatuses(ctx context.Context, tx *sqlx.Tx) (int, int, error) {
+	var endedIDs []int
+	err := tx.SelectContext(ctx, &endedIDs, `
+		UPDATE events SET status = 'ended'
+		WHERE status <> 'ended' AND ends_at <= NOW()
+		RETURNING id
+	`)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE events SET status = 'active'
+		WHERE status = 'scheduled' AND starts_at <= NOW() AND ends_at > NOW()
+	`)
+	if err != nil {
+		return 0, 0, err
+	}
+	activated, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(endedIDs) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO notifications (user_id, kind, payload)
+			SELECT s.user_id, $2, jsonb_build_object(
+				'event_id', e.id,
+				'title', e.title,
+				'rank', RANK() OVER (PARTITION BY s.event_id ORDER BY s.xp_earned DESC),
+				'xp_earned', s.xp_earned
+			)
+			FROM event_scores s
+			JOIN events e ON e.id = s.event_id
+			WHERE s.event_id = ANY($1)
+		`, pq.Array(endedIDs), models.NotificationEventEnded)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return int(activated), len(endedIDs), nil
+}
+
+// GetEvents возвращает события; если includeEnded = false - только активные и запланированные
+func (r *QuestRepository) GetEvents(ctx context.Context, includeEnded bool) ([]models.Event, error) {
+	events := []models.Event{}
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT * FROM events
+		WHERE $1 OR status <> 'ended'
+		ORDER BY starts_at DESC, id DESC
+	`, includeEnded)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range events {
+		if events[i].QuestIDs, err = r.getEventQuestIDs(ctx, r.db, events[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// GetEvent возвращает событие вместе с его квестами
+func (r *QuestRepository) GetEvent(ctx context.Context, eventID int) (*models.EventDetails, error) {
+	var details models.EventDetails
+	if err := r.db.GetContext(ctx, &details.Event, `SELECT * FROM events WHERE id = $1`, eventID); err != nil {
+		return nil, err
+	}
+
+	details.Quests = []models.Quest{}
+	err := r.db.SelectContext(ctx, &details.Quests, `
+		SELECT q.*, `+questRatingStatsColumns+`
+		FROM quests q
+		JOIN event_quests eq ON eq.quest_id = q.id
+		`+questRatingStatsJoin+`
+		WHERE eq.event_id = $1
+		ORDER BY q.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	details.QuestIDs = make([]int, 0, len(details.Quests))
+	for _, q := range details.Quests {
+		details.QuestIDs = append(details.QuestIDs, q.ID)
+	}
+	return &details, nil
+}
+
+func (r *QuestRepository) getEventQuestIDs(ctx context.Context, q sqlx.QueryerContext, eventID int) ([]int, error) {
+	ids := []int{}
+	err := sqlx.SelectContext(ctx, q, &ids, `
+		SELECT quest_id FROM event_quests WHERE event_id = $1 ORDER BY quest_id
+	`, eventID)
+	return ids, err
+}
+
+// SaveEvent создает (eventID = 0) или изменяет событие вместе со списком его квестов.
+// Статус пересчитывается сразу, не дожидаясь планировщика; уведомление о завершении
+// приходит только при переходе scheduled/active -> ended.
+func (r *QuestRepository) SaveEvent(ctx context.Context, eventID int, req models.EventRequest) (*models.Event, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if eventID == 0 {
+		err = tx.GetContext(ctx, &eventID, `
+			INSERT INTO events (title, description, starts_at, ends_at, xp_multiplier, coin_multiplier)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, req.Title, req.Description, req.StartsAt, req.EndsAt, req.XPMultiplier, req.CoinMultiplier)
+	} else {
+		err = tx.GetContext(ctx, &eventID, `
+			UPDATE events SET title = $2, description = $3, starts_at = $4, ends_at = $5,
+				xp_multiplier = $6, coin_multiplier = $7,
+				-- завершенное событие остается завершенным, пока его не перенесли в будущее
+				status = CASE WHEN status = 'ended' AND $5 <= NOW() THEN 'ended' ELSE 'scheduled' END
+			WHERE id = $1
+			RETURNING id
+		`, eventID, req.Title, req.Description, req.StartsAt, req.EndsAt, req.XPMultiplier, req.CoinMultiplier)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_quests WHERE event_id = $1`, eventID); err != nil {
+		return nil, err
+	}
+	for _, questID := range req.QuestIDs {
+		var otherEvent bool
+		err = tx.GetContext(ctx, &otherEvent, `
+			SELECT EXISTS(SELECT 1 FROM event_quests WHERE quest_id = $1 AND event_id <> $2)
+		`, questID, eventID)
+		if err != nil {
+			return nil, err
+		}
+		if otherEvent {
+			return nil, fmt.Errorf("quest %d: %w", questID, ErrQuestInOtherEvent)
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO event_quests (event_id, quest_id)
+			SELECT $1, id FROM quests WHERE id = $2
+			ON CONFLICT DO NOTHING
+		`, eventID, questID)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, fmt.Errorf("quest %d: %w", questID, ErrEventQuestNotFound)
+		}
+	}
+
+	if _, _, err := refreshEventStatuses(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	var event models.Event
+	if err := tx.GetContext(ctx, &event, `SELECT * FROM events WHERE id = $1`, eventID); err != nil {
+		return nil, err
+	}
+	if event.QuestIDs, err = r.getEventQuestIDs(ctx, tx, eventID); err != nil {
+		return nil, err
+	}
+
+	return &event, tx.Commit()
+}
+
+// DeleteEvent удаляет событие; его квесты становятся обычными квестами магазина
+func (r *QuestRepository) DeleteEvent(ctx context.Context, eventID int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM events WHERE id = $1`, eventID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetEventLeaderboard возвращает таблицу лидеров события по заработанному за время события опыту
+func (r *QuestRepository) GetEventLeaderboard(ctx context.Context, eventID, limit, offset int) ([]models.EventLeaderboardEntry, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)`, eventID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	entries := []models.EventLeaderboardEntry{}
+	err := r.db.SelectContext(ctx, &entries, `
+		SELECT
+			RANK() OVER (ORDER BY s.xp_earned DESC)::int AS rank,
+			s.user_id, u.username, s.xp_earned, s.coins_earned, s.quests_completed
+		FROM event_scores s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.event_id = $1 AND NOT u.leaderboard_opt_out
+		ORDER BY s.xp_earned DESC, s.user_id
+		LIMIT $2 OFFSET $3
+	`, eventID, limit, offset)
+	return entries, err
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 
 	"BecomeOverMan/internal/models"
 	"errors"
@@ -10,57 +11,194 @@ import (
 )
 
 var (
-	ErrAlreadyFriends = errors.New("Эти пользователи уже друзья")
+	ErrAlreadyFriends        = errors.New("Эти пользователи уже друзья")
+	ErrFriendSelf            = errors.New("Нельзя добавить в друзья самого себя")
+	ErrFriendRequestExists   = errors.New("Заявка в друзья уже отправлена")
+	ErrFriendRequestNotFound = errors.New("Заявка в друзья не найдена")
 )
 
-func (r *UserRepository) AddFriend(userID, friendID int) error {
+// AddFriend отправляет заявку в друзья и возвращает итоговый статус (pending или accepted)
+func (r *UserRepository) AddFriend(userID, friendID int) (string, error) {
 	exists, err := r.isUserExists(friendID)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if !exists {
-		return errors.New("Такого пользователя не существует")
+		return "", errors.New("Такого пользователя не существует")
 	}
 
 	return r.addFriend(userID, friendID)
 }
 
-func (r *UserRepository) AddFriendbyName(userID int, friendName string) error {
+func (r *UserRepository) AddFriendbyName(userID int, friendName string) (string, error) {
 	friendID, err := r.getUserIdByUsername(friendName)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	return r.addFriend(userID, friendID)
 }
 
-// Проверяем, что дружба не существует
-func (r *UserRepository) isFriends(userID, friendID int) (bool, error) {
-	var friendshipExists bool
-	err := r.db.Get(&friendshipExists, `
-		SELECT EXISTS(SELECT 1 FROM friends WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))`,
+// addFriend отправляет заявку в друзья.
+// Если встречная заявка от friendID уже ждет ответа, она сразу принимается.
+func (r *UserRepository) addFriend(userID, friendID int) (string, error) {
+	if userID == friendID {
+		return "", ErrFriendSelf
+	}
+
+	ctx := context.Background()
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	blocked, err := isBlocked(ctx, tx, userID, friendID)
+	if err != nil {
+		return "", err
+	}
+	if blocked {
+		return "", ErrUserBlocked
+	}
+
+	existing, err := lockFriendship(ctx, tx, userID, friendID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Уникальный индекс по паре пользователей не дает создать две встречные записи:
+		// если параллельная заявка успела вставиться первой, перечитываем ее ниже
+		var requestID int
+		err = tx.GetContext(ctx, &requestID, `
+			INSERT INTO friends (user_id, friend_id, status)
+			VALUES ($1, $2, $3)
+			ON CONFLICT ((LEAST(user_id, friend_id)), (GREATEST(user_id, friend_id))) DO NOTHING
+			RETURNING id`,
+			userID, friendID, models.FriendStatusPending)
+		if err == nil {
+			err = notify(ctx, tx, friendID, models.NotificationFriendRequest, map[string]any{
+				"request_id":   requestID,
+				"from_user_id": userID,
+			})
+			if err != nil {
+				return "", err
+			}
+			return models.FriendStatusPending, tx.Commit()
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+		existing, err = lockFriendship(ctx, tx, userID, friendID)
+	}
+	switch {
+	case err != nil:
+		return "", err
+	case existing.Status == models.FriendStatusAccepted:
+		return "", ErrAlreadyFriends
+	case existing.UserID == userID:
+		return "", ErrFriendRequestExists
+	}
+
+	// Встречная заявка - принимаем ее
+	if err := acceptFriendRequest(ctx, tx, existing.ID, existing.UserID, userID); err != nil {
+		return "", err
+	}
+	return models.FriendStatusAccepted, tx.Commit()
+}
+
+// lockFriendship блокирует запись о дружбе (или заявке) между двумя пользователями в любом направлении
+func lockFriendship(ctx context.Context, tx *sqlx.Tx, userID, friendID int) (models.Friend, error) {
+	var existing models.Friend
+	err := tx.GetContext(ctx, &existing, `
+		SELECT id, user_id, friend_id, status, '' AS username, created_at FROM friends
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)
+		FOR UPDATE`,
 		userID, friendID)
+	return existing, err
+}
+
+// GetFriendRequests возвращает ожидающие заявки: входящие (username - отправитель) или исходящие (username - получатель)
+func (r *UserRepository) GetFriendRequests(userID int, incoming bool) ([]models.Friend, error) {
+	requests := []models.Friend{}
+	err := r.db.Select(&requests, `
+		SELECT f.id, f.user_id, f.friend_id, f.status, u.username, f.created_at
+		FROM friends f
+		JOIN users u ON u.id = CASE WHEN $2 THEN f.user_id ELSE f.friend_id END
+		WHERE f.status = $3
+		AND CASE WHEN $2 THEN f.friend_id ELSE f.user_id END = $1
+		ORDER BY f.created_at DESC, f.id DESC`,
+		userID, incoming, models.FriendStatusPending)
+	return requests, err
+}
+
+// AcceptFriendRequest принимает входящую заявку (только получатель)
+func (r *UserRepository) AcceptFriendRequest(userID, requestID int) error {
+	ctx := context.Background()
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return false, err
+		return err
 	}
+	defer tx.Rollback()
+
+	var senderID int
+	err = tx.GetContext(ctx, &senderID, `
+		SELECT user_id FROM friends
+		WHERE id = $1 AND friend_id = $2 AND status = $3
+		FOR UPDATE`,
+		requestID, userID, models.FriendStatusPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFriendRequestNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := acceptFriendRequest(ctx, tx, requestID, senderID, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeclineFriendRequest отклоняет входящую заявку (только получатель).
+// Запись удаляется, поэтому отправитель сможет отправить заявку повторно.
+func (r *UserRepository) DeclineFriendRequest(userID, requestID int) error {
+	return r.deleteFriendRequest(`
+		DELETE FROM friends WHERE id = $1 AND friend_id = $2 AND status = $3`,
+		requestID, userID)
+}
 
-	return friendshipExists, nil
+// CancelFriendRequest отзывает исходящую заявку (только отправитель)
+func (r *UserRepository) CancelFriendRequest(userID, requestID int) error {
+	return r.deleteFriendRequest(`
+		DELETE FROM friends WHERE id = $1 AND user_id = $2 AND status = $3`,
+		requestID, userID)
 }
 
-func (r *UserRepository) addFriend(userID, friendID int) error {
-	isFriends, err := r.isFriends(userID, friendID)
+func (r *UserRepository) deleteFriendRequest(query string, requestID, userID int) error {
+	res, err := r.db.Exec(query, requestID, userID, models.FriendStatusPending)
 	if err != nil {
 		return err
 	}
-	if isFriends {
-		return ErrAlreadyFriends
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
 	}
+	if affected == 0 {
+		return ErrFriendRequestNotFound
+	}
+	return nil
+}
 
-	_, err = r.db.Exec(`
-		INSERT INTO friends (user_id, friend_id, status) 
-		VALUES ($1, $2, 'accepted')`,
-		userID, friendID)
-	return err
+// acceptFriendRequest переводит заявку в accepted и уведомляет отправителя
+func acceptFriendRequest(ctx context.Context, tx *sqlx.Tx, requestID, senderID, recipientID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE friends SET status = $2 WHERE id = $1`,
+		requestID, models.FriendStatusAccepted)
+	if err != nil {
+		return err
+	}
+
+	return notify(ctx, tx, senderID, models.NotificationFriendAccepted, map[string]any{
+		"request_id": requestID,
+		"user_id":    recipientID,
+	})
 }
 
 func (r *UserRepository) GetAllAcceptedFriends(userID int) ([]int, error) {
@@ -106,130 +244,14 @@ func (r *UserRepository) GetFriends(userID int) ([]models.Friend, error) {
 	return friends, err
 }
 
-// Shared quest methods
-func (r *QuestRepository) CreateSharedQuest(user1ID, user2ID, questID int) error {
-	ctx := context.Background()
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Проверяем, что пользователи друзья (проверяем оба направления)
+// areAcceptedFriends проверяет, что пользователи друзья (в обоих направлениях, статус accepted)
+func areAcceptedFriends(ctx context.Context, q sqlx.QueryerContext, userID, friendID int) (bool, error) {
 	var areFriends bool
-	err = tx.Get(&areFriends, `
+	err := sqlx.GetContext(ctx, q, &areFriends, `
 		SELECT EXISTS(
 			SELECT 1 FROM friends 
 			WHERE ((user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))
 			AND status = 'accepted'
-		)`, user1ID, user2ID)
-	if err != nil {
-		return err
-	}
-	if !areFriends {
-		return errors.New("users are not friends")
-	}
-
-	// Создаем shared quest
-	_, err = tx.Exec(`
-		INSERT INTO shared_quests (user1_id, user2_id, quest_id, status) 
-		VALUES ($1, $2, $3, 'active')`,
-		user1ID, user2ID, questID)
-	if err != nil {
-		return err
-	}
-
-	// Стартуем квест для обоих пользователей
-	if err := r.startQuestForUser(tx, user1ID, questID); err != nil {
-		return err
-	}
-	if err := r.startQuestForUser(tx, user2ID, questID); err != nil {
-		return err
-	}
-
-	return tx.Commit()
-}
-
-func (r *QuestRepository) startQuestForUser(tx *sqlx.Tx, userID, questID int) error {
-	// Покупаем квест (если еще не куплен)
-	var alreadyPurchased bool
-	err := tx.Get(&alreadyPurchased, `
-		SELECT EXISTS(SELECT 1 FROM user_quests WHERE user_id = $1 AND quest_id = $2)`,
-		userID, questID)
-	if err != nil {
-		return err
-	}
-
-	if alreadyPurchased {
-		return errors.New("quest already purchased")
-	}
-
-	// Получаем цену квеста
-	var price int
-	err = tx.Get(&price, "SELECT price FROM quests WHERE id = $1", questID)
-	if err != nil {
-		return err
-	}
-
-	// Проверяем баланс
-	var balance int
-	err = tx.Get(&balance, "SELECT coin_balance FROM users WHERE id = $1", userID)
-	if err != nil {
-		return err
-	}
-
-	if balance < price {
-		return errors.New("not enough coins for shared quest")
-	}
-
-	// Покупаем квест
-	_, err = tx.Exec(`
-			INSERT INTO user_quests (user_id, quest_id, status) 
-			VALUES ($1, $2, 'purchased')`,
-		userID, questID)
-	if err != nil {
-		return err
-	}
-
-	// Списываем монеты
-	_, err = tx.Exec(`
-			UPDATE users SET coin_balance = coin_balance - $1 WHERE id = $2`,
-		price, userID)
-	if err != nil {
-		return err
-	}
-
-	// Создаем user_tasks для всех задач квеста
-	_, err = tx.Exec(`
-		INSERT INTO user_tasks (user_id, task_id, quest_id, status)
-		SELECT $1, qt.task_id, qt.quest_id, 'not_started'
-		FROM quest_tasks qt
-		WHERE qt.quest_id = $2
-		ORDER BY qt.task_order
-	`, userID, questID)
-	if err != nil {
-		return err
-	}
-
-	// Стартуем квест
-	_, err = tx.Exec(`
-			UPDATE user_quests 
-			SET status = 'started', started_at = NOW(), expires_at = (
-				SELECT NOW() + (time_limit_hours || ' hours')::interval 
-				FROM quests WHERE id = $2
-			)
-			WHERE user_id = $1 AND quest_id = $2`,
-		userID, questID)
-	if err != nil {
-		return err
-	}
-
-	// Активируем задачи
-	_, err = tx.Exec(`
-		UPDATE user_tasks
-		SET status = 'active'
-		WHERE user_id = $1 AND quest_id = $2 AND status = 'not_started'
-	`, userID, questID)
-
-	return err
+		)`, userID, friendID)
+	return areFriends, err
 }
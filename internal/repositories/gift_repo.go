@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotFriends        = errors.New("users are not friends")
+	ErrGiftToSelf        = errors.New("can't send a gift to yourself")
+	ErrGiftLimitExceeded = errors.New("daily gift limit exceeded")
+	ErrQuestAlreadyOwned = errors.New("friend already has this quest")
+)
+
+// GiftCoins переводит монеты другу (парные записи в журнале у отправителя и получателя)
+func (r *QuestRepository) GiftCoins(ctx context.Context, senderID, recipientID, amount int, message string) (*models.Gift, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	senderName, err := r.checkGiftAllowed(ctx, tx, senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sentToday int
+	err = tx.GetContext(ctx, &sentToday, `
+		SELECT COALESCE(SUM(amount), 0) FROM gifts
+		WHERE sender_id = $1 AND kind = $2 AND created_at >= date_trunc('day', NOW())
+	`, senderID, models.GiftKindCoins)
+	if err != nil {
+		return nil, err
+	}
+	limit := config.Economy().Gifts.DailyCoinsLimit
+	if sentToday+amount > limit {
+		return nil, fmt.Errorf("%w: %d of %d coins left for today", ErrGiftLimitExceeded, max(limit-sentToday, 0), limit)
+	}
+
+	gift, err := r.insertGift(ctx, tx, senderID, recipientID, models.GiftKindCoins, amount, nil, message)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ledger.Apply(ctx, tx, coinEntry(senderID, -amount,
+		models.TransactionTypeSpent, models.ReferenceTypeGift, gift.ID, fmt.Sprintf("Gift to user #%d", recipientID)))
+	if err != nil {
+		return nil, err
+	}
+	err = r.ledger.Apply(ctx, tx, coinEntry(recipientID, amount,
+		models.TransactionTypeBonus, models.ReferenceTypeGift, gift.ID, "Gift from "+senderName))
+	if err != nil {
+		return nil, err
+	}
+
+	err = notify(ctx, tx, recipientID, models.NotificationGiftCoins, map[string]any{
+		"gift_id":     gift.ID,
+		"sender_id":   senderID,
+		"sender_name": senderName,
+		"amount":      amount,
+		"message":     message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gift, tx.Commit()
+}
+
+// GiftQuest покупает квест за счет отправителя и кладет его другу в статусе purchased.
+// В журнале получателя квест отражается парой: подарок +price и покупка -price.
+func (r *QuestRepository) GiftQuest(ctx context.Context, senderID, recipientID, questID int, message string) (*models.Gift, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	senderName, err := r.checkGiftAllowed(ctx, tx, senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var giftsToday int
+	err = tx.GetContext(ctx, &giftsToday, `
+		SELECT COUNT(*) FROM gifts
+		WHERE sender_id = $1 AND kind = $2 AND created_at >= date_trunc('day', NOW())
+	`, senderID, models.GiftKindQuest)
+	if err != nil {
+		return nil, err
+	}
+	limit := config.Economy().Gifts.DailyQuestsLimit
+	if giftsToday >= limit {
+		return nil, fmt.Errorf("%w: max %d quests per day", ErrGiftLimitExceeded, limit)
+	}
+
+	var quest models.Quest
+	if err := tx.GetContext(ctx, &quest, "SELECT * FROM quests WHERE id = $1", questID); err != nil {
+		return nil, err
+	}
+
+	var alreadyOwned bool
+	err = tx.GetContext(ctx, &alreadyOwned, `
+		SELECT EXISTS(SELECT 1 FROM user_quests WHERE user_id = $1 AND quest_id = $2)
+	`, recipientID, questID)
+	if err != nil {
+		return nil, err
+	}
+	if alreadyOwned {
+		return nil, ErrQuestAlreadyOwned
+	}
+
+	pending, err := hasPendingSharedSeat(ctx, tx, recipientID, questID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrSharedSeatPending
+	}
+
+	gift, err := r.insertGift(ctx, tx, senderID, recipientID, models.GiftKindQuest, quest.Price, &questID, message)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.CoinTransaction{
+		coinEntry(senderID, -quest.Price, models.TransactionTypeSpent, models.ReferenceTypeGift, gift.ID,
+			fmt.Sprintf("Gifted quest to user #%d: %s", recipientID, quest.Title)),
+		coinEntry(recipientID, quest.Price, models.TransactionTypeBonus, models.ReferenceTypeGift, gift.ID,
+			"Quest gift from "+senderName),
+		coinEntry(recipientID, -quest.Price, models.TransactionTypeSpent, models.ReferenceTypeQuest, questID,
+			"Purchased quest (gift): "+quest.Title),
+	}
+	for _, entry := range entries {
+		if err := r.ledger.Apply(ctx, tx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.grantQuestToUser(ctx, tx, recipientID, questID); err != nil {
+		return nil, err
+	}
+
+	err = notify(ctx, tx, recipientID, models.NotificationGiftQuest, map[string]any{
+		"gift_id":     gift.ID,
+		"sender_id":   senderID,
+		"sender_name": senderName,
+		"quest_id":    questID,
+		"quest_title": quest.Title,
+		"message":     message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gift, tx.Commit()
+}
+
+// GetGifts возвращает подарки пользователя (отправленные и полученные), новые сверху
+func (r *QuestRepository) GetGifts(ctx context.Context, userID, limit, offset int) ([]models.Gift, error) {
+	gifts := []models.Gift{}
+	err := r.db.SelectContext(ctx, &gifts, `
+		SELECT * FROM gifts
+		WHERE sender_id = $1 OR recipient_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return gifts, nil
+}
+
+// checkGiftAllowed блокирует строку отправителя (сериализует лимиты) и проверяет дружбу.
+// Возвращает username отправителя.
+func (r *QuestRepository) checkGiftAllowed(ctx context.Context, tx *sqlx.Tx, senderID, recipientID int) (string, error) {
+	if senderID == recipientID {
+		return "", ErrGiftToSelf
+	}
+
+	var senderName string
+	err := tx.GetContext(ctx, &senderName, `SELECT username FROM users WHERE id = $1 FOR UPDATE`, senderID)
+	if err != nil {
+		return "", err
+	}
+
+	areFriends, err := areAcceptedFriends(ctx, tx, senderID, recipientID)
+	if err != nil {
+		return "", err
+	}
+	if !areFriends {
+		return "", ErrNotFriends
+	}
+
+	return senderName, nil
+}
+
+func (r *QuestRepository) insertGift(ctx context.Context, tx *sqlx.Tx, senderID, recipientID int, kind string, amount int, questID *int, message string) (*models.Gift, error) {
+	var msg *string
+	if message != "" {
+		msg = &message
+	}
+
+	var gift models.Gift
+	err := tx.GetContext(ctx, &gift, `
+		INSERT INTO gifts (sender_id, recipient_id, kind, amount, quest_id, message)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, senderID, recipientID, kind, amount, questID, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &gift, nil
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrItemNotOwned       = errors.New("item not in inventory")
+	ErrItemUsedAutomatic  = errors.New("streak freeze is used automatically when a day is missed")
+	ErrQuestNotExtendable = errors.New("quest is not started or has no time limit")
+	ErrUnknownItemEffect  = errors.New("unknown item effect")
+)
+
+// addInventoryItem добавляет пользователю quantity предметов itemCode
+func addInventoryItem(ctx context.Context, tx *sqlx.Tx, userID int, itemCode string, quantity int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_inventory (user_id, item_code, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, item_code) DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity
+	`, userID, itemCode, quantity)
+	return err
+}
+
+// getInventoryQuantity возвращает количество предметов itemCode у пользователя
+func getInventoryQuantity(ctx context.Context, q sqlx.QueryerContext, userID int, itemCode string) (int, error) {
+	var quantity int
+	err := sqlx.GetContext(ctx, q, &quantity, `
+		SELECT COALESCE((SELECT quantity FROM user_inventory WHERE user_id = $1 AND item_code = $2), 0)
+	`, userID, itemCode)
+	return quantity, err
+}
+
+// xpMultiplier возвращает действующий множитель опыта пользователя в процентах (100 - без бустера).
+// Бустеры не складываются - действует наибольший.
+func xpMultiplier(ctx context.Context, q sqlx.QueryerContext, userID int) (int, error) {
+	var percent int
+	err := sqlx.GetContext(ctx, q, &percent, `
+		SELECT COALESCE(MAX(effect_value), 100) FROM user_active_effects
+		WHERE user_id = $1 AND effect_type = $2 AND starts_at <= NOW() AND expires_at > NOW()
+	`, userID, models.ItemEffectXPBooster)
+	return percent, err
+}
+
+// boostedXP применяет к награде опытом действующий бустер пользователя
+func boostedXP(ctx context.Context, q sqlx.QueryerContext, userID, xp int) (int, error) {
+	if xp <= 0 {
+		return xp, nil
+	}
+	percent, err := xpMultiplier(ctx, q, userID)
+	if err != nil {
+		return 0, err
+	}
+	return xp * percent / 100, nil
+}
+
+// GetItems возвращает каталог предметов, доступных для покупки
+func (r *QuestRepository) GetItems(ctx context.Context) ([]models.Item, error) {
+	items := []models.Item{}
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT code, name, description, price, effect_type, effect_value, duration_hours, is_active
+		FROM items WHERE is_active ORDER BY price, code
+	`)
+	return items, err
+}
+
+// GetInventory возвращает предметы пользователя (только с ненулевым количеством)
+func (r *QuestRepository) GetInventory(ctx context.Context, userID int) ([]models.InventoryItem, error) {
+	inventory := []models.InventoryItem{}
+	err := r.db.SelectContext(ctx, &inventory, `
+		SELECT i.code, i.name, i.description, i.price, i.effect_type, i.effect_value, i.duration_hours, i.is_active,
+			ui.quantity
+		FROM user_inventory ui
+		JOIN items i ON i.code = ui.item_code
+		WHERE ui.user_id = $1 AND ui.quantity > 0
+		ORDER BY i.name
+	`, userID)
+	return inventory, err
+}
+
+// GetActiveEffects возвращает действующие эффекты пользователя
+func (r *QuestRepository) GetActiveEffects(ctx context.Context, userID int) ([]models.ActiveEffect, error) {
+	effects := []models.ActiveEffect{}
+	err := r.db.SelectContext(ctx, &effects, `
+		SELECT * FROM user_active_effects
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY expires_at
+	`, userID)
+	return effects, err
+}
+
+// PurchaseItem покупает quantity предметов: списание через журнал транзакций и пополнение инвентаря в одной транзакции
+func (r *QuestRepository) PurchaseItem(ctx context.Context, userID int, code string, quantity int) (*models.InventoryItem, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Проверяем, что предмет существует и продается
+	var item struct {
+		ID int `db:"id"`
+		models.Item
+	}
+	err = tx.GetContext(ctx, &item, `SELECT * FROM items WHERE code = $1 AND is_active`, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Списываем валюту (с проверкой баланса и записью в журнал транзакций)
+	err = r.ledger.Apply(ctx, tx, coinEntry(userID, -item.Price*quantity,
+		models.TransactionTypeSpent, models.ReferenceTypeItem, item.ID, fmt.Sprintf("Purchased item: %s x%d", item.Name, quantity)))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addInventoryItem(ctx, tx, userID, code, quantity); err != nil {
+		return nil, err
+	}
+
+	err = recordActivity(ctx, tx, userID, models.ActivityItemPurchased, map[string]any{
+		"code":     code,
+		"name":     item.Name,
+		"quantity": quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := getInventoryQuantity(ctx, tx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InventoryItem{Item: item.Item, Quantity: total}, tx.Commit()
+}
+
+// UseItem тратит один предмет из инвентаря и применяет его эффект
+func (r *QuestRepository) UseItem(ctx context.Context, userID int, code string, questID int) (*models.UseItemResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var item models.Item
+	err = tx.GetContext(ctx, &item, `
+		SELECT code, name, description, price, effect_type, effect_value, duration_hours, is_active
+		FROM items WHERE code = $1
+	`, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.EffectType == models.ItemEffectStreakFreeze {
+		return nil, ErrItemUsedAutomatic
+	}
+
+	// Списываем предмет (блокировка строки инвентаря защищает от двойного использования)
+	result := &models.UseItemResult{ItemCode: code}
+	err = tx.GetContext(ctx, &result.RemainingQuantity, `
+		UPDATE user_inventory SET quantity = quantity - 1
+		WHERE user_id = $1 AND item_code = $2 AND quantity > 0
+		RETURNING quantity
+	`, userID, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrItemNotOwned
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch item.EffectType {
+	case models.ItemEffectXPBooster:
+		var effect models.ActiveEffect
+		err = tx.GetContext(ctx, &effect, `
+			INSERT INTO user_active_effects (user_id, item_code, effect_type, effect_value, expires_at)
+			VALUES ($1, $2, $3, $4, NOW() + make_interval(hours => $5))
+			RETURNING *
+		`, userID, code, item.EffectType, item.EffectValue, item.DurationHours)
+		if err != nil {
+			return nil, err
+		}
+		result.Effect = &effect
+
+	case models.ItemEffectTimerExtender:
+		var expiresAt time.Time
+		err = tx.GetContext(ctx, &expiresAt, `
+			UPDATE user_quests SET expires_at = expires_at + make_interval(hours => $1)
+			WHERE user_id = $2 AND quest_id = $3 AND status = 'started'
+			  AND expires_at IS NOT NULL AND expires_at > NOW()
+			RETURNING expires_at
+		`, item.EffectValue, userID, questID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrQuestNotExtendable
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.QuestExpiresAt = &expiresAt
+
+	default:
+		return nil, ErrUnknownItemEffect
+	}
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard parameters")
+
+// leaderboardUserColumns - колонка users со счетом таблицы лидеров за все время
+var leaderboardUserColumns = map[string]string{
+	models.LeaderboardMetricXP:     "xp_points",
+	models.LeaderboardMetricLevel:  "level",
+	models.LeaderboardMetricStreak: "current_streak",
+	models.LeaderboardMetricQuests: "quests_completed",
+}
+
+// recordXPHistory добавляет заработанный опыт и завершенные квесты в агрегаты текущего дня, недели (UTC)
+// и всего времени: общий (category = ”) и по категории квеста. questID = 0 - опыт вне квестов, идет только в общий.
+func recordXPHistory(ctx context.Context, tx *sqlx.Tx, userID, questID, xp, quests int) error {
+	if xp <= 0 && quests == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_xp_periods (period, period_start, category, user_id, xp_earned, quests_completed)
+		SELECT p.period, p.period_start, c.category, $1, $3, $4
+		FROM (VALUES ($5, $6::date), ($7, $8::date), ($9, $10::date)) AS p(period, period_start)
+		CROSS JOIN (
+			SELECT '' AS category
+			UNION
+			SELECT category FROM quests WHERE id = $2 AND category <> ''
+		) c
+		ON CONFLICT (period, period_start, category, user_id) DO UPDATE SET
+			xp_earned = user_xp_periods.xp_earned + EXCLUDED.xp_earned,
+			quests_completed = user_xp_periods.quests_completed + EXCLUDED.quests_completed
+	`, userID, questID, max(xp, 0), quests,
+		models.LeaderboardPeriodDay, leaderboardSince(models.LeaderboardPeriodDay, now).Format(time.DateOnly),
+		models.LeaderboardPeriodWeek, leaderboardSince(models.LeaderboardPeriodWeek, now).Format(time.DateOnly),
+		models.LeaderboardPeriodAll, leaderboardSince(models.LeaderboardPeriodAll, now).Format(time.DateOnly))
+	return err
+}
+
+// leaderboardSince - первый день периода (UTC); для all - нулевое время (0001-01-01 в user_xp_periods)
+func leaderboardSince(period string, now time.Time) time.Time {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case models.LeaderboardPeriodDay:
+		return today
+	case models.LeaderboardPeriodWeek:
+		// неделя начинается с понедельника
+		return today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	}
+	return time.Time{}
+}
+
+// validateLeaderboardQuery проверяет метрику, период, область и категорию
+func validateLeaderboardQuery(q models.LeaderboardQuery) error {
+	if _, ok := leaderboardUserColumns[q.Metric]; !ok {
+		return fmt.Errorf("%w: unknown metric %q", ErrInvalidLeaderboard, q.Metric)
+	}
+	switch q.Period {
+	case models.LeaderboardPeriodDay, models.LeaderboardPeriodWeek, models.LeaderboardPeriodAll:
+	default:
+		return fmt.Errorf("%w: unknown period %q", ErrInvalidLeaderboard, q.Period)
+	}
+	if q.Scope != models.LeaderboardScopeGlobal && q.Scope != models.LeaderboardScopeFriends {
+		return fmt.Errorf("%w: unknown scope %q", ErrInvalidLeaderboard, q.Scope)
+	}
+	if _, ok := attributeColumns[q.Category]; q.Category != "" && !ok {
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidLeaderboard, q.Category)
+	}
+	return nil
+}
+
+// leaderboardScores строит подзапрос (user_id, username, score) для таблицы лидеров.
+// За все время без категории счет берется из колонок users, уровень в категории - из уровня ветки,
+// остальное - из готовых агрегатов user_xp_periods. Порядок (score DESC, user_id) везде читается
+// по индексу, без сортировки всех пользователей.
+// Скрытые пользователи не попадают в таблицу.
+func leaderboardScores(q models.LeaderboardQuery, userID int, now time.Time) (string, []any, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conds := []string{"NOT u.leaderboard_opt_out"}
+
+	var score, from string
+	switch {
+	case q.Period == models.LeaderboardPeriodAll && q.Category == "":
+		score, from = "u."+leaderboardUserColumns[q.Metric], "users u"
+	case q.Period == models.LeaderboardPeriodAll && q.Metric == models.LeaderboardMetricLevel:
+		score, from = "u."+attributeColumns[q.Category], "users u"
+	case q.Metric == models.LeaderboardMetricXP || q.Metric == models.LeaderboardMetricQuests:
+		score = "p.xp_earned"
+		if q.Metric == models.LeaderboardMetricQuests {
+			score = "p.quests_completed"
+		}
+		from = "user_xp_periods p JOIN users u ON u.id = p.user_id"
+		conds = append(conds,
+			"p.period = "+arg(q.Period),
+			"p.period_start = "+arg(leaderboardSince(q.Period, now).Format(time.DateOnly)),
+			"p.category = "+arg(q.Category),
+			score+" > 0")
+	default:
+		return "", nil, fmt.Errorf("%w: %s leaderboard is only available for all time", ErrInvalidLeaderboard, q.Metric)
+	}
+
+	if q.Scope == models.LeaderboardScopeFriends {
+		me := arg(userID)
+		conds = append(conds, fmt.Sprintf(`(u.id = %[1]s OR u.id IN (
+			SELECT CASE WHEN user_id = %[1]s THEN friend_id ELSE user_id END
+			FROM friends
+			WHERE (user_id = %[1]s OR friend_id = %[1]s) AND status = 'accepted'
+		))`, me))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT u.id AS user_id, u.username, %s AS score
+		FROM %s
+		WHERE %s`, score, from, strings.Join(conds, " AND "))
+	return query, args, nil
+}
+
+// rankLeaderboardEntries проставляет места на странице (как RANK: равный счет - одно место).
+// above - участников со счетом выше первой записи страницы, atOrAbove - со счетом не ниже ее.
+func rankLeaderboardEntries(entries []models.LeaderboardEntry, above, atOrAbove int) {
+	if len(entries) == 0 {
+		return
+	}
+
+	first := entries[0].Score
+	rank, below := above+1, 0
+	for i := range entries {
+		if i > 0 && entries[i].Score != entries[i-1].Score {
+			rank = atOrAbove + below + 1
+		}
+		entries[i].Rank = rank
+		if entries[i].Score < first {
+			below++
+		}
+	}
+}
+
+// GetLeaderboard возвращает страницу таблицы лидеров и место в ней текущего пользователя.
+// Страница выбирается по курсору q.After (или offset) в порядке индекса, места считаются
+// подсчетом участников с большим счетом - без оконных функций по всей таблице.
+func (r *QuestRepository) GetLeaderboard(ctx context.Context, userID int, q models.LeaderboardQuery, limit, offset int) (*models.Leaderboard, error) {
+	if err := validateLeaderboardQuery(q); err != nil {
+		return nil, err
+	}
+
+	scores, args, err := leaderboardScores(q, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// withArgs добавляет параметры к аргументам подзапроса и возвращает плейсхолдер последнего
+	withArgs := func(extra ...any) (string, []any) {
+		all := append(args[:len(args):len(args)], extra...)
+		return fmt.Sprintf("$%d", len(all)), all
+	}
+
+	board := &models.Leaderboard{LeaderboardQuery: q, Entries: []models.LeaderboardEntry{}}
+
+	pageArgs := args[:len(args):len(args)]
+	where := "TRUE"
+	if q.After != nil {
+		pageArgs = append(pageArgs, q.After.Score, q.After.UserID)
+		where = fmt.Sprintf("s.score < $%[1]d OR (s.score = $%[1]d AND s.user_id > $%[2]d)", len(pageArgs)-1, len(pageArgs))
+		offset = 0
+	}
+	pageArgs = append(pageArgs, limit, offset)
+	err = r.db.SelectContext(ctx, &board.Entries, fmt.Sprintf(`
+		SELECT s.user_id, s.username, s.score
+		FROM (%s) s
+		WHERE %s
+		ORDER BY s.score DESC, s.user_id
+		LIMIT $%d OFFSET $%d
+	`, scores, where, len(pageArgs)-1, len(pageArgs)), pageArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(board.Entries) > 0 {
+		first := board.Entries[0].Score
+		scoreArg, countArgs := withArgs(first)
+
+		var above, atOrAbove int
+		err = r.db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT
+				(SELECT COUNT(*) FROM (%[1]s) s WHERE s.score > %[2]s),
+				(SELECT COUNT(*) FROM (%[1]s) s WHERE s.score >= %[2]s)
+		`, scores, scoreArg), countArgs...).Scan(&above, &atOrAbove)
+		if err != nil {
+			return nil, err
+		}
+		rankLeaderboardEntries(board.Entries, above, atOrAbove)
+
+		if len(board.Entries) == limit {
+			last := board.Entries[len(board.Entries)-1]
+			board.NextCursor = &models.LeaderboardCursor{Score: last.Score, UserID: last.UserID}
+		}
+	}
+
+	// Место пользователя - число участников с большим счетом + 1 (совпадает с RANK)
+	var me models.LeaderboardEntry
+	meArg, meArgs := withArgs(userID)
+	err = r.db.GetContext(ctx, &me, fmt.Sprintf(`
+		SELECT s.user_id, s.username, s.score FROM (%s) s WHERE s.user_id = %s
+	`, scores, meArg), meArgs...)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return board, nil
+	case err != nil:
+		return nil, err
+	}
+
+	scoreArg, countArgs := withArgs(me.Score)
+	err = r.db.GetContext(ctx, &me.Rank, fmt.Sprintf(`
+		SELECT COUNT(*)::int + 1 FROM (%s) s WHERE s.score > %s
+	`, scores, scoreArg), countArgs...)
+	if err != nil {
+		return nil, err
+	}
+	board.Me = &me
+
+	return board, nil
+}
+
+// SetLeaderboardOptOut скрывает пользователя из таблиц лидеров или возвращает его туда
+func (r *QuestRepository) SetLeaderboardOptOut(ctx context.Context, userID int, optOut bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET leaderboard_opt_out = $1 WHERE id = $2`, optOut, userID)
+	return err
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"BecomeOverMan/internal/models"
+)
+
+func TestRankLeaderboardEntries(t *testing.T) {
+	tests := []struct {
+		name      string
+		scores    []int64
+		above     int
+		atOrAbove int
+		want      []int
+	}{
+		{name: "first page", scores: []int64{50, 40, 30}, above: 0, atOrAbove: 1, want: []int{1, 2, 3}},
+		{name: "ties share a rank", scores: []int64{50, 50, 40, 40, 10}, above: 0, atOrAbove: 2, want: []int{1, 1, 3, 3, 5}},
+		{name: "page starts inside a tie", scores: []int64{40, 40, 30, 20, 20}, above: 5, atOrAbove: 9, want: []int{6, 6, 10, 11, 11}},
+		{name: "whole page is one tie", scores: []int64{7, 7, 7}, above: 12, atOrAbove: 20, want: []int{13, 13, 13}},
+		{name: "empty page", scores: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []models.LeaderboardEntry
+			for i, s := range tt.scores {
+				entries = append(entries, models.LeaderboardEntry{UserID: i + 1, Score: s})
+			}
+
+			rankLeaderboardEntries(entries, tt.above, tt.atOrAbove)
+
+			var got []int
+			for _, e := range entries {
+				got = append(got, e.Rank)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ranks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeaderboardSince(t *testing.T) {
+	tests := []struct {
+		name   string
+		period string
+		now    time.Time
+		want   string
+	}{
+		{name: "day", period: models.LeaderboardPeriodDay, now: time.Date(2026, 3, 4, 23, 59, 0, 0, time.UTC), want: "2026-03-04"},
+		{name: "day uses UTC", period: models.LeaderboardPeriodDay, now: time.Date(2026, 3, 5, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*3600)), want: "2026-03-04"},
+		{name: "week from wednesday", period: models.LeaderboardPeriodWeek, now: time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), want: "2026-03-02"},
+		{name: "week on monday", period: models.LeaderboardPeriodWeek, now: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), want: "2026-03-02"},
+		{name: "week on sunday", period: models.LeaderboardPeriodWeek, now: time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC), want: "2026-03-02"},
+		{name: "all time", period: models.LeaderboardPeriodAll, now: time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), want: "0001-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := leaderboardSince(tt.period, tt.now).Format(time.DateOnly); got != tt.want {
+				t.Errorf("leaderboardSince(%q) = %s, want %s", tt.period, got, tt.want)
+			}
+		})
+	}
+}
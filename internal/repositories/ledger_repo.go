@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotEnoughCoins = errors.New("not enough currency")
+)
+
+// LedgerRepository - единственная точка изменения users.coin_balance.
+// Каждое изменение баланса записывается в user_coin_transactions в той же транзакции БД.
+type LedgerRepository struct {
+	db *sqlx.DB
+}
+
+func NewLedgerRepository(db *sqlx.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// Apply изменяет баланс пользователя на entry.Amount и пишет типизированную запись в журнал.
+// Если баланс уйдет в минус - возвращает ErrNotEnoughCoins. Нулевые суммы не пишутся.
+func (r *LedgerRepository) Apply(ctx context.Context, tx *sqlx.Tx, entry models.CoinTransaction) error {
+	if entry.Amount == 0 {
+		return nil
+	}
+
+	var balanceAfter int
+	err := tx.GetContext(ctx, &balanceAfter, `
+		UPDATE users
+		SET coin_balance = coin_balance + $1
+		WHERE id = $2 AND coin_balance + $1 >= 0
+		RETURNING coin_balance
+	`, entry.Amount, entry.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotEnoughCoins
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_coin_transactions
+		(user_id, amount, balance_after, transaction_type, reference_type, reference_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, entry.UserID, entry.Amount, balanceAfter, entry.TransactionType,
+		entry.ReferenceType, entry.ReferenceID, entry.Description)
+	return err
+}
+
+// queryTransactionHistory - журнал пользователя с балансом после каждой операции.
+// Для старых записей без balance_after баланс считается нарастающим итогом от начального баланса -
+// монет, которые были у пользователя до появления журнала (coin_balance минус сумма всех записей).
+const queryTransactionHistory = `
+	WITH opening AS (
+		SELECT COALESCE(u.coin_balance, 0)
+			- COALESCE((SELECT SUM(amount) FROM user_coin_transactions WHERE user_id = u.id), 0) AS balance
+		FROM users u
+		WHERE u.id = $1
+	),
+	ledger AS (
+		SELECT t.*,
+			COALESCE((SELECT balance FROM opening), 0)
+				+ SUM(t.amount) OVER (ORDER BY t.created_at, t.id) AS running_balance
+		FROM user_coin_transactions t
+		WHERE t.user_id = $1
+	)
+	SELECT
+		l.id, l.user_id, l.reference_type, l.reference_id, l.transaction_type,
+		l.amount, COALESCE(l.balance_after, l.running_balance) AS balance_after,
+		l.description, l.created_at,
+		CASE l.reference_type
+			WHEN 'quest' THEN q.title
+			WHEN 'task'  THEN tk.title
+			WHEN 'item'  THEN it.name
+		END AS reference_title
+	FROM ledger l
+	LEFT JOIN quests q ON l.reference_type = 'quest' AND q.id = l.reference_id
+	LEFT JOIN tasks tk ON l.reference_type = 'task' AND tk.id = l.reference_id
+	LEFT JOIN items it ON l.reference_type = 'item' AND it.id = l.reference_id
+	WHERE ($2 = '' OR l.transaction_type = $2)
+	  AND ($3 = '' OR l.reference_type = $3)
+	  AND ($4::timestamp IS NULL OR l.created_at >= $4)
+	  AND ($5::timestamp IS NULL OR l.created_at < $5)
+`
+
+// GetTransactions возвращает страницу истории транзакций пользователя (новые сверху) и общее количество
+func (r *LedgerRepository) GetTransactions(ctx context.Context, userID int, f models.TransactionFilter) ([]models.CoinTransaction, int, error) {
+	args := []any{userID, f.TransactionType, f.ReferenceType, f.From, f.To}
+
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+queryTransactionHistory+`) h`, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := []models.CoinTransaction{}
+	err = r.db.SelectContext(ctx, &items, queryTransactionHistory+`
+		ORDER BY l.created_at DESC, l.id DESC
+		LIMIT $6 OFFSET $7
+	`, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// ForEachTransaction построчно читает всю историю транзакций пользователя по фильтрам (новые сверху)
+// и вызывает fn для каждой записи, не загружая журнал в память целиком
+func (r *LedgerRepository) ForEachTransaction(ctx context.Context, userID int, f models.TransactionFilter, fn func(models.CoinTransaction) error) error {
+	rows, err := r.db.QueryxContext(ctx, queryTransactionHistory+`
+		ORDER BY l.created_at DESC, l.id DESC
+	`, userID, f.TransactionType, f.ReferenceType, f.From, f.To)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.CoinTransaction
+		if err := rows.StructScan(&t); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetDrifts возвращает пользователей, у которых coin_balance не совпадает с суммой по журналу
+func (r *LedgerRepository) GetDrifts(ctx context.Context) ([]models.LedgerDrift, error) {
+	drifts := []models.LedgerDrift{}
+	err := r.db.SelectContext(ctx, &drifts, `
+		SELECT
+			u.id AS user_id,
+			u.username,
+			COALESCE(u.coin_balance, 0) AS coin_balance,
+			COALESCE(l.ledger_sum, 0) AS ledger_sum,
+			COALESCE(u.coin_balance, 0) - COALESCE(l.ledger_sum, 0) AS drift
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, SUM(amount) AS ledger_sum
+			FROM user_coin_transactions
+			GROUP BY user_id
+		) l ON l.user_id = u.id
+		WHERE COALESCE(u.coin_balance, 0) <> COALESCE(l.ledger_sum, 0)
+		ORDER BY ABS(COALESCE(u.coin_balance, 0) - COALESCE(l.ledger_sum, 0)) DESC, u.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+func (r *LedgerRepository) CountUsers(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM users`)
+	return count, err
+}
+
+// coinEntry - хелпер для сборки записи журнала
+func coinEntry(userID, amount int, transactionType, referenceType string, referenceID int, description string) models.CoinTransaction {
+	return models.CoinTransaction{
+		UserID:          userID,
+		Amount:          amount,
+		TransactionType: transactionType,
+		ReferenceType:   referenceType,
+		ReferenceID:     &referenceID,
+		Description:     &description,
+	}
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// notify создает уведомление пользователю в рамках текущей транзакции
+func notify(ctx context.Context, tx sqlx.ExecerContext, userID int, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, payload) VALUES ($1, $2, $3::jsonb)
+	`, userID, kind, string(data))
+	return err
+}
+
+func (r *NotificationRepository) GetNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	err := r.db.SelectContext(ctx, &notifications, `
+		SELECT * FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR is_read = FALSE)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, notificationID int) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_id = $2
+	`, notificationID, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE
+	`, userID)
+	return err
+}
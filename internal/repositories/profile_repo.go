@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrProfilePrivate = errors.New("profile is private")
+)
+
+// publicProfileRow - данные профиля вместе с настройками видимости его владельца
+type publicProfileRow struct {
+	ID              int    `db:"id"`
+	Username        string `db:"username"`
+	IsFriend        bool   `db:"is_friend"`
+	Level           int    `db:"level"`
+	XpPoints        int    `db:"xp_points"`
+	CurrentStreak   int    `db:"current_streak"`
+	LongestStreak   int    `db:"longest_streak"`
+	QuestsCompleted int    `db:"quests_completed"`
+	models.UserAttributes
+
+	ProfileVisibility    string `db:"profile_visibility"`
+	StatsVisibility      string `db:"stats_visibility"`
+	AttributesVisibility string `db:"attributes_visibility"`
+	BadgesVisibility     string `db:"badges_visibility"`
+	QuestsVisibility     string `db:"quests_visibility"`
+}
+
+// visibleTo - видно ли поле с видимостью v пользователю viewerID
+func (row *publicProfileRow) visibleTo(viewerID int, v string) bool {
+	return row.ID == viewerID || v == models.VisibilityPublic || (v == models.VisibilityFriends && row.IsFriend)
+}
+
+// profile собирает публичный профиль, скрывая недоступные viewerID группы полей
+func (row *publicProfileRow) profile(viewerID int) models.PublicProfile {
+	p := models.PublicProfile{ID: row.ID, Username: row.Username, IsFriend: row.IsFriend}
+	if !row.visibleTo(viewerID, row.ProfileVisibility) {
+		p.HiddenFields = []string{"profile"}
+		return p
+	}
+
+	if row.visibleTo(viewerID, row.StatsVisibility) {
+		p.Level, p.XpPoints = &row.Level, &row.XpPoints
+		p.CurrentStreak, p.LongestStreak = &row.CurrentStreak, &row.LongestStreak
+		p.QuestsCompleted = &row.QuestsCompleted
+	} else {
+		p.HiddenFields = append(p.HiddenFields, "stats")
+	}
+
+	if row.visibleTo(viewerID, row.AttributesVisibility) {
+		p.Attributes = &row.UserAttributes
+	} else {
+		p.HiddenFields = append(p.HiddenFields, "attributes")
+	}
+
+	if !row.visibleTo(viewerID, row.BadgesVisibility) {
+		p.HiddenFields = append(p.HiddenFields, "badges")
+	}
+	if !row.visibleTo(viewerID, row.QuestsVisibility) {
+		p.HiddenFields = append(p.HiddenFields, "quests")
+	}
+	return p
+}
+
+func (r *UserRepository) queryPublicProfileRows(viewerID int, userIDs []int) ([]publicProfileRow, error) {
+	defaults := models.DefaultPrivacySettings(0)
+
+	rows := []publicProfileRow{}
+	err := r.db.Select(&rows, `
+		SELECT u.id, u.username,
+			EXISTS (
+				SELECT 1 FROM friends f
+				WHERE ((f.user_id = $1 AND f.friend_id = u.id) OR (f.user_id = u.id AND f.friend_id = $1))
+				AND f.status = 'accepted'
+			) AS is_friend,
+			COALESCE(u.level, 1) AS level,
+			COALESCE(u.xp_points, 0) AS xp_points,
+			COALESCE(u.current_streak, 0) AS current_streak,
+			COALESCE(u.longest_streak, 0) AS longest_streak,
+			u.quests_completed,
+			COALESCE(u.health_level, 0) AS health_level,
+			COALESCE(u.mental_health_level, 0) AS mental_health_level,
+			COALESCE(u.intelligence_level, 0) AS intelligence_level,
+			COALESCE(u.charisma_level, 0) AS charisma_level,
+			COALESCE(u.willpower_level, 0) AS willpower_level,
+			COALESCE(p.profile_visibility, $3) AS profile_visibility,
+			COALESCE(p.stats_visibility, $4) AS stats_visibility,
+			COALESCE(p.attributes_visibility, $5) AS attributes_visibility,
+			COALESCE(p.badges_visibility, $6) AS badges_visibility,
+			COALESCE(p.quests_visibility, $7) AS quests_visibility
+		FROM users u
+		LEFT JOIN user_privacy_settings p ON p.user_id = u.id
+		WHERE u.id = ANY($2)
+		ORDER BY array_position($2, u.id)`,
+		viewerID, pq.Array(userIDs),
+		defaults.ProfileVisibility, defaults.StatsVisibility, defaults.AttributesVisibility,
+		defaults.BadgesVisibility, defaults.QuestsVisibility)
+	return rows, err
+}
+
+// GetPublicProfiles возвращает профили пользователей в исходном порядке так, как их видит viewerID
+// (без достижений и списка квестов - они есть только в GetPublicProfile)
+func (r *UserRepository) GetPublicProfiles(viewerID int, userIDs []int) ([]models.PublicProfile, error) {
+	if len(userIDs) == 0 {
+		return []models.PublicProfile{}, nil
+	}
+
+	rows, err := r.queryPublicProfileRows(viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]models.PublicProfile, len(rows))
+	for i := range rows {
+		profiles[i] = rows[i].profile(viewerID)
+	}
+	return profiles, nil
+}
+
+// publicProfileCompletedQuests - сколько последних завершенных квестов показывать в профиле
+const publicProfileCompletedQuests = 20
+
+// GetPublicProfile возвращает профиль пользователя с достижениями и последними завершенными квестами
+// (с учетом настроек приватности). Заблокированные в любую сторону профиль не видят.
+func (r *UserRepository) GetPublicProfile(viewerID, userID int) (*models.PublicProfile, error) {
+	blocked, err := isBlocked(context.Background(), r.db, viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrUserNotFound
+	}
+
+	rows, err := r.queryPublicProfileRows(viewerID, []int{userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrUserNotFound
+	}
+	row := &rows[0]
+
+	if !row.visibleTo(viewerID, row.ProfileVisibility) {
+		return nil, ErrProfilePrivate
+	}
+	profile := row.profile(viewerID)
+
+	if row.visibleTo(viewerID, row.BadgesVisibility) {
+		profile.Badges = []models.Badge{}
+		err = r.db.Select(&profile.Badges, `
+			SELECT 'achievement' AS source, a.name, COALESCE(a.description, '') AS description, ua.unlocked_at
+			FROM user_achievements ua
+			JOIN achievements a ON a.id = ua.achievement_id
+			WHERE ua.user_id = $1
+			UNION ALL
+			SELECT 'team' AS source, ta.name, ta.description, tua.unlocked_at
+			FROM team_members tm
+			JOIN team_unlocked_achievements tua ON tua.team_id = tm.team_id
+			JOIN team_achievements ta ON ta.id = tua.achievement_id
+			WHERE tm.user_id = $1
+			ORDER BY unlocked_at DESC`,
+			userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if row.visibleTo(viewerID, row.QuestsVisibility) {
+		profile.CompletedQuests = []models.CompletedQuest{}
+		err = r.db.Select(&profile.CompletedQuests, `
+			SELECT uq.quest_id, q.title, q.category, uq.completed_at
+			FROM user_quests uq
+			JOIN quests q ON q.id = uq.quest_id
+			WHERE uq.user_id = $1 AND uq.status = 'completed'
+			ORDER BY uq.completed_at DESC
+			LIMIT $2`,
+			userID, publicProfileCompletedQuests)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &profile, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchUsers ищет пользователей по началу имени и нечетко (pg_trgm): сначала совпадения по префиксу,
+// затем по похожести. Исключает заблокированных в любую сторону и тех, чей профиль скрыт от viewerID.
+func (r *UserRepository) SearchUsers(viewerID int, query string, limit, offset int) ([]models.UserSearchResult, error) {
+	query = strings.ToLower(query)
+	defaults := models.DefaultPrivacySettings(0)
+
+	results := []models.UserSearchResult{}
+	err := r.db.Select(&results, `
+		SELECT u.id, u.username,
+			CASE
+				WHEN f.status = $5 THEN $7
+				WHEN f.user_id = $1 THEN $8
+				WHEN f.id IS NOT NULL THEN $9
+				ELSE $10
+			END AS relation,
+			CASE WHEN f.status = $6 THEN f.id END AS friend_request_id
+		FROM users u
+		LEFT JOIN user_privacy_settings p ON p.user_id = u.id
+		LEFT JOIN LATERAL (
+			SELECT f.id, f.user_id, f.status
+			FROM friends f
+			WHERE (f.user_id = $1 AND f.friend_id = u.id) OR (f.user_id = u.id AND f.friend_id = $1)
+			ORDER BY f.status = $5 DESC
+			LIMIT 1
+		) f ON TRUE
+		WHERE u.id <> $1
+		AND (lower(u.username) LIKE $3 OR lower(u.username) % $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+		)
+		AND (
+			COALESCE(p.profile_visibility, $4) = $11
+			OR (COALESCE(p.profile_visibility, $4) = $12 AND f.status = $5)
+		)
+		ORDER BY lower(u.username) LIKE $3 DESC, similarity(lower(u.username), $2) DESC, u.username, u.id
+		LIMIT $13 OFFSET $14`,
+		viewerID, query, escapeLike(query)+"%", defaults.ProfileVisibility,
+		models.FriendStatusAccepted, models.FriendStatusPending,
+		models.RelationFriend, models.RelationRequestSent, models.RelationRequestReceived, models.RelationNone,
+		models.VisibilityPublic, models.VisibilityFriends,
+		limit, offset)
+	return results, err
+}
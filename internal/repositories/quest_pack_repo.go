@@ -0,0 +1,349 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ExportQuestPack выгружает весь каталог квестов (с задачами и цепочками) в формат quest pack.
+// Ключом квеста служит pack_key, который выдается при создании квеста (см. initDB.sql),
+// поэтому повторный импорт пака обновляет именно эти квесты, а пак из другой базы не совпадает с чужими.
+func (r *QuestRepository) ExportQuestPack(ctx context.Context) (*models.QuestPack, error) {
+	var quests []models.Quest
+	if err := r.db.SelectContext(ctx, &quests, `SELECT * FROM quests ORDER BY id`); err != nil {
+		return nil, err
+	}
+
+	pack := &models.QuestPack{
+		Version: models.QuestPackVersion,
+		Quests:  make([]models.QuestPackQuest, 0, len(quests)),
+	}
+	keysByID := make(map[int]string, len(quests))
+
+	for _, q := range quests {
+		var tasks []models.Task
+		err := r.db.SelectContext(ctx, &tasks, `
+			SELECT t.*, qt.task_order
+			FROM tasks t
+			INNER JOIN quest_tasks qt ON t.id = qt.task_id
+			WHERE qt.quest_id = $1
+			ORDER BY qt.task_order ASC
+		`, q.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		packQuest := models.QuestPackQuest{
+			Key:            *q.PackKey,
+			Title:          q.Title,
+			Description:    q.Description,
+			Category:       q.Category,
+			Rarity:         q.Rarity,
+			Difficulty:     q.Difficulty,
+			Price:          q.Price,
+			IsSequential:   q.IsSequential,
+			RewardXP:       q.RewardXP,
+			RewardCoin:     q.RewardCoin,
+			TimeLimitHours: q.TimeLimitHours,
+			Coop:           models.NewQuestPackCoop(q.QuestCoopSettings),
+			Tasks:          make([]models.QuestPackTask, 0, len(tasks)),
+		}
+		if packQuest.Conditions, err = fromJSONB(q.ConditionsJson); err != nil {
+			return nil, fmt.Errorf("quest %d conditions_json: %w", q.ID, err)
+		}
+		if packQuest.Bonus, err = fromJSONB(q.BonusJson); err != nil {
+			return nil, fmt.Errorf("quest %d bonus_json: %w", q.ID, err)
+		}
+
+		for _, t := range tasks {
+			packQuest.Tasks = append(packQuest.Tasks, models.QuestPackTask{
+				Order:          t.TaskOrder,
+				Title:          t.Title,
+				Description:    t.Description,
+				Difficulty:     t.Difficulty,
+				Rarity:         t.Rarity,
+				Category:       t.Category,
+				BaseXpReward:   t.BaseXpReward,
+				BaseCoinReward: t.BaseCoinReward,
+			})
+		}
+
+		keysByID[q.ID] = packQuest.Key
+		pack.Quests = append(pack.Quests, packQuest)
+	}
+
+	var chains []struct {
+		ID      int    `db:"id"`
+		PackKey string `db:"pack_key"`
+		Title   string `db:"title"`
+	}
+	if err := r.db.SelectContext(ctx, &chains, `SELECT id, pack_key, title FROM quest_chains ORDER BY id`); err != nil {
+		return nil, err
+	}
+
+	for _, ch := range chains {
+		var questIDs []int
+		err := r.db.SelectContext(ctx, &questIDs, `
+			SELECT quest_id FROM quest_chain_items WHERE chain_id = $1 ORDER BY position
+		`, ch.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		packChain := models.QuestPackChain{Key: ch.PackKey, Title: ch.Title, Quests: make([]string, 0, len(questIDs))}
+		for _, id := range questIDs {
+			packChain.Quests = append(packChain.Quests, keysByID[id])
+		}
+		pack.Chains = append(pack.Chains, packChain)
+	}
+
+	return pack, nil
+}
+
+// ImportQuestPack идемпотентно загружает (провалидированный) quest pack в БД.
+// Квесты сопоставляются по pack_key, задачи внутри квеста - по order.
+// Задачи, привязанные и к другим квестам, не изменяются: квест получает их новую копию.
+// При dryRun все изменения откатываются, но отчет о действиях возвращается.
+func (r *QuestRepository) ImportQuestPack(ctx context.Context, pack *models.QuestPack, dryRun bool) ([]models.QuestPackEntryResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]models.QuestPackEntryResult, 0, len(pack.Quests)+len(pack.Chains))
+	questIDsByKey := make(map[string]int, len(pack.Quests))
+
+	for i, q := range pack.Quests {
+		questID, action, err := r.importPackQuest(ctx, tx, q)
+		if err != nil {
+			return nil, fmt.Errorf("quest #%d (%s): %w", i, q.Key, err)
+		}
+		questIDsByKey[q.Key] = questID
+		results = append(results, models.QuestPackEntryResult{Kind: "quest", Key: q.Key, Index: i, Action: action})
+	}
+
+	for i, ch := range pack.Chains {
+		action, err := r.importPackChain(ctx, tx, ch, questIDsByKey)
+		if err != nil {
+			return nil, fmt.Errorf("chain #%d (%s): %w", i, ch.Key, err)
+		}
+		results = append(results, models.QuestPackEntryResult{Kind: "chain", Key: ch.Key, Index: i, Action: action})
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	return results, tx.Commit()
+}
+
+// findPackQuestID ищет квест по pack_key (по id не сопоставляем: пак мог прийти из другой базы)
+func (r *QuestRepository) findPackQuestID(ctx context.Context, tx *sqlx.Tx, key string) (int, bool, error) {
+	var questID int
+	err := tx.GetContext(ctx, &questID, `SELECT id FROM quests WHERE pack_key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return questID, true, nil
+}
+
+func (r *QuestRepository) importPackQuest(ctx context.Context, tx *sqlx.Tx, q models.QuestPackQuest) (int, string, error) {
+	conditions, err := toJSONB(q.Conditions)
+	if err != nil {
+		return 0, "", err
+	}
+	bonus, err := toJSONB(q.Bonus)
+	if err != nil {
+		return 0, "", err
+	}
+
+	coop := q.Coop.Settings()
+
+	questID, found, err := r.findPackQuestID(ctx, tx, q.Key)
+	if err != nil {
+		return 0, "", err
+	}
+
+	action := models.QuestPackActionUpdated
+	if found {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE quests SET
+				title = $1, description = $2, category = $3, rarity = $4, difficulty = $5,
+				price = $6, tasks_count = $7, conditions_json = $8::jsonb, bonus_json = $9::jsonb,
+				is_sequential = $10, reward_xp = $11, reward_coin = $12, time_limit_hours = $13,
+				pack_key = $14, coop_bonus_percent = $16, coop_bonus_window_hours = $17,
+				coop_partial_reward_percent = $18, coop_fail_penalty_percent = $19
+			WHERE id = $15
+		`, q.Title, q.Description, q.Category, q.Rarity, q.Difficulty,
+			q.Price, len(q.Tasks), conditions, bonus,
+			q.IsSequential, q.RewardXP, q.RewardCoin, q.TimeLimitHours,
+			q.Key, questID, coop.CoopBonusPercent, coop.CoopBonusWindowHours,
+			coop.CoopPartialRewardPercent, coop.CoopFailPenaltyPercent)
+	} else {
+		action = models.QuestPackActionCreated
+		err = tx.GetContext(ctx, &questID, `
+			INSERT INTO quests (
+				title, description, category, rarity, difficulty, price, tasks_count,
+				conditions_json, bonus_json, is_sequential, reward_xp, reward_coin, time_limit_hours, pack_key,
+				coop_bonus_percent, coop_bonus_window_hours, coop_partial_reward_percent, coop_fail_penalty_percent
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+			RETURNING id
+		`, q.Title, q.Description, q.Category, q.Rarity, q.Difficulty,
+			q.Price, len(q.Tasks), conditions, bonus,
+			q.IsSequential, q.RewardXP, q.RewardCoin, q.TimeLimitHours, q.Key,
+			coop.CoopBonusPercent, coop.CoopBonusWindowHours, coop.CoopPartialRewardPercent, coop.CoopFailPenaltyPercent)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	// Текущие задачи квеста: task_order -> связь (shared - задача привязана и к другим квестам)
+	var links []struct {
+		ID        int  `db:"id"`
+		TaskOrder int  `db:"task_order"`
+		TaskID    int  `db:"task_id"`
+		Shared    bool `db:"shared"`
+	}
+	err = tx.SelectContext(ctx, &links, `
+		SELECT qt.id, COALESCE(qt.task_order, 0) AS task_order, qt.task_id,
+			EXISTS (
+				SELECT 1 FROM quest_tasks other
+				WHERE other.task_id = qt.task_id AND other.quest_id <> qt.quest_id
+			) AS shared
+		FROM quest_tasks qt
+		WHERE qt.quest_id = $1
+	`, questID)
+	if err != nil {
+		return 0, "", err
+	}
+	linksByOrder := make(map[int]int, len(links))
+	for i, l := range links {
+		linksByOrder[l.TaskOrder] = i
+	}
+
+	orders := make([]int64, 0, len(q.Tasks))
+	for _, t := range q.Tasks {
+		orders = append(orders, int64(t.Order))
+
+		i, linked := linksByOrder[t.Order]
+		if linked && !links[i].Shared {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE tasks SET
+					title = $1, description = $2, difficulty = $3, rarity = $4, category = $5,
+					base_xp_reward = $6, base_coin_reward = $7
+				WHERE id = $8
+			`, t.Title, t.Description, t.Difficulty, t.Rarity, t.Category,
+				t.BaseXpReward, t.BaseCoinReward, links[i].TaskID)
+			if err != nil {
+				return 0, "", err
+			}
+			continue
+		}
+
+		var taskID int
+		err = tx.GetContext(ctx, &taskID, `
+			INSERT INTO tasks (
+				title, description, difficulty, rarity, category,
+				base_xp_reward, base_coin_reward
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`, t.Title, t.Description, t.Difficulty, t.Rarity, t.Category,
+			t.BaseXpReward, t.BaseCoinReward)
+		if err != nil {
+			return 0, "", err
+		}
+
+		if linked {
+			// Общая задача остается у других квестов, этот квест переходит на свою копию
+			_, err = tx.ExecContext(ctx, `UPDATE quest_tasks SET task_id = $1 WHERE id = $2`, taskID, links[i].ID)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO quest_tasks (quest_id, task_id, task_order)
+				VALUES ($1, $2, $3)
+			`, questID, taskID, t.Order)
+		}
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	// Отвязываем задачи, которых больше нет в паке (сами задачи остаются - на них может ссылаться история)
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM quest_tasks WHERE quest_id = $1 AND NOT (COALESCE(task_order, 0) = ANY($2))
+	`, questID, pq.Array(orders))
+	if err != nil {
+		return 0, "", err
+	}
+
+	return questID, action, nil
+}
+
+func (r *QuestRepository) importPackChain(ctx context.Context, tx *sqlx.Tx, ch models.QuestPackChain, questIDsByKey map[string]int) (string, error) {
+	var chainID int
+	var inserted bool
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO quest_chains (pack_key, title) VALUES ($1, $2)
+		ON CONFLICT (pack_key) DO UPDATE SET title = EXCLUDED.title
+		RETURNING id, (xmax = 0)
+	`, ch.Key, ch.Title).Scan(&chainID, &inserted)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quest_chain_items WHERE chain_id = $1`, chainID); err != nil {
+		return "", err
+	}
+
+	for pos, key := range ch.Quests {
+		questID, ok := questIDsByKey[key]
+		if !ok {
+			return "", fmt.Errorf("unknown quest key %q", key)
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO quest_chain_items (chain_id, quest_id, position) VALUES ($1, $2, $3)
+		`, chainID, questID, pos+1)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if inserted {
+		return models.QuestPackActionCreated, nil
+	}
+	return models.QuestPackActionUpdated, nil
+}
+
+func toJSONB(m map[string]any) (*string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	s := string(data)
+	return &s, nil
+}
+
+func fromJSONB(raw *json.RawMessage) (map[string]any, error) {
+	if raw == nil || len(*raw) == 0 || string(*raw) == "null" {
+		return nil, nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(*raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -2,11 +2,12 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"math"
 	"time"
 
+	"BecomeOverMan/internal/config"
 	"BecomeOverMan/internal/models"
 
 	"github.com/jmoiron/sqlx"
@@ -14,11 +15,12 @@ import (
 )
 
 type QuestRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	ledger *LedgerRepository
 }
 
 func NewQuestRepository(db *sqlx.DB) *QuestRepository {
-	return &QuestRepository{db: db}
+	return &QuestRepository{db: db, ledger: NewLedgerRepository(db)}
 }
 
 func (r *QuestRepository) SaveQuestToDB(quest *models.Quest, tasks []models.Task) (int, error) {
@@ -135,6 +137,7 @@ const (
 			ut.duration,
 			ut.updated_by_ai,
 			ut.is_confirmed,
+			ut.reward_held,
 			ut.completed_at,
 			ut.xp_gained,
 			ut.coin_gained
@@ -153,7 +156,10 @@ func (r *QuestRepository) GetQuestDetails(ctx context.Context, questID int, user
 	// Получаем основную информацию о квесте
 	var quest models.Quest
 	err := r.db.GetContext(ctx, &quest, `
-        SELECT * FROM quests WHERE id = $1
+        SELECT q.*, `+questRatingStatsColumns+`
+        FROM quests q
+        `+questRatingStatsJoin+`
+        WHERE q.id = $1
     `, questID)
 	if err != nil {
 		return nil, err
@@ -264,17 +270,33 @@ func (r *QuestRepository) GetAvailableQuests(ctx context.Context, userID int) ([
 	return quests, nil
 }
 
-func (r *QuestRepository) GetQuestShop(ctx context.Context, userID int) ([]models.Quest, error) {
+// Варианты сортировки магазина квестов
+const (
+	ShopSortDefault = ""
+	ShopSortRating  = "rating"
+)
+
+func (r *QuestRepository) GetQuestShop(ctx context.Context, userID int, sort string) ([]models.Quest, error) {
 	var quests []models.Quest
 
 	query := `
-	SELECT q.* FROM quests q
+	SELECT q.*, ` + questRatingStatsColumns + `
+	FROM quests q
+	` + questRatingStatsJoin + `
 	WHERE NOT EXISTS (
 		SELECT 1 FROM user_quests uq
 		WHERE uq.quest_id = q.id AND uq.user_id = $1
-	)`
+	)
+	AND NOT ` + eventQuestHiddenCondition
 	// + TODO: conditions_json нужно проверить
 
+	switch sort {
+	case ShopSortRating:
+		query += ` ORDER BY rs.avg_rating DESC NULLS LAST, reviews_count DESC, q.id`
+	default:
+		query += ` ORDER BY q.id`
+	}
+
 	// Получаем все квесты, что у нас не куплены и не были пройдены
 	if err := r.db.SelectContext(ctx, &quests, query, userID); err != nil {
 		return nil, err
@@ -358,27 +380,46 @@ func (r *QuestRepository) PurchaseQuest(ctx context.Context, userID, questID int
 		return errors.New("quest already purchased or completed")
 	}
 
-	// Проверяем баланс пользователя
-	var balance int
-	err = tx.GetContext(ctx, &balance, "SELECT coin_balance FROM users WHERE id = $1", userID)
+	// Квест будет выдан при старте совместного квеста - второй раз не покупаем
+	pending, err := hasPendingSharedSeat(ctx, tx, userID, questID)
 	if err != nil {
 		return err
 	}
-
-	if balance < quest.Price {
-		return errors.New("not enough currency")
+	if pending {
+		return ErrSharedSeatPending
 	}
 
-	// Списываем валюту
-	_, err = tx.ExecContext(ctx,
-		"UPDATE users SET coin_balance = coin_balance - $1 WHERE id = $2",
-		quest.Price, userID)
+	// Списываем валюту (с проверкой баланса и записью в журнал транзакций)
+	err = r.ledger.Apply(ctx, tx, coinEntry(userID, -quest.Price,
+		models.TransactionTypeSpent, models.ReferenceTypeQuest, quest.ID, "Purchased quest: "+quest.Title))
 	if err != nil {
 		return err
 	}
 
 	// Добавляем квест пользователю
-	_, err = tx.ExecContext(ctx, `
+	if err := r.grantQuestToUser(ctx, tx, userID, questID); err != nil {
+		return err
+	}
+
+	err = recordActivity(ctx, tx, userID, models.ActivityQuestPurchased, map[string]any{
+		"quest_id": quest.ID,
+		"title":    quest.Title,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// grantQuestToUser кладет квест пользователю в статусе purchased (вместе с его задачами)
+func (r *QuestRepository) grantQuestToUser(ctx context.Context, tx *sqlx.Tx, userID, questID int) error {
+	// Квесты событий можно получить только пока событие активно
+	if err := checkQuestAvailable(ctx, tx, questID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
         INSERT INTO user_quests 
         (user_id, quest_id, status, started_at, expires_at)
         VALUES ($1, $2, 'purchased', NULL, NULL)`,
@@ -394,21 +435,7 @@ func (r *QuestRepository) PurchaseQuest(ctx context.Context, userID, questID int
 		WHERE qt.quest_id = $2
 		ORDER BY qt.task_order
 	`, userID, questID)
-	if err != nil {
-		return err
-	}
-
-	// Записываем транзакцию
-	_, err = tx.ExecContext(ctx, `
-        INSERT INTO user_coin_transactions 
-        (user_id, amount, transaction_type, reference_type, reference_id, description)
-        VALUES ($1, $2, 'spent', 'quest', $3, 'Purchased quest: ' || $4)`,
-		userID, -quest.Price, quest.ID, quest.Title)
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit()
+	return err
 }
 
 // StartQuest начинает выполнение квеста
@@ -450,63 +477,82 @@ func (r *QuestRepository) StartQuest(ctx context.Context, userID, questID int) e
 	return tx.Commit()
 }
 
-// calculateLevel вычисляет уровень игрока на основе опыта
-// Использует квадратичную прогрессию (quadratic progression) - научно обоснованную формулу для RPG
-// Формула: level = floor(sqrt(XP / base)) + 1
-// Где base = 100 - базовое значение для балансировки прогрессии
-// Эта формула обеспечивает экспоненциальный рост требований к опыту для каждого следующего уровня
+// calculateLevel вычисляет уровень игрока на основе опыта по кривой из конфигурации экономики (economy.yaml).
+// По умолчанию - квадратичная прогрессия: level = floor(sqrt(XP / 100)) + 1
 // Примеры: 0-99 XP → Lv1, 100-399 XP → Lv2, 400-899 XP → Lv3, 900-1599 XP → Lv4, и т.д.
 func calculateLevel(xp int) int {
-	if xp < 0 {
-		xp = 0
-	}
-	
-	const baseXP = 100.0 // Базовое значение для квадратичной прогрессии
-	
-	// Квадратичная формула: level = floor(sqrt(XP / base)) + 1
-	level := int(math.Floor(math.Sqrt(float64(xp) / baseXP))) + 1
-	
-	// Минимальный уровень = 1
-	if level < 1 {
-		level = 1
-	}
-	
-	return level
+	return config.Economy().LevelForXP(max(xp, 0))
 }
 
-// addXPAndCoinsWithLevelUp начисляет опыт и монеты пользователю, автоматически повышая уровень
-func (r *QuestRepository) addXPAndCoinsWithLevelUp(tx *sqlx.Tx, ctx context.Context, userID, xpAmount, coinAmount int) error {
-	// Получаем текущий опыт пользователя
-	var currentXP int
-	err := tx.GetContext(ctx, &currentXP, "SELECT xp_points FROM users WHERE id = $1", userID)
+// addXPAndCoinsWithLevelUp начисляет опыт и монеты пользователю, автоматически повышая уровень.
+// Монеты начисляются через журнал транзакций (coins.Amount).
+func (r *QuestRepository) addXPAndCoinsWithLevelUp(tx *sqlx.Tx, ctx context.Context, userID, xpAmount int, coins models.CoinTransaction) error {
+	// Получаем текущий опыт и уровень пользователя
+	var current struct {
+		XP    int `db:"xp_points"`
+		Level int `db:"level"`
+	}
+	err := tx.GetContext(ctx, &current, "SELECT xp_points, level FROM users WHERE id = $1", userID)
 	if err != nil {
 		return err
 	}
 
 	// Вычисляем новый опыт и уровень
-	newXP := currentXP + xpAmount
+	newXP := current.XP + xpAmount
 	newLevel := calculateLevel(newXP)
 
-	// Начисляем опыт, монеты и обновляем уровень
+	// Начисляем опыт и обновляем уровень
 	_, err = tx.ExecContext(ctx, `
 		UPDATE users 
 		SET xp_points = xp_points + $1,
-			coin_balance = coin_balance + $2,
-			level = $3
-		WHERE id = $4`,
-		xpAmount, coinAmount, newLevel, userID)
+			level = $2
+		WHERE id = $3`,
+		xpAmount, newLevel, userID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if newLevel > current.Level {
+		if err := recordActivity(ctx, tx, userID, models.ActivityLevelUp, map[string]any{"level": newLevel}); err != nil {
+			return err
+		}
+	}
+
+	// Начисляем монеты
+	coins.UserID = userID
+	return r.ledger.Apply(ctx, tx, coins)
+}
+
+// attributeColumns - колонка ветки пользователя для категории квеста
+var attributeColumns = map[string]string{
+	"health":        "health_level",
+	"mental_health": "mental_health_level",
+	"intelligence":  "intelligence_level",
+	"charisma":      "charisma_level",
+	"willpower":     "willpower_level",
 }
 
-// CompleteTask отмечает выполнение задачи
-func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, taskID int) error {
+// addAttribute изменяет уровень ветки пользователя на delta в пределах [0, attribute_cap].
+// Категории без ветки игнорируются.
+func addAttribute(ctx context.Context, tx *sqlx.Tx, userID int, category string, delta int) error {
+	column, ok := attributeColumns[category]
+	if !ok || delta == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE users SET %[1]s = LEAST(GREATEST(COALESCE(%[1]s, 0) + $1, 0), $2) WHERE id = $3
+	`, column), delta, config.Economy().AttributeCap, userID)
+	return err
+}
+
+// CompleteTask отмечает выполнение задачи.
+// Выполнение проходит проверки anti-cheat: подозрительное выполнение засчитывается,
+// но награда удерживается до решения администратора.
+func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, taskID int) (*models.TaskCompletionResult, error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
@@ -523,28 +569,68 @@ func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, tas
 		`, userID, questID, taskID,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !exists {
-		return errors.New("quest or task not found or already completed")
+		return nil, errors.New("quest or task not found or already completed")
+	}
+
+	flags, err := checkTaskCompletion(ctx, tx, userID, questID, taskID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Получаем награду за задачу
 	var baseXpReward, baseCoinReward int
+	var taskTitle string
 	err = tx.QueryRowContext(ctx, `
-		SELECT base_xp_reward, base_coin_reward 
+		SELECT base_xp_reward, base_coin_reward, title
 		FROM tasks 
 		WHERE id = $1
-	`, taskID).Scan(&baseXpReward, &baseCoinReward)
+	`, taskID).Scan(&baseXpReward, &baseCoinReward, &taskTitle)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Начисляем награду пользователю сразу
-	err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, baseXpReward, baseCoinReward)
+	// Награда увеличивается бустером пользователя и множителями активных событий
+	baseXpReward, baseCoinReward, eventIDs, err := calcRewardBonuses(ctx, tx, userID, baseXpReward, baseCoinReward)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	result := &models.TaskCompletionResult{XPGained: baseXpReward, CoinGained: baseCoinReward, Flags: flags}
+	if len(flags) > 0 {
+		// Подозрительное выполнение - награда удерживается до проверки
+		reviewID, err := holdTaskReward(ctx, tx, userID, questID, taskID, flags, baseXpReward, baseCoinReward)
+		if err != nil {
+			return nil, err
+		}
+		result.RewardHeld = true
+		result.ReviewID = &reviewID
+	} else {
+		if err := recordEventScores(ctx, tx, eventIDs, userID, baseXpReward, baseCoinReward, false); err != nil {
+			return nil, err
+		}
+
+		// Начисляем награду пользователю сразу
+		err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, baseXpReward, coinEntry(userID, baseCoinReward,
+			models.TransactionTypeEarned, models.ReferenceTypeTask, taskID, "Completed task: "+taskTitle))
+		if err != nil {
+			return nil, err
+		}
+		if err := recordXPHistory(ctx, tx, userID, questID, baseXpReward, 0); err != nil {
+			return nil, err
+		}
+
+		err = recordActivity(ctx, tx, userID, models.ActivityTaskCompleted, map[string]any{
+			"quest_id": questID,
+			"task_id":  taskID,
+			"title":    taskTitle,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// обновляем статус задачи, сохраняем награду в user_tasks
@@ -554,19 +640,25 @@ func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, tas
 			status = 'completed',
 			completed_at = NOW(),
 			xp_gained = $4, 
-			coin_gained = $5
+			coin_gained = $5,
+			reward_held = $6
         FROM tasks t
 		WHERE ut.user_id = $1
           AND ut.quest_id = $2
           AND ut.task_id = $3
           AND ut.status = 'active'
           AND t.id = ut.task_id
-		`, userID, questID, taskID, baseXpReward, baseCoinReward)
+		`, userID, questID, taskID, baseXpReward, baseCoinReward, result.RewardHeld)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return tx.Commit()
+	_, err = tx.ExecContext(ctx, `UPDATE users SET last_active_at = NOW() WHERE id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, tx.Commit()
 }
 
 // ----------------------------------------------------
@@ -579,7 +671,7 @@ const checkAnyNotCompletedTasks = `
 		FROM user_tasks
 		WHERE user_id = $1
 		AND quest_id = $2
-		AND status != 'completed'
+		AND (status != 'completed' OR reward_held)
 	)
 `
 
@@ -604,88 +696,131 @@ func (r *QuestRepository) CompleteQuest(ctx context.Context, userID, questID int
 	}
 	// --- конец проверки ---
 
-	// Проверяем: есть ли хоть одна невыполненная задача
-	var hasIncomplete bool
-	err = tx.GetContext(ctx, &hasIncomplete, checkAnyNotCompletedTasks, userID, questID)
-	if err != nil {
-		return err
-	}
-
-	if hasIncomplete {
-		return errors.New("not all tasks completed")
-	}
-
-	// Проверяем, является ли квест совместным
-	var sharedQuestID int
-	var friendID int
-	err = tx.QueryRowContext(ctx,
-		`SELECT id, 
-            CASE WHEN user1_id = $1 THEN user2_id ELSE user1_id END
-        FROM shared_quests 
-        WHERE quest_id = $2
-			AND (user1_id = $1 OR user2_id = $1)
-			AND status = 'active'
-	`, userID, questID).Scan(&sharedQuestID, &friendID)
-
+	// Совместный квест завершается по его правилу сразу для всех участников
+	sharedQuest, err := lockActiveSharedQuest(ctx, tx, userID, questID)
 	if err == nil {
-		// проверяем друга
-		var friendHasIncomplete bool
-		err = tx.GetContext(ctx, &friendHasIncomplete, checkAnyNotCompletedTasks, friendID, questID)
+		members, err := checkSharedQuestCompletion(ctx, tx, sharedQuest)
 		if err != nil {
 			return err
 		}
 
-		if friendHasIncomplete {
-			return errors.New("friend has not completed all tasks")
-		}
-
-		// Оба завершили все задачи! Награждаем обоих и завершаем совместный квест
-		if err := r.completeQuestForUsers(tx, ctx, []int{userID, friendID}, questID); err != nil {
+		if err := r.completeQuestForUsers(tx, ctx, members, questID, sharedQuest); err != nil {
 			return err
 		}
 
-		// Отмечаем совместный квест как завершенный
-		_, err = tx.ExecContext(ctx, `UPDATE shared_quests SET status = 'completed' WHERE id = $1`, sharedQuestID)
+		_, err = tx.ExecContext(ctx, `UPDATE shared_quests SET status = 'completed' WHERE id = $1`, sharedQuest.ID)
 		if err != nil {
 			return err
 		}
+		return tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
 
-	} else {
-		// Обычный квест - награждаем только текущего пользователя
-		if err := r.completeQuestForUsers(tx, ctx, []int{userID}, questID); err != nil {
-			return err
-		}
+	// Обычный квест: проверяем, есть ли хоть одна невыполненная задача
+	var hasIncomplete bool
+	err = tx.GetContext(ctx, &hasIncomplete, checkAnyNotCompletedTasks, userID, questID)
+	if err != nil {
+		return err
+	}
+
+	if hasIncomplete {
+		return errors.New("not all tasks completed")
+	}
+
+	if err := r.completeQuestForUsers(tx, ctx, []int{userID}, questID, nil); err != nil {
+		return err
 	}
 
 	return tx.Commit()
 }
 
-// completeQuestForUsers - упрощенная версия (если сложно с динамическими IN clause)
-func (r *QuestRepository) completeQuestForUsers(tx *sqlx.Tx, ctx context.Context, userIDs []int, questID int) error {
+// completeQuestForUsers - упрощенная версия (если сложно с динамическими IN clause).
+// sharedQuest - совместный квест, по которому завершают участники (nil для обычного квеста).
+func (r *QuestRepository) completeQuestForUsers(tx *sqlx.Tx, ctx context.Context, userIDs []int, questID int, sharedQuest *models.SharedQuest) error {
 	// Получаем награду за квест
 	var rewardXP, rewardCoin int
+	var questTitle, questCategory string
+	var coop models.QuestCoopSettings
 	err := tx.QueryRowContext(ctx, `
-        SELECT reward_xp, reward_coin FROM quests WHERE id = $1`, questID).
-		Scan(&rewardXP, &rewardCoin)
+        SELECT reward_xp, reward_coin, title, category,
+            coop_bonus_percent, coop_bonus_window_hours, coop_partial_reward_percent, coop_fail_penalty_percent
+        FROM quests WHERE id = $1`, questID).
+		Scan(&rewardXP, &rewardCoin, &questTitle, &questCategory,
+			&coop.CoopBonusPercent, &coop.CoopBonusWindowHours, &coop.CoopPartialRewardPercent, &coop.CoopFailPenaltyPercent)
 	if err != nil {
 		return err
 	}
 
+	// Кооперативные механики совместного квеста: процент базовой награды каждого участника
+	var coopPercents map[int]int
+	if sharedQuest != nil {
+		if coopPercents, err = sharedQuestRewardPercents(ctx, tx, sharedQuest, coop); err != nil {
+			return err
+		}
+	}
+
 	// Для каждого пользователя выполняем операции
 	for _, userID := range userIDs {
+		baseXP, baseCoin := rewardXP, rewardCoin
+		if percent, ok := coopPercents[userID]; ok {
+			baseXP, baseCoin = baseXP*percent/100, baseCoin*percent/100
+		}
+
+		// Награда увеличивается бустером пользователя и множителями активных событий
+		userXP, userCoin, err := r.rewardWithBonuses(ctx, tx, userID, baseXP, baseCoin, true)
+		if err != nil {
+			return err
+		}
+
 		// Начисляем награду с автоматическим повышением уровня
-		err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, rewardXP, rewardCoin)
+		err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, userXP, coinEntry(userID, userCoin,
+			models.TransactionTypeEarned, models.ReferenceTypeQuest, questID, "Completed quest: "+questTitle))
 		if err != nil {
 			return err
 		}
 
+		// Опыт и завершенный квест учитываются в таблицах лидеров
+		if err := recordXPHistory(ctx, tx, userID, questID, userXP, 1); err != nil {
+			return err
+		}
+
+		// Прокачиваем ветку категории квеста (не выше attribute_cap)
+		if err := addAttribute(ctx, tx, userID, questCategory, config.Economy().AttributeGainPerQuest); err != nil {
+			return err
+		}
+
+		// Опыт за квест пополняет пул команды пользователя
+		if err := contributeToTeam(ctx, tx, userID, questID, userXP); err != nil {
+			return err
+		}
+
 		// Отмечаем квест как завершенный
 		_, err = tx.ExecContext(ctx, `
             UPDATE user_quests 
             SET status = 'completed', completed_at = NOW(),
                 xp_gained = $1, coin_gained = $2
             WHERE user_id = $3 AND quest_id = $4`,
-			rewardXP, rewardCoin, userID, questID)
+			userXP, userCoin, userID, questID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET quests_completed = quests_completed + 1 WHERE id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		payload := map[string]any{
+			"quest_id":  questID,
+			"title":     questTitle,
+			"xp_gained": userXP,
+		}
+		if percent, ok := coopPercents[userID]; ok {
+			payload["coop_percent"] = percent
+		}
+		err = recordActivity(ctx, tx, userID, models.ActivityQuestCompleted, payload)
 		if err != nil {
 			return err
 		}
@@ -703,5 +838,13 @@ func (r *QuestRepository) completeQuestForUsers(tx *sqlx.Tx, ctx context.Context
 		}
 	}
 
+	// Дуэли на этот квест завершаются сразу; после цикла - чтобы участники
+	// одного совместного квеста, завершившие его одновременно, сыграли вничью
+	for _, userID := range userIDs {
+		if err := r.resolveQuestDuels(ctx, tx, userID, questID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
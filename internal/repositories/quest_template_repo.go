@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"BecomeOverMan/internal/models"
+)
+
+func (r *QuestRepository) CreateQuestTemplate(ctx context.Context, tpl *models.QuestTemplate) (int, error) {
+	paramsJSON, err := json.Marshal(tpl.Params)
+	if err != nil {
+		return 0, err
+	}
+	tasksJSON, err := json.Marshal(tpl.Tasks)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = r.db.GetContext(ctx, &id, `
+		INSERT INTO quest_templates (
+			title, description, category, rarity, difficulty, is_sequential,
+			params_json, tasks_json,
+			price_formula, reward_xp_formula, reward_coin_formula, time_limit_hours_formula,
+			created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb, $9, $10, $11, $12, $13)
+		RETURNING id
+	`, tpl.Title, tpl.Description, tpl.Category, tpl.Rarity, tpl.Difficulty, tpl.IsSequential,
+		string(paramsJSON), string(tasksJSON),
+		tpl.PriceFormula, tpl.RewardXPFormula, tpl.RewardCoinFormula, tpl.TimeLimitHoursFormula,
+		tpl.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *QuestRepository) GetQuestTemplate(ctx context.Context, id int) (*models.QuestTemplate, error) {
+	var row models.QuestTemplateRow
+	if err := r.db.GetContext(ctx, &row, `SELECT * FROM quest_templates WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+
+	return questTemplateFromRow(row)
+}
+
+func (r *QuestRepository) GetQuestTemplates(ctx context.Context) ([]models.QuestTemplate, error) {
+	var rows []models.QuestTemplateRow
+	if err := r.db.SelectContext(ctx, &rows, `SELECT * FROM quest_templates ORDER BY id`); err != nil {
+		return nil, err
+	}
+
+	templates := make([]models.QuestTemplate, 0, len(rows))
+	for _, row := range rows {
+		tpl, err := questTemplateFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tpl)
+	}
+
+	return templates, nil
+}
+
+func questTemplateFromRow(row models.QuestTemplateRow) (*models.QuestTemplate, error) {
+	tpl := &models.QuestTemplate{
+		ID:                    row.ID,
+		Title:                 row.Title,
+		Description:           row.Description,
+		Category:              row.Category,
+		Rarity:                row.Rarity,
+		Difficulty:            row.Difficulty,
+		IsSequential:          row.IsSequential,
+		PriceFormula:          row.PriceFormula,
+		RewardXPFormula:       row.RewardXPFormula,
+		RewardCoinFormula:     row.RewardCoinFormula,
+		TimeLimitHoursFormula: row.TimeLimitHoursFormula,
+		CreatedBy:             row.CreatedBy,
+		CreatedAt:             row.CreatedAt,
+	}
+
+	if row.ParamsJson != nil {
+		if err := json.Unmarshal(*row.ParamsJson, &tpl.Params); err != nil {
+			return nil, err
+		}
+	}
+	if row.TasksJson != nil {
+		if err := json.Unmarshal(*row.TasksJson, &tpl.Tasks); err != nil {
+			return nil, err
+		}
+	}
+
+	return tpl, nil
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrReviewNotAllowed = errors.New("quest can be reviewed only after it is completed or failed")
+)
+
+// questRatingStatsJoin подтягивает агрегаты отзывов к квестам (алиас квеста - q)
+const questRatingStatsJoin = `
+	LEFT JOIN (
+		SELECT quest_id,
+			AVG(rating)::float8            AS avg_rating,
+			AVG(difficulty_rating)::float8 AS avg_difficulty_rating,
+			COUNT(*)                       AS reviews_count
+		FROM quest_reviews
+		GROUP BY quest_id
+	) rs ON rs.quest_id = q.id
+`
+
+// questRatingStatsColumns - колонки агрегатов для SELECT вместе с questRatingStatsJoin
+const questRatingStatsColumns = `
+	rs.avg_rating,
+	rs.avg_difficulty_rating,
+	COALESCE(rs.reviews_count, 0) AS reviews_count
+`
+
+// UpsertQuestReview сохраняет (или обновляет) отзыв пользователя о квесте.
+// Оставить отзыв можно только по завершенному или проваленному (в т.ч. просроченному) квесту.
+func (r *QuestRepository) UpsertQuestReview(ctx context.Context, userID, questID int, req models.CreateQuestReviewRequest) (*models.QuestReview, error) {
+	var canReview bool
+	err := r.db.GetContext(ctx, &canReview, `
+		SELECT EXISTS(
+			SELECT 1 FROM user_quests
+			WHERE user_id = $1 AND quest_id = $2
+			AND (
+				status IN ('completed', 'failed')
+				OR (status = 'started' AND expires_at < NOW())
+			)
+		)`, userID, questID)
+	if err != nil {
+		return nil, err
+	}
+	if !canReview {
+		return nil, ErrReviewNotAllowed
+	}
+
+	var reviewText *string
+	if req.ReviewText != "" {
+		reviewText = &req.ReviewText
+	}
+
+	var review models.QuestReview
+	err = r.db.GetContext(ctx, &review, `
+		INSERT INTO quest_reviews (user_id, quest_id, rating, difficulty_rating, review_text)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, quest_id)
+		DO UPDATE SET
+			rating            = EXCLUDED.rating,
+			difficulty_rating = EXCLUDED.difficulty_rating,
+			review_text       = EXCLUDED.review_text,
+			updated_at        = NOW()
+		RETURNING id, user_id, quest_id, rating, difficulty_rating, review_text, created_at, updated_at
+	`, userID, questID, req.Rating, req.DifficultyRating, reviewText)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *QuestRepository) GetQuestReviews(ctx context.Context, questID, limit, offset int) ([]models.QuestReview, error) {
+	reviews := []models.QuestReview{}
+	err := r.db.SelectContext(ctx, &reviews, `
+		SELECT qr.id, qr.user_id, qr.quest_id, u.username, qr.rating, qr.difficulty_rating,
+		       qr.review_text, qr.created_at, qr.updated_at
+		FROM quest_reviews qr
+		INNER JOIN users u ON u.id = qr.user_id
+		WHERE qr.quest_id = $1
+		ORDER BY qr.updated_at DESC
+		LIMIT $2 OFFSET $3
+	`, questID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (r *QuestRepository) DeleteQuestReview(ctx context.Context, userID, questID int) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM quest_reviews WHERE user_id = $1 AND quest_id = $2
+	`, userID, questID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetQuestRatingStats возвращает агрегаты отзывов для квеста (для Recommendation Service)
+func (r *QuestRepository) GetQuestRatingStats(ctx context.Context, questID int) (*models.Quest, error) {
+	var quest models.Quest
+	err := r.db.GetContext(ctx, &quest, `
+		SELECT q.id, q.title, q.description, q.category, `+questRatingStatsColumns+`
+		FROM quests q
+		`+questRatingStatsJoin+`
+		WHERE q.id = $1
+	`, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quest, nil
+}
+
+// GetUserQuestRatings возвращает оценки пользователя: quest_id -> rating
+func (r *QuestRepository) GetUserQuestRatings(userID int) (map[int]int, error) {
+	var rows []struct {
+		QuestID int `db:"quest_id"`
+		Rating  int `db:"rating"`
+	}
+	if err := r.db.Select(&rows, "SELECT quest_id, rating FROM quest_reviews WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[int]int, len(rows))
+	for _, row := range rows {
+		ratings[row.QuestID] = row.Rating
+	}
+	return ratings, nil
+}
@@ -0,0 +1,1012 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvalidSharedQuest     = errors.New("invalid shared quest settings")
+	ErrSharedInviteExists     = errors.New("shared quest invitation already exists")
+	ErrSharedInviteNotFound   = errors.New("shared quest invitation not found")
+	ErrSharedInviteExpired    = errors.New("shared quest invitation has expired")
+	ErrSharedQuestNotFound    = errors.New("shared quest not found")
+	ErrSharedQuestOwned       = errors.New("one of the users already has this quest")
+	ErrSharedQuestIncomplete  = errors.New("shared quest completion rule is not met yet")
+	ErrSharedQuestOwnerAction = errors.New("owner cannot respond to own shared quest")
+	ErrSharedSeatPending      = errors.New("quest is reserved by a pending shared quest")
+)
+
+const querySharedQuestDetails = `
+	SELECT sq.*, q.title AS quest_title, u.username AS owner_username
+	FROM shared_quests sq
+	JOIN quests q ON q.id = sq.quest_id
+	JOIN users u ON u.id = sq.owner_id
+`
+
+// Участники с прогрессом: выполненные задачи (без удержанных anti-cheat), всего задач квеста
+// и состояние квеста у принявших приглашение
+const querySharedQuestMembers = `
+	SELECT m.shared_quest_id, m.user_id, u.username, m.status, m.paid_by, m.responded_at,
+		COUNT(ut.id) FILTER (WHERE ut.status = 'completed' AND NOT COALESCE(ut.reward_held, FALSE)) AS tasks_completed,
+		(SELECT COUNT(*) FROM quest_tasks qt WHERE qt.quest_id = sq.quest_id) AS tasks_total,
+		uq.status AS quest_status, uq.started_at AS quest_started_at, uq.completed_at AS quest_completed_at
+	FROM shared_quest_members m
+	JOIN shared_quests sq ON sq.id = m.shared_quest_id
+	JOIN users u ON u.id = m.user_id
+	LEFT JOIN user_tasks ut ON ut.user_id = m.user_id AND ut.quest_id = sq.quest_id
+	LEFT JOIN user_quests uq ON uq.user_id = m.user_id AND uq.quest_id = sq.quest_id AND m.status = 'accepted'
+	WHERE m.shared_quest_id = ANY($1)
+	GROUP BY m.shared_quest_id, m.user_id, u.username, m.status, m.paid_by, m.responded_at, m.created_at, sq.quest_id,
+		uq.status, uq.started_at, uq.completed_at
+	ORDER BY m.shared_quest_id, m.created_at, m.user_id
+`
+
+type sharedQuestMemberRow struct {
+	SharedQuestID int `db:"shared_quest_id"`
+	models.SharedQuestMember
+}
+
+// CreateSharedQuest создает групповой квест и приглашает друзей владельца.
+// Владелец сразу оплачивает свое место (и места всех, если pay_for_all) - монеты
+// удерживаются до старта и возвращаются при отказе, отмене или истечении приглашения.
+func (r *QuestRepository) CreateSharedQuest(ctx context.Context, sq *models.SharedQuest, friendIDs []int, ttl time.Duration) (int, error) {
+	if slices.Contains(friendIDs, sq.OwnerID) {
+		return 0, fmt.Errorf("%w: cannot invite yourself", ErrInvalidSharedQuest)
+	}
+	slices.Sort(friendIDs)
+	friendIDs = slices.Compact(friendIDs)
+	if len(friendIDs) == 0 || len(friendIDs) > models.MaxSharedQuestFriends {
+		return 0, fmt.Errorf("%w: invite from 1 to %d friends", ErrInvalidSharedQuest, models.MaxSharedQuestFriends)
+	}
+
+	if sq.CompletionRule == models.SharedQuestRuleQuorum {
+		if sq.Quorum == nil || *sq.Quorum < 1 || *sq.Quorum > len(friendIDs)+1 {
+			return 0, fmt.Errorf("%w: quorum must be between 1 and %d", ErrInvalidSharedQuest, len(friendIDs)+1)
+		}
+	} else {
+		sq.Quorum = nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, friendID := range friendIDs {
+		if err := checkCanShareQuest(ctx, tx, sq.OwnerID, friendID, sq.QuestID); err != nil {
+			return 0, err
+		}
+	}
+
+	participants := append([]int{sq.OwnerID}, friendIDs...)
+	var exists bool
+	err = tx.GetContext(ctx, &exists, `
+		SELECT EXISTS(
+			SELECT 1 FROM shared_quests sq
+			JOIN shared_quest_members m ON m.shared_quest_id = sq.id
+			WHERE sq.quest_id = $1 AND m.user_id = ANY($2)
+			AND m.status IN ('invited', 'accepted')
+			AND (sq.status = 'active' OR (sq.status = 'pending' AND sq.expires_at > NOW()))
+		)`, sq.QuestID, pq.Array(participants))
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, ErrSharedInviteExists
+	}
+
+	var title string
+	err = tx.QueryRowContext(ctx, `SELECT price, title FROM quests WHERE id = $1`, sq.QuestID).Scan(&sq.Price, &title)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.GetContext(ctx, &sq.ID, `
+		INSERT INTO shared_quests (quest_id, owner_id, status, completion_rule, quorum, price, pay_for_all, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW() + $8 * INTERVAL '1 second')
+		RETURNING id`,
+		sq.QuestID, sq.OwnerID, models.SharedQuestStatusPending, sq.CompletionRule, sq.Quorum,
+		sq.Price, sq.PayForAll, int(ttl.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	// Место владельца оплачивается сразу
+	if err := r.paySharedSeat(ctx, tx, sq, sq.OwnerID, sq.OwnerID, title); err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO shared_quest_members (shared_quest_id, user_id, status, paid_by, responded_at)
+		VALUES ($1, $2, $3, $2, NOW())`,
+		sq.ID, sq.OwnerID, models.SharedMemberAccepted)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, friendID := range friendIDs {
+		var paidBy *int
+		if sq.PayForAll {
+			if err := r.paySharedSeat(ctx, tx, sq, friendID, sq.OwnerID, title); err != nil {
+				return 0, err
+			}
+			paidBy = &sq.OwnerID
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO shared_quest_members (shared_quest_id, user_id, status, paid_by)
+			VALUES ($1, $2, $3, $4)`,
+			sq.ID, friendID, models.SharedMemberInvited, paidBy)
+		if err != nil {
+			return 0, err
+		}
+
+		err = notify(ctx, tx, friendID, models.NotificationSharedQuestInvite, map[string]any{
+			"shared_quest_id": sq.ID,
+			"quest_id":        sq.QuestID,
+			"from_user_id":    sq.OwnerID,
+			"pay_for_all":     sq.PayForAll,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return sq.ID, tx.Commit()
+}
+
+// checkCanShareQuest проверяет, что пользователи - друзья без блокировок,
+// квест доступен и ни у кого из них его еще нет
+func checkCanShareQuest(ctx context.Context, tx *sqlx.Tx, ownerID, friendID, questID int) error {
+	blocked, err := isBlocked(ctx, tx, ownerID, friendID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+
+	areFriends, err := areAcceptedFriends(ctx, tx, ownerID, friendID)
+	if err != nil {
+		return err
+	}
+	if !areFriends {
+		return ErrNotFriends
+	}
+
+	if err := checkQuestAvailable(ctx, tx, questID); err != nil {
+		return err
+	}
+
+	var owned bool
+	err = tx.GetContext(ctx, &owned, `
+		SELECT EXISTS(SELECT 1 FROM user_quests WHERE quest_id = $1 AND user_id IN ($2, $3))`,
+		questID, ownerID, friendID)
+	if err != nil {
+		return err
+	}
+	if owned {
+		return ErrSharedQuestOwned
+	}
+	return nil
+}
+
+// hasPendingSharedSeat - ждет ли пользователя место в еще не начавшемся совместном квесте
+// (квест будет выдан при старте, поэтому купить или получить его в подарок нельзя)
+func hasPendingSharedSeat(ctx context.Context, q sqlx.QueryerContext, userID, questID int) (bool, error) {
+	var pending bool
+	err := sqlx.GetContext(ctx, q, &pending, `
+		SELECT EXISTS(
+			SELECT 1 FROM shared_quests sq
+			JOIN shared_quest_members m ON m.shared_quest_id = sq.id
+			WHERE sq.quest_id = $2 AND m.user_id = $1 AND sq.status = $3
+			AND m.status IN ($4, $5)
+		)`, userID, questID, models.SharedQuestStatusPending,
+		models.SharedMemberInvited, models.SharedMemberAccepted)
+	return pending, err
+}
+
+// GetSharedQuestInvites возвращает ожидающие ответа совместные квесты:
+// входящие приглашения пользователя или созданные им самим
+func (r *QuestRepository) GetSharedQuestInvites(ctx context.Context, userID int, incoming bool) ([]models.SharedQuestDetails, error) {
+	invites := []models.SharedQuestDetails{}
+	err := r.db.SelectContext(ctx, &invites, querySharedQuestDetails+`
+		WHERE sq.status = $3 AND sq.expires_at > NOW()
+		AND CASE WHEN $2
+			THEN EXISTS (
+				SELECT 1 FROM shared_quest_members m
+				WHERE m.shared_quest_id = sq.id AND m.user_id = $1 AND m.status = 'invited'
+			)
+			ELSE sq.owner_id = $1
+		END
+		ORDER BY sq.created_at DESC, sq.id DESC`,
+		userID, incoming, models.SharedQuestStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fillSharedQuestMembers(ctx, r.db, invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// GetSharedQuest возвращает совместный квест с прогрессом участников (доступен только участникам)
+func (r *QuestRepository) GetSharedQuest(ctx context.Context, userID, sharedQuestID int) (*models.SharedQuestDetails, error) {
+	var details models.SharedQuestDetails
+	err := r.db.GetContext(ctx, &details, querySharedQuestDetails+`
+		WHERE sq.id = $1 AND EXISTS (
+			SELECT 1 FROM shared_quest_members m
+			WHERE m.shared_quest_id = sq.id AND m.user_id = $2
+		)`, sharedQuestID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSharedQuestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := []models.SharedQuestDetails{details}
+	if err := fillSharedQuestMembers(ctx, r.db, list); err != nil {
+		return nil, err
+	}
+	details = list[0]
+
+	if details.CompletionRule == models.SharedQuestRulePool {
+		var completed int
+		err = r.db.GetContext(ctx, &completed, queryPoolTasksCompleted, details.QuestID, details.ID)
+		if err != nil {
+			return nil, err
+		}
+		details.PoolTasksCompleted = &completed
+	}
+
+	details.TaskCompletions = []models.SharedTaskCompletion{}
+	err = r.db.SelectContext(ctx, &details.TaskCompletions, `
+		SELECT ut.id AS user_task_id, ut.user_id, u.username, ut.task_id, t.title AS task_title, ut.completed_at,
+			`+socialCountsColumns(models.SocialTargetTask, "ut.id", "$3")+`
+		FROM shared_quest_members m
+		JOIN users u ON u.id = m.user_id
+		JOIN user_tasks ut ON ut.user_id = m.user_id AND ut.quest_id = $2
+		JOIN tasks t ON t.id = ut.task_id
+		WHERE m.shared_quest_id = $1 AND m.status = $4
+		AND ut.status = 'completed' AND NOT ut.reward_held
+		ORDER BY ut.completed_at, ut.id
+	`, details.ID, details.QuestID, userID, models.SharedMemberAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	if details.Tasks, err = sharedQuestTasks(ctx, r.db, &details.SharedQuest); err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// sharedQuestTasks собирает задачи квеста со статусами user_tasks каждого принявшего участника
+// (в порядке задач квеста и участников)
+func sharedQuestTasks(ctx context.Context, q sqlx.QueryerContext, sq *models.SharedQuest) ([]models.SharedQuestTask, error) {
+	var rows []struct {
+		TaskID    int    `db:"task_id"`
+		TaskTitle string `db:"task_title"`
+		TaskOrder *int   `db:"task_order"`
+		models.SharedMemberTaskState
+	}
+	err := sqlx.SelectContext(ctx, q, &rows, `
+		SELECT qt.task_id, t.title AS task_title, qt.task_order,
+			m.user_id, ut.id AS user_task_id, COALESCE(ut.status, 'not_started') AS status,
+			COALESCE(ut.reward_held, FALSE) AS reward_held, ut.deadline, ut.completed_at
+		FROM quest_tasks qt
+		JOIN tasks t ON t.id = qt.task_id
+		JOIN shared_quest_members m ON m.shared_quest_id = $1 AND m.status = $3
+		LEFT JOIN user_tasks ut ON ut.user_id = m.user_id AND ut.task_id = qt.task_id AND ut.quest_id = qt.quest_id
+		WHERE qt.quest_id = $2
+		ORDER BY qt.task_order NULLS LAST, qt.id, m.created_at, m.user_id`,
+		sq.ID, sq.QuestID, models.SharedMemberAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.SharedQuestTask{}
+	for _, row := range rows {
+		if n := len(tasks); n == 0 || tasks[n-1].TaskID != row.TaskID {
+			tasks = append(tasks, models.SharedQuestTask{TaskID: row.TaskID, TaskTitle: row.TaskTitle, TaskOrder: row.TaskOrder})
+		}
+		last := &tasks[len(tasks)-1]
+		last.Members = append(last.Members, row.SharedMemberTaskState)
+	}
+	return tasks, nil
+}
+
+// GetSharedQuestHistory возвращает активные и завершенные совместные квесты, в которых участвовал
+// пользователь, вместе с партнерами. status - active, completed или пусто (оба).
+func (r *QuestRepository) GetSharedQuestHistory(ctx context.Context, userID int, status string, limit, offset int) ([]models.SharedQuestDetails, error) {
+	statuses := []string{models.SharedQuestStatusActive, models.SharedQuestStatusCompleted}
+	if status != "" {
+		statuses = []string{status}
+	}
+
+	history := []models.SharedQuestDetails{}
+	err := r.db.SelectContext(ctx, &history, querySharedQuestDetails+`
+		WHERE sq.status = ANY($2) AND EXISTS (
+			SELECT 1 FROM shared_quest_members m
+			WHERE m.shared_quest_id = sq.id AND m.user_id = $1 AND m.status = $3
+		)
+		ORDER BY sq.started_at DESC NULLS LAST, sq.id DESC
+		LIMIT $4 OFFSET $5`,
+		userID, pq.Array(statuses), models.SharedMemberAccepted, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fillSharedQuestMembers(ctx, r.db, history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func fillSharedQuestMembers(ctx context.Context, q sqlx.QueryerContext, list []models.SharedQuestDetails) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(list))
+	for i := range list {
+		ids[i] = list[i].ID
+	}
+
+	var rows []sharedQuestMemberRow
+	if err := sqlx.SelectContext(ctx, q, &rows, querySharedQuestMembers, pq.Array(ids)); err != nil {
+		return err
+	}
+
+	members := make(map[int][]models.SharedQuestMember, len(list))
+	for _, row := range rows {
+		members[row.SharedQuestID] = append(members[row.SharedQuestID], row.SharedQuestMember)
+	}
+	for i := range list {
+		sq := &list[i]
+		sq.Members = members[sq.ID]
+
+		sq.User1ID, sq.PayForBoth = sq.OwnerID, sq.PayForAll
+		if len(sq.Members) == 2 {
+			for _, m := range sq.Members {
+				if m.UserID != sq.OwnerID {
+					sq.User2ID = &m.UserID
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// AcceptSharedQuestInvite принимает приглашение и оплачивает место (если его не оплатил владелец).
+// Когда ответили все приглашенные, квест стартует у всех принявших.
+func (r *QuestRepository) AcceptSharedQuestInvite(ctx context.Context, userID, sharedQuestID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sq, member, err := lockSharedInvite(ctx, tx, sharedQuestID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkCanShareQuest(ctx, tx, sq.OwnerID, userID, sq.QuestID); err != nil {
+		return err
+	}
+
+	if member.PaidBy == nil {
+		var title string
+		if err := tx.GetContext(ctx, &title, `SELECT title FROM quests WHERE id = $1`, sq.QuestID); err != nil {
+			return err
+		}
+		if err := r.paySharedSeat(ctx, tx, sq, userID, userID, title); err != nil {
+			return err
+		}
+		member.PaidBy = &userID
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shared_quest_members SET status = $3, paid_by = $4, responded_at = NOW()
+		WHERE shared_quest_id = $1 AND user_id = $2`,
+		sq.ID, userID, models.SharedMemberAccepted, member.PaidBy)
+	if err != nil {
+		return err
+	}
+
+	err = notify(ctx, tx, sq.OwnerID, models.NotificationSharedQuestAccepted, map[string]any{
+		"shared_quest_id": sq.ID,
+		"quest_id":        sq.QuestID,
+		"user_id":         userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.activateSharedQuestIfReady(ctx, tx, sq, models.SharedQuestStatusDeclined); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeclineSharedQuestInvite отклоняет приглашение, оплаченное владельцем место возвращается ему
+func (r *QuestRepository) DeclineSharedQuestInvite(ctx context.Context, userID, sharedQuestID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sq, _, err := lockSharedInvite(ctx, tx, sharedQuestID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.releaseSharedMembers(ctx, tx, sq, []int{userID}, models.SharedMemberDeclined); err != nil {
+		return err
+	}
+
+	err = notify(ctx, tx, sq.OwnerID, models.NotificationSharedQuestDeclined, map[string]any{
+		"shared_quest_id": sq.ID,
+		"quest_id":        sq.QuestID,
+		"user_id":         userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.activateSharedQuestIfReady(ctx, tx, sq, models.SharedQuestStatusDeclined); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelSharedQuest отменяет еще не начавшийся совместный квест (только владелец), все оплаты возвращаются
+func (r *QuestRepository) CancelSharedQuest(ctx context.Context, userID, sharedQuestID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sq, err := lockPendingSharedQuest(ctx, tx, sharedQuestID)
+	if err != nil {
+		return err
+	}
+	if sq.OwnerID != userID {
+		return ErrSharedInviteNotFound
+	}
+
+	memberIDs, err := sharedQuestMemberIDs(ctx, tx, sq.ID, models.SharedMemberInvited, models.SharedMemberAccepted)
+	if err != nil {
+		return err
+	}
+
+	if err := r.closeSharedQuest(ctx, tx, sq, models.SharedQuestStatusCancelled); err != nil {
+		return err
+	}
+
+	for _, memberID := range memberIDs {
+		if memberID == sq.OwnerID {
+			continue
+		}
+		err = notify(ctx, tx, memberID, models.NotificationSharedQuestCanceled, map[string]any{
+			"shared_quest_id": sq.ID,
+			"quest_id":        sq.QuestID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ExpireSharedQuestInvites закрывает просроченные приглашения.
+// Если кто-то из приглашенных успел принять, квест стартует без опоздавших.
+func (r *QuestRepository) ExpireSharedQuestInvites(ctx context.Context) (int, error) {
+	var ids []int
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT id FROM shared_quests WHERE status = $1 AND expires_at <= NOW()`,
+		models.SharedQuestStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	// Каждый квест в своей транзакции, чтобы ошибка в одном не блокировала остальные
+	expired := 0
+	for _, id := range ids {
+		ok, err := r.expireSharedQuest(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to expire shared quest", "error", err, "shared_quest_id", id)
+			if ok, err = r.closeFailedSharedQuest(ctx, id, err); err != nil {
+				slog.ErrorContext(ctx, "Failed to close shared quest", "error", err, "shared_quest_id", id)
+				continue
+			}
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// sharedQuestStartRetryWindow - сколько после истечения приглашений повторяются попытки
+// стартовать квест, прежде чем он закрывается с возвратом оплаты
+const sharedQuestStartRetryWindow = time.Hour
+
+// closeFailedSharedQuest закрывает просроченный квест, который не удалось стартовать: сразу, если
+// квест больше недоступен, иначе - когда попытки идут дольше sharedQuestStartRetryWindow.
+// Все оплаченные места возвращаются.
+func (r *QuestRepository) closeFailedSharedQuest(ctx context.Context, sharedQuestID int, cause error) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	sq, err := lockPendingSharedQuest(ctx, tx, sharedQuestID)
+	if errors.Is(err, ErrSharedInviteNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	retrying := sq.ExpiresAt != nil && time.Since(*sq.ExpiresAt) < sharedQuestStartRetryWindow
+	if !errors.Is(cause, ErrQuestNotAvailable) && retrying {
+		return false, nil // попробуем при следующем запуске
+	}
+
+	memberIDs, err := sharedQuestMemberIDs(ctx, tx, sq.ID, models.SharedMemberInvited, models.SharedMemberAccepted)
+	if err != nil {
+		return false, err
+	}
+	if err := r.closeSharedQuest(ctx, tx, sq, models.SharedQuestStatusExpired); err != nil {
+		return false, err
+	}
+
+	for _, userID := range memberIDs {
+		err = notify(ctx, tx, userID, models.NotificationSharedQuestCanceled, map[string]any{
+			"shared_quest_id": sq.ID,
+			"quest_id":        sq.QuestID,
+			"reason":          "start_failed",
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+func (r *QuestRepository) expireSharedQuest(ctx context.Context, sharedQuestID int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	sq, err := lockPendingSharedQuest(ctx, tx, sharedQuestID)
+	if errors.Is(err, ErrSharedInviteNotFound) {
+		return false, nil // уже обработан
+	}
+	if err != nil {
+		return false, err
+	}
+
+	invited, err := sharedQuestMemberIDs(ctx, tx, sq.ID, models.SharedMemberInvited)
+	if err != nil {
+		return false, err
+	}
+	if err := r.releaseSharedMembers(ctx, tx, sq, invited, models.SharedMemberExpired); err != nil {
+		return false, err
+	}
+
+	err = notify(ctx, tx, sq.OwnerID, models.NotificationSharedQuestExpired, map[string]any{
+		"shared_quest_id": sq.ID,
+		"quest_id":        sq.QuestID,
+		"user_ids":        invited,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.activateSharedQuestIfReady(ctx, tx, sq, models.SharedQuestStatusExpired); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// lockPendingSharedQuest блокирует совместный квест в статусе pending
+func lockPendingSharedQuest(ctx context.Context, tx *sqlx.Tx, sharedQuestID int) (*models.SharedQuest, error) {
+	var sq models.SharedQuest
+	err := tx.GetContext(ctx, &sq, `
+		SELECT * FROM shared_quests WHERE id = $1 AND status = $2 FOR UPDATE`,
+		sharedQuestID, models.SharedQuestStatusPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSharedInviteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sq, nil
+}
+
+// lockSharedInvite блокирует непросроченное приглашение userID в совместный квест
+func lockSharedInvite(ctx context.Context, tx *sqlx.Tx, sharedQuestID, userID int) (*models.SharedQuest, *models.SharedQuestMember, error) {
+	sq, err := lockPendingSharedQuest(ctx, tx, sharedQuestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sq.OwnerID == userID {
+		return nil, nil, ErrSharedQuestOwnerAction
+	}
+	if sq.ExpiresAt != nil && !sq.ExpiresAt.After(time.Now()) {
+		return nil, nil, ErrSharedInviteExpired
+	}
+
+	var member models.SharedQuestMember
+	err = tx.GetContext(ctx, &member, `
+		SELECT user_id, '' AS username, status, paid_by, responded_at, 0 AS tasks_completed, 0 AS tasks_total
+		FROM shared_quest_members
+		WHERE shared_quest_id = $1 AND user_id = $2 AND status = $3
+		FOR UPDATE`,
+		sharedQuestID, userID, models.SharedMemberInvited)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrSharedInviteNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return sq, &member, nil
+}
+
+func sharedQuestMemberIDs(ctx context.Context, q sqlx.QueryerContext, sharedQuestID int, statuses ...string) ([]int, error) {
+	var ids []int
+	err := sqlx.SelectContext(ctx, q, &ids, `
+		SELECT user_id FROM shared_quest_members
+		WHERE shared_quest_id = $1 AND status = ANY($2)
+		ORDER BY created_at, user_id`,
+		sharedQuestID, pq.Array(statuses))
+	return ids, err
+}
+
+// activateSharedQuestIfReady стартует квест, когда не осталось неотвеченных приглашений
+// и кроме владельца принял хотя бы один участник. Иначе квест закрывается со статусом closeStatus.
+func (r *QuestRepository) activateSharedQuestIfReady(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest, closeStatus string) error {
+	invited, err := sharedQuestMemberIDs(ctx, tx, sq.ID, models.SharedMemberInvited)
+	if err != nil {
+		return err
+	}
+	if len(invited) > 0 {
+		return nil
+	}
+
+	accepted, err := sharedQuestMemberIDs(ctx, tx, sq.ID, models.SharedMemberAccepted)
+	if err != nil {
+		return err
+	}
+
+	// Пока приглашения ждали ответа, квест мог появиться у участника другим путем -
+	// такое место закрывается с возвратом оплаты
+	var owners []int
+	err = tx.SelectContext(ctx, &owners, `
+		SELECT user_id FROM user_quests WHERE quest_id = $1 AND user_id = ANY($2)`,
+		sq.QuestID, pq.Array(accepted))
+	if err != nil {
+		return err
+	}
+	if len(owners) > 0 {
+		if err := r.releaseSharedMembers(ctx, tx, sq, owners, models.SharedMemberWithdrawn); err != nil {
+			return err
+		}
+		for _, userID := range owners {
+			err = notify(ctx, tx, userID, models.NotificationSharedQuestCanceled, map[string]any{
+				"shared_quest_id": sq.ID,
+				"quest_id":        sq.QuestID,
+				"reason":          "quest_already_owned",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		accepted = slices.DeleteFunc(accepted, func(id int) bool { return slices.Contains(owners, id) })
+	}
+
+	if len(accepted) < 2 {
+		return r.closeSharedQuest(ctx, tx, sq, closeStatus)
+	}
+
+	// Кворум не может превышать число оставшихся участников
+	if sq.Quorum != nil && *sq.Quorum > len(accepted) {
+		_, err = tx.ExecContext(ctx, `UPDATE shared_quests SET quorum = $2 WHERE id = $1`, sq.ID, len(accepted))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range accepted {
+		if err := r.startSharedQuestForUser(ctx, tx, userID, sq.QuestID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shared_quests SET status = $2, started_at = NOW() WHERE id = $1`,
+		sq.ID, models.SharedQuestStatusActive)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range accepted {
+		err = notify(ctx, tx, userID, models.NotificationSharedQuestStarted, map[string]any{
+			"shared_quest_id": sq.ID,
+			"quest_id":        sq.QuestID,
+			"member_ids":      accepted,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeSharedQuest закрывает не начавшийся квест и возвращает все оплаченные места
+func (r *QuestRepository) closeSharedQuest(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest, status string) error {
+	memberIDs, err := sharedQuestMemberIDs(ctx, tx, sq.ID, models.SharedMemberInvited, models.SharedMemberAccepted)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range memberIDs {
+		if err := r.refundSharedSeat(ctx, tx, sq, userID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE shared_quests SET status = $2 WHERE id = $1`, sq.ID, status)
+	return err
+}
+
+// releaseSharedMembers переводит участников в итоговый статус (declined/expired) и возвращает оплату их мест
+func (r *QuestRepository) releaseSharedMembers(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest, userIDs []int, status string) error {
+	for _, userID := range userIDs {
+		if err := r.refundSharedSeat(ctx, tx, sq, userID); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE shared_quest_members SET status = $3, responded_at = NOW()
+		WHERE shared_quest_id = $1 AND user_id = ANY($2)`,
+		sq.ID, pq.Array(userIDs), status)
+	return err
+}
+
+// paySharedSeat списывает цену места участника userID с payerID
+func (r *QuestRepository) paySharedSeat(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest, userID, payerID int, title string) error {
+	description := "Shared quest: " + title
+	if payerID != userID {
+		description = "Shared quest for a friend: " + title
+	}
+	return r.ledger.Apply(ctx, tx, coinEntry(payerID, -sq.Price,
+		models.TransactionTypeSpent, models.ReferenceTypeQuest, sq.QuestID, description))
+}
+
+// refundSharedSeat возвращает оплату места участника тому, кто за него заплатил
+func (r *QuestRepository) refundSharedSeat(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest, userID int) error {
+	var paidBy *int
+	err := tx.GetContext(ctx, &paidBy, `
+		SELECT paid_by FROM shared_quest_members WHERE shared_quest_id = $1 AND user_id = $2`,
+		sq.ID, userID)
+	if err != nil {
+		return err
+	}
+	if paidBy == nil {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shared_quest_members SET paid_by = NULL WHERE shared_quest_id = $1 AND user_id = $2`,
+		sq.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	return r.ledger.Apply(ctx, tx, coinEntry(*paidBy, sq.Price,
+		models.TransactionTypeEarned, models.ReferenceTypeQuest, sq.QuestID, "Shared quest refund"))
+}
+
+// startSharedQuestForUser выдает участнику квест (место уже оплачено) и сразу стартует его
+func (r *QuestRepository) startSharedQuestForUser(ctx context.Context, tx *sqlx.Tx, userID, questID int) error {
+	if err := r.grantQuestToUser(ctx, tx, userID, questID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE user_quests
+		SET status = 'started', started_at = NOW(), expires_at = (
+			SELECT NOW() + (time_limit_hours || ' hours')::interval
+			FROM quests WHERE id = $2
+		)
+		WHERE user_id = $1 AND quest_id = $2`,
+		userID, questID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_tasks
+		SET status = 'active'
+		WHERE user_id = $1 AND quest_id = $2 AND status = 'not_started'
+	`, userID, questID)
+	return err
+}
+
+// Задачи квеста, выполненные хотя бы одним принявшим участником (правило pool)
+const queryPoolTasksCompleted = `
+	SELECT COUNT(*) FROM quest_tasks qt
+	WHERE qt.quest_id = $1 AND EXISTS (
+		SELECT 1 FROM user_tasks ut
+		JOIN shared_quest_members m ON m.user_id = ut.user_id
+			AND m.shared_quest_id = $2 AND m.status = 'accepted'
+		WHERE ut.task_id = qt.task_id AND ut.quest_id = qt.quest_id
+		AND ut.status = 'completed' AND NOT COALESCE(ut.reward_held, FALSE)
+	)
+`
+
+// lockActiveSharedQuest находит активный совместный квест, в котором участвует пользователь
+func lockActiveSharedQuest(ctx context.Context, tx *sqlx.Tx, userID, questID int) (*models.SharedQuest, error) {
+	var sq models.SharedQuest
+	err := tx.GetContext(ctx, &sq, `
+		SELECT sq.* FROM shared_quests sq
+		JOIN shared_quest_members m ON m.shared_quest_id = sq.id
+		WHERE sq.quest_id = $2 AND m.user_id = $1
+		AND m.status = 'accepted' AND sq.status = 'active'
+		FOR UPDATE OF sq`,
+		userID, questID)
+	if err != nil {
+		return nil, err
+	}
+	return &sq, nil
+}
+
+// checkSharedQuestCompletion проверяет правило завершения совместного квеста
+// для участников, у которых квест еще в процессе. Возвращает этих участников.
+func checkSharedQuestCompletion(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest) ([]int, error) {
+	var members []int
+	err := tx.SelectContext(ctx, &members, `
+		SELECT m.user_id FROM shared_quest_members m
+		JOIN user_quests uq ON uq.user_id = m.user_id AND uq.quest_id = $2
+		WHERE m.shared_quest_id = $1 AND m.status = 'accepted' AND uq.status = 'started'
+		ORDER BY m.user_id`,
+		sq.ID, sq.QuestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sq.CompletionRule == models.SharedQuestRulePool {
+		var completed, total int
+		if err := tx.GetContext(ctx, &completed, queryPoolTasksCompleted, sq.QuestID, sq.ID); err != nil {
+			return nil, err
+		}
+		if err := tx.GetContext(ctx, &total, `SELECT COUNT(*) FROM quest_tasks WHERE quest_id = $1`, sq.QuestID); err != nil {
+			return nil, err
+		}
+		if completed < total {
+			return nil, fmt.Errorf("%w: group completed %d of %d tasks", ErrSharedQuestIncomplete, completed, total)
+		}
+		return members, nil
+	}
+
+	var finished int
+	err = tx.GetContext(ctx, &finished, `
+		SELECT COUNT(*) FROM unnest($1::int[]) AS m(user_id)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM user_tasks ut
+			WHERE ut.user_id = m.user_id AND ut.quest_id = $2
+			AND (ut.status != 'completed' OR ut.reward_held)
+		)`,
+		pq.Array(members), sq.QuestID)
+	if err != nil {
+		return nil, err
+	}
+
+	required := len(members)
+	if sq.CompletionRule == models.SharedQuestRuleQuorum && sq.Quorum != nil {
+		required = min(*sq.Quorum, len(members))
+	}
+	if finished < required {
+		return nil, fmt.Errorf("%w: %d of %d required members have completed all tasks", ErrSharedQuestIncomplete, finished, required)
+	}
+	return members, nil
+}
+
+// sharedQuestMemberResult - итог участника совместного квеста для кооперативных механик
+type sharedQuestMemberResult struct {
+	UserID     int        `db:"user_id"`
+	Finished   bool       `db:"finished"`
+	FinishedAt *time.Time `db:"finished_at"`
+}
+
+// sharedQuestRewardPercents применяет кооперативные механики квеста и возвращает процент
+// базовой награды для каждого принявшего участника (см. coopRewardPercents).
+func sharedQuestRewardPercents(ctx context.Context, tx *sqlx.Tx, sq *models.SharedQuest, coop models.QuestCoopSettings) (map[int]int, error) {
+	var members []sharedQuestMemberResult
+	err := tx.SelectContext(ctx, &members, `
+		SELECT m.user_id,
+			COALESCE(uq.status = 'started', FALSE) AND NOT EXISTS (
+				SELECT 1 FROM user_tasks ut
+				WHERE ut.user_id = m.user_id AND ut.quest_id = $2
+				AND (ut.status != 'completed' OR ut.reward_held)
+			) AS finished,
+			(
+				SELECT MAX(ut.completed_at) FROM user_tasks ut
+				WHERE ut.user_id = m.user_id AND ut.quest_id = $2
+			) AS finished_at
+		FROM shared_quest_members m
+		LEFT JOIN user_quests uq ON uq.user_id = m.user_id AND uq.quest_id = $2
+		WHERE m.shared_quest_id = $1 AND m.status = $3`,
+		sq.ID, sq.QuestID, models.SharedMemberAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	return coopRewardPercents(members, coop), nil
+}
+
+// coopRewardPercents считает процент базовой награды для каждого участника:
+//   - все выполнили задачи в пределах coop_bonus_window_hours - coop_bonus_percent всем;
+//   - кто-то не справился (не выполнил все задачи или провалил квест по времени) -
+//     выполнившим 100 - coop_fail_penalty_percent, остальным coop_partial_reward_percent.
+func coopRewardPercents(members []sharedQuestMemberResult, coop models.QuestCoopSettings) map[int]int {
+	allFinished := true
+	var first, last time.Time
+	for _, m := range members {
+		if !m.Finished {
+			allFinished = false
+			continue
+		}
+		if m.FinishedAt == nil {
+			continue
+		}
+		if first.IsZero() || m.FinishedAt.Before(first) {
+			first = *m.FinishedAt
+		}
+		if m.FinishedAt.After(last) {
+			last = *m.FinishedAt
+		}
+	}
+
+	percents := make(map[int]int, len(members))
+	for _, m := range members {
+		switch {
+		case allFinished:
+			percents[m.UserID] = 100
+			window := time.Duration(coop.CoopBonusWindowHours) * time.Hour
+			if coop.CoopBonusWindowHours == 0 || last.Sub(first) <= window {
+				percents[m.UserID] = coop.CoopBonusPercent
+			}
+		case m.Finished:
+			percents[m.UserID] = 100 - coop.CoopFailPenaltyPercent
+		default:
+			percents[m.UserID] = coop.CoopPartialRewardPercent
+		}
+	}
+	return percents
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"BecomeOverMan/internal/models"
+)
+
+func TestCoopRewardPercents(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := base.Add(d)
+		return &v
+	}
+
+	coop := models.QuestCoopSettings{
+		CoopBonusPercent:         150,
+		CoopBonusWindowHours:     24,
+		CoopPartialRewardPercent: 30,
+		CoopFailPenaltyPercent:   20,
+	}
+
+	tests := []struct {
+		name    string
+		coop    models.QuestCoopSettings
+		members []sharedQuestMemberResult
+		want    map[int]int
+	}{
+		{
+			name: "all finished within window get bonus",
+			coop: coop,
+			members: []sharedQuestMemberResult{
+				{UserID: 1, Finished: true, FinishedAt: at(0)},
+				{UserID: 2, Finished: true, FinishedAt: at(24 * time.Hour)},
+			},
+			want: map[int]int{1: 150, 2: 150},
+		},
+		{
+			name: "all finished outside window get base reward",
+			coop: coop,
+			members: []sharedQuestMemberResult{
+				{UserID: 1, Finished: true, FinishedAt: at(0)},
+				{UserID: 2, Finished: true, FinishedAt: at(24*time.Hour + time.Minute)},
+				{UserID: 3, Finished: true, FinishedAt: at(time.Hour)},
+			},
+			want: map[int]int{1: 100, 2: 100, 3: 100},
+		},
+		{
+			name: "zero window means no time limit for bonus",
+			coop: models.QuestCoopSettings{CoopBonusPercent: 120, CoopPartialRewardPercent: 100},
+			members: []sharedQuestMemberResult{
+				{UserID: 1, Finished: true, FinishedAt: at(0)},
+				{UserID: 2, Finished: true, FinishedAt: at(30 * 24 * time.Hour)},
+			},
+			want: map[int]int{1: 120, 2: 120},
+		},
+		{
+			name: "finished without completion time still counts for bonus",
+			coop: coop,
+			members: []sharedQuestMemberResult{
+				{UserID: 1, Finished: true},
+				{UserID: 2, Finished: true, FinishedAt: at(time.Hour)},
+			},
+			want: map[int]int{1: 150, 2: 150},
+		},
+		{
+			name: "partner failed: penalty for finished, partial for the rest",
+			coop: coop,
+			members: []sharedQuestMemberResult{
+				{UserID: 1, Finished: true, FinishedAt: at(0)},
+				{UserID: 2, Finished: false, FinishedAt: at(time.Hour)},
+				{UserID: 3, Finished: false},
+			},
+			want: map[int]int{1: 80, 2: 30, 3: 30},
+		},
+		{
+			name: "defaults keep everyone at base reward",
+			coop: models.DefaultQuestCoopSettings(),
+			members: []sharedQuestMemberResult{
+				{UserID: 1, Finished: true, FinishedAt: at(0)},
+				{UserID: 2, Finished: false},
+			},
+			want: map[int]int{1: 100, 2: 100},
+		},
+		{
+			name:    "no members",
+			coop:    coop,
+			members: nil,
+			want:    map[int]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := coopRewardPercents(tt.members, tt.coop)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("coopRewardPercents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrSocialTargetNotFound = errors.New("объект не найден или недоступен")
+	ErrReactionNotFound     = errors.New("реакция не найдена")
+	ErrCommentNotFound      = errors.New("комментарий не найден")
+	ErrCommentPermission    = errors.New("удалить комментарий может только его автор или владелец объекта")
+)
+
+// socialCountsColumns - колонки models.SocialCounts для объекта targetType с id в idColumn;
+// userParam - плейсхолдер текущего пользователя (для my_reactions)
+func socialCountsColumns(targetType, idColumn, userParam string) string {
+	return fmt.Sprintf(`
+		(SELECT COUNT(*) FROM social_reactions sr
+			WHERE sr.target_type = '%[1]s' AND sr.target_id = %[2]s AND sr.kind = '%[4]s') AS kudos_count,
+		(SELECT COUNT(*) FROM social_reactions sr
+			WHERE sr.target_type = '%[1]s' AND sr.target_id = %[2]s AND sr.kind = '%[5]s') AS cheer_count,
+		(SELECT COUNT(*) FROM social_comments sc
+			WHERE sc.target_type = '%[1]s' AND sc.target_id = %[2]s) AS comments_count,
+		ARRAY(SELECT sr.kind FROM social_reactions sr
+			WHERE sr.target_type = '%[1]s' AND sr.target_id = %[2]s AND sr.user_id = %[3]s
+			ORDER BY sr.kind) AS my_reactions`,
+		targetType, idColumn, userParam, models.ReactionKudos, models.ReactionCheer)
+}
+
+// socialTargetOwner возвращает автора объекта, если он доступен пользователю:
+// свое событие или событие друга, открытое его настройками приватности;
+// выполненная задача своя или участника того же совместного квеста.
+func socialTargetOwner(ctx context.Context, q sqlx.QueryerContext, userID int, targetType string, targetID int) (int, error) {
+	var ownerID int
+	var err error
+	switch targetType {
+	case models.SocialTargetActivity:
+		err = sqlx.GetContext(ctx, q, &ownerID, `
+			SELECT a.user_id
+			FROM activity_events a
+			LEFT JOIN user_privacy_settings p ON p.user_id = a.user_id
+			WHERE a.id = $1 AND (a.user_id = $2 OR (
+				EXISTS (
+					SELECT 1 FROM friends
+					WHERE ((user_id = $2 AND friend_id = a.user_id) OR (user_id = a.user_id AND friend_id = $2))
+					AND status = 'accepted'
+				)
+				AND `+activityVisibleToFriends+`
+			))`, targetID, userID)
+	case models.SocialTargetTask:
+		err = sqlx.GetContext(ctx, q, &ownerID, `
+			SELECT ut.user_id
+			FROM user_tasks ut
+			WHERE ut.id = $1 AND ut.status = 'completed' AND NOT ut.reward_held
+			AND (ut.user_id = $2 OR EXISTS (
+				SELECT 1
+				FROM shared_quests sq
+				JOIN shared_quest_members owner ON owner.shared_quest_id = sq.id AND owner.user_id = ut.user_id
+				JOIN shared_quest_members viewer ON viewer.shared_quest_id = sq.id AND viewer.user_id = $2
+				WHERE sq.quest_id = ut.quest_id
+				AND sq.status IN ('active', 'completed')
+				AND owner.status = 'accepted' AND viewer.status = 'accepted'
+			))`, targetID, userID)
+	default:
+		return 0, ErrSocialTargetNotFound
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrSocialTargetNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if ownerID != userID {
+		blocked, err := isBlocked(ctx, q, userID, ownerID)
+		if err != nil {
+			return 0, err
+		}
+		if blocked {
+			return 0, ErrSocialTargetNotFound
+		}
+	}
+	return ownerID, nil
+}
+
+// AddReaction ставит реакцию (повторная реакция того же вида ничего не меняет) и уведомляет автора
+func (r *QuestRepository) AddReaction(ctx context.Context, userID int, targetType string, targetID int, kind string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ownerID, err := socialTargetOwner(ctx, tx, userID, targetType, targetID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO social_reactions (target_type, target_id, user_id, kind) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (target_type, target_id, user_id, kind) DO NOTHING`,
+		targetType, targetID, userID, kind)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n > 0 && ownerID != userID {
+		err = notify(ctx, tx, ownerID, models.NotificationReaction, map[string]any{
+			"target_type": targetType,
+			"target_id":   targetID,
+			"user_id":     userID,
+			"kind":        kind,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveReaction снимает свою реакцию
+func (r *QuestRepository) RemoveReaction(ctx context.Context, userID int, targetType string, targetID int, kind string) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM social_reactions
+		WHERE target_type = $1 AND target_id = $2 AND user_id = $3 AND kind = $4`,
+		targetType, targetID, userID, kind)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReactionNotFound
+	}
+	return nil
+}
+
+const querySocialComments = `
+	SELECT c.id, c.target_type, c.target_id, c.user_id, u.username, c.body, c.created_at
+	FROM social_comments c
+	JOIN users u ON u.id = c.user_id
+`
+
+// GetComments возвращает комментарии к объекту, старые сначала
+func (r *QuestRepository) GetComments(ctx context.Context, userID int, targetType string, targetID, limit, offset int) ([]models.SocialComment, error) {
+	if _, err := socialTargetOwner(ctx, r.db, userID, targetType, targetID); err != nil {
+		return nil, err
+	}
+
+	comments := []models.SocialComment{}
+	err := r.db.SelectContext(ctx, &comments, querySocialComments+`
+		WHERE c.target_type = $1 AND c.target_id = $2
+		ORDER BY c.created_at, c.id
+		LIMIT $3 OFFSET $4`,
+		targetType, targetID, limit, offset)
+	return comments, err
+}
+
+// AddComment добавляет комментарий и уведомляет автора объекта
+func (r *QuestRepository) AddComment(ctx context.Context, userID int, targetType string, targetID int, body string) (*models.SocialComment, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ownerID, err := socialTargetOwner(ctx, tx, userID, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	var commentID int
+	err = tx.GetContext(ctx, &commentID, `
+		INSERT INTO social_comments (target_type, target_id, user_id, body) VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		targetType, targetID, userID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if ownerID != userID {
+		err = notify(ctx, tx, ownerID, models.NotificationComment, map[string]any{
+			"target_type": targetType,
+			"target_id":   targetID,
+			"comment_id":  commentID,
+			"user_id":     userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var comment models.SocialComment
+	if err := tx.GetContext(ctx, &comment, querySocialComments+`WHERE c.id = $1`, commentID); err != nil {
+		return nil, err
+	}
+
+	return &comment, tx.Commit()
+}
+
+// DeleteComment удаляет комментарий: свой или любой под своим объектом (модерация автором объекта)
+func (r *QuestRepository) DeleteComment(ctx context.Context, userID, commentID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var comment models.SocialComment
+	err = tx.GetContext(ctx, &comment, querySocialComments+`WHERE c.id = $1 FOR UPDATE OF c`, commentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		ownerID, err := socialTargetOwner(ctx, tx, userID, comment.TargetType, comment.TargetID)
+		if errors.Is(err, ErrSocialTargetNotFound) {
+			return ErrCommentPermission
+		}
+		if err != nil {
+			return err
+		}
+		if ownerID != userID {
+			return ErrCommentPermission
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM social_comments WHERE id = $1`, commentID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ModerateDeleteComment - удаление комментария администратором
+func (r *QuestRepository) ModerateDeleteComment(ctx context.Context, commentID int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM social_comments WHERE id = $1`, commentID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
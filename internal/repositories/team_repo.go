@@ -0,0 +1,644 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTeamNotFound       = errors.New("team not found")
+	ErrTeamNameTaken      = errors.New("team name is already taken")
+	ErrTeamFull           = errors.New("team is full")
+	ErrAlreadyInTeam      = errors.New("user is already in a team")
+	ErrNotTeamMember      = errors.New("user is not a member of this team")
+	ErrTeamPermission     = errors.New("insufficient team role")
+	ErrInvalidInviteCode  = errors.New("invalid invite code")
+	ErrTeamQuestExists    = errors.New("quest is already a team quest")
+	ErrTeamQuestNotFound  = errors.New("team quest not found")
+	ErrTeamOwnerSelfApply = errors.New("owner cannot change own role or remove themselves")
+)
+
+const queryTeams = `
+	SELECT t.*, (SELECT COUNT(*) FROM team_members m WHERE m.team_id = t.id) AS members_count
+	FROM teams t
+`
+
+// Зачисляет достижения, пороги которых команда уже достигла, и возвращает только что открытые
+const queryUnlockTeamAchievements = `
+	WITH metrics AS (
+		SELECT t.xp_pool, t.quests_completed,
+			(SELECT COUNT(*) FROM team_members WHERE team_id = t.id) AS members,
+			(SELECT COUNT(*) FROM team_quests WHERE team_id = t.id AND completed_at IS NOT NULL) AS team_quests_completed
+		FROM teams t WHERE t.id = $1
+	), unlocked AS (
+		INSERT INTO team_unlocked_achievements (team_id, achievement_id)
+		SELECT $1, a.id FROM team_achievements a, metrics m
+		WHERE CASE a.metric
+			WHEN 'xp_pool' THEN m.xp_pool
+			WHEN 'quests_completed' THEN m.quests_completed
+			WHEN 'members' THEN m.members
+			WHEN 'team_quests_completed' THEN m.team_quests_completed
+			ELSE 0
+		END >= a.threshold
+		ON CONFLICT DO NOTHING
+		RETURNING achievement_id
+	)
+	SELECT a.code, a.name FROM unlocked u JOIN team_achievements a ON a.id = u.achievement_id
+`
+
+func generateInviteCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// CreateTeam создает команду, создатель становится ее владельцем
+func (r *QuestRepository) CreateTeam(ctx context.Context, ownerID int, name, description string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, _, err := teamMembership(ctx, tx, ownerID); err == nil {
+		return 0, ErrAlreadyInTeam
+	} else if !errors.Is(err, ErrNotTeamMember) {
+		return 0, err
+	}
+
+	var taken bool
+	err = tx.GetContext(ctx, &taken, `SELECT EXISTS(SELECT 1 FROM teams WHERE LOWER(name) = LOWER($1))`, name)
+	if err != nil {
+		return 0, err
+	}
+	if taken {
+		return 0, ErrTeamNameTaken
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return 0, err
+	}
+
+	var teamID int
+	err = tx.GetContext(ctx, &teamID, `
+		INSERT INTO teams (name, description, owner_id, invite_code)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		name, description, ownerID, code)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)`,
+		teamID, ownerID, models.TeamRoleOwner)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := unlockTeamAchievements(ctx, tx, teamID, ownerID); err != nil {
+		return 0, err
+	}
+
+	return teamID, tx.Commit()
+}
+
+// teamMembership возвращает команду и роль пользователя
+func teamMembership(ctx context.Context, q sqlx.QueryerContext, userID int) (int, string, error) {
+	var m struct {
+		TeamID int    `db:"team_id"`
+		Role   string `db:"role"`
+	}
+	err := sqlx.GetContext(ctx, q, &m, `SELECT team_id, role FROM team_members WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrNotTeamMember
+	}
+	return m.TeamID, m.Role, err
+}
+
+// teamRole возвращает роль пользователя в конкретной команде
+func teamRole(ctx context.Context, q sqlx.QueryerContext, teamID, userID int) (string, error) {
+	memberTeamID, role, err := teamMembership(ctx, q, userID)
+	if err != nil {
+		return "", err
+	}
+	if memberTeamID != teamID {
+		return "", ErrNotTeamMember
+	}
+	return role, nil
+}
+
+// lockTeam блокирует команду и проверяет, что у пользователя одна из ролей roles (пусто - любая роль)
+func lockTeam(ctx context.Context, tx *sqlx.Tx, teamID, userID int, roles ...string) (string, error) {
+	var exists bool
+	err := tx.GetContext(ctx, &exists, `SELECT TRUE FROM teams WHERE id = $1 FOR UPDATE`, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrTeamNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	role, err := teamRole(ctx, tx, teamID, userID)
+	if err != nil {
+		return "", err
+	}
+	if len(roles) > 0 && !slices.Contains(roles, role) {
+		return "", ErrTeamPermission
+	}
+	return role, nil
+}
+
+// GetTeam возвращает команду с достижениями. Код приглашения виден только владельцу и офицерам.
+func (r *QuestRepository) GetTeam(ctx context.Context, teamID, viewerID int) (*models.TeamDetails, error) {
+	var details models.TeamDetails
+	err := r.db.GetContext(ctx, &details.Team, queryTeams+` WHERE t.id = $1`, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := teamRole(ctx, r.db, teamID, viewerID)
+	if err != nil && !errors.Is(err, ErrNotTeamMember) {
+		return nil, err
+	}
+	details.MyRole = role
+	if role != models.TeamRoleOwner && role != models.TeamRoleOfficer {
+		details.InviteCode = nil
+	}
+
+	details.Achievements = []models.TeamAchievement{}
+	err = r.db.SelectContext(ctx, &details.Achievements, `
+		SELECT a.*, u.unlocked_at
+		FROM team_achievements a
+		LEFT JOIN team_unlocked_achievements u ON u.achievement_id = a.id AND u.team_id = $1
+		ORDER BY u.unlocked_at IS NULL, u.unlocked_at, a.metric, a.threshold`,
+		teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
+// GetMyTeam возвращает команду пользователя
+func (r *QuestRepository) GetMyTeam(ctx context.Context, userID int) (*models.TeamDetails, error) {
+	teamID, _, err := teamMembership(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetTeam(ctx, teamID, userID)
+}
+
+// JoinTeam добавляет пользователя в команду по коду приглашения
+func (r *QuestRepository) JoinTeam(ctx context.Context, userID int, inviteCode string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var team struct {
+		ID      int `db:"id"`
+		OwnerID int `db:"owner_id"`
+	}
+	err = tx.GetContext(ctx, &team, `
+		SELECT id, owner_id FROM teams WHERE invite_code = UPPER($1) FOR UPDATE`,
+		strings.TrimSpace(inviteCode))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidInviteCode
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, _, err := teamMembership(ctx, tx, userID); err == nil {
+		return 0, ErrAlreadyInTeam
+	} else if !errors.Is(err, ErrNotTeamMember) {
+		return 0, err
+	}
+
+	var members int
+	if err := tx.GetContext(ctx, &members, `SELECT COUNT(*) FROM team_members WHERE team_id = $1`, team.ID); err != nil {
+		return 0, err
+	}
+	if members >= config.Economy().Teams.MaxMembers {
+		return 0, ErrTeamFull
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)`,
+		team.ID, userID, models.TeamRoleMember)
+	if err != nil {
+		return 0, err
+	}
+
+	err = notify(ctx, tx, team.OwnerID, models.NotificationTeamMemberJoined, map[string]any{
+		"team_id": team.ID,
+		"user_id": userID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := unlockTeamAchievements(ctx, tx, team.ID, userID); err != nil {
+		return 0, err
+	}
+
+	return team.ID, tx.Commit()
+}
+
+// LeaveTeam выводит пользователя из команды. Если уходит владелец, владение переходит
+// самому давнему офицеру (или участнику), а опустевшая команда удаляется.
+func (r *QuestRepository) LeaveTeam(ctx context.Context, userID, teamID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	role, err := lockTeam(ctx, tx, teamID, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role != models.TeamRoleOwner {
+		return tx.Commit()
+	}
+
+	var successorID int
+	err = tx.GetContext(ctx, &successorID, `
+		SELECT user_id FROM team_members WHERE team_id = $1
+		ORDER BY role = 'officer' DESC, joined_at, user_id
+		LIMIT 1`, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, teamID)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE teams SET owner_id = $2 WHERE id = $1`, teamID, successorID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE team_members SET role = $3 WHERE team_id = $1 AND user_id = $2`,
+		teamID, successorID, models.TeamRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *QuestRepository) GetTeamMembers(ctx context.Context, teamID int) ([]models.TeamMember, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM teams WHERE id = $1)`, teamID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	members := []models.TeamMember{}
+	err := r.db.SelectContext(ctx, &members, `
+		SELECT m.user_id, u.username, m.role, m.xp_contributed, m.joined_at
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'officer' THEN 1 ELSE 2 END, m.joined_at`,
+		teamID)
+	return members, err
+}
+
+// SetTeamMemberRole назначает участника офицером или возвращает в участники (только владелец)
+func (r *QuestRepository) SetTeamMemberRole(ctx context.Context, actorID, teamID, userID int, role string) error {
+	if actorID == userID {
+		return ErrTeamOwnerSelfApply
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTeam(ctx, tx, teamID, actorID, models.TeamRoleOwner); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE team_members SET role = $3 WHERE team_id = $1 AND user_id = $2`,
+		teamID, userID, role)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNotTeamMember
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTeamMember исключает участника: владелец - любого, офицер - только обычных участников
+func (r *QuestRepository) RemoveTeamMember(ctx context.Context, actorID, teamID, userID int) error {
+	if actorID == userID {
+		return ErrTeamOwnerSelfApply
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	actorRole, err := lockTeam(ctx, tx, teamID, actorID, models.TeamRoleOwner, models.TeamRoleOfficer)
+	if err != nil {
+		return err
+	}
+
+	targetRole, err := teamRole(ctx, tx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if targetRole == models.TeamRoleOwner || (actorRole == models.TeamRoleOfficer && targetRole != models.TeamRoleMember) {
+		return ErrTeamPermission
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = notify(ctx, tx, userID, models.NotificationTeamRemoved, map[string]any{"team_id": teamID})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RegenerateTeamInviteCode выдает новый код приглашения, старый перестает работать
+func (r *QuestRepository) RegenerateTeamInviteCode(ctx context.Context, actorID, teamID int) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTeam(ctx, tx, teamID, actorID, models.TeamRoleOwner, models.TeamRoleOfficer); err != nil {
+		return "", err
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE teams SET invite_code = $2 WHERE id = $1`, teamID, code); err != nil {
+		return "", err
+	}
+
+	return code, tx.Commit()
+}
+
+func (r *QuestRepository) GetTeamQuests(ctx context.Context, teamID int) ([]models.TeamQuest, error) {
+	quests := []models.TeamQuest{}
+	err := r.db.SelectContext(ctx, &quests, `
+		SELECT tq.quest_id, q.title AS quest_title, tq.target_completions, tq.completions,
+			tq.added_by, tq.created_at, tq.completed_at
+		FROM team_quests tq
+		JOIN quests q ON q.id = tq.quest_id
+		WHERE tq.team_id = $1
+		ORDER BY tq.completed_at IS NOT NULL, tq.created_at DESC`,
+		teamID)
+	return quests, err
+}
+
+// AddTeamQuest делает квест командным: засчитываются завершения участниками после добавления
+func (r *QuestRepository) AddTeamQuest(ctx context.Context, actorID, teamID, questID, targetCompletions int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTeam(ctx, tx, teamID, actorID, models.TeamRoleOwner, models.TeamRoleOfficer); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO team_quests (team_id, quest_id, target_completions, added_by)
+		SELECT $1, q.id, $3, $4 FROM quests q WHERE q.id = $2
+		ON CONFLICT DO NOTHING`,
+		teamID, questID, targetCompletions, actorID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		var questExists bool
+		if err := tx.GetContext(ctx, &questExists, `SELECT EXISTS(SELECT 1 FROM quests WHERE id = $1)`, questID); err != nil {
+			return err
+		}
+		if !questExists {
+			return sql.ErrNoRows
+		}
+		return ErrTeamQuestExists
+	}
+
+	return tx.Commit()
+}
+
+func (r *QuestRepository) RemoveTeamQuest(ctx context.Context, actorID, teamID, questID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTeam(ctx, tx, teamID, actorID, models.TeamRoleOwner, models.TeamRoleOfficer); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM team_quests WHERE team_id = $1 AND quest_id = $2`, teamID, questID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrTeamQuestNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetTeamProgress собирает сводку команды по user_quests и user_tasks участников
+// (учитывается только активность после вступления в команду). Доступна только участникам.
+func (r *QuestRepository) GetTeamProgress(ctx context.Context, teamID, viewerID int) (*models.TeamProgress, error) {
+	var xpPool int64
+	err := r.db.GetContext(ctx, &xpPool, `SELECT xp_pool FROM teams WHERE id = $1`, teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := teamRole(ctx, r.db, teamID, viewerID); err != nil {
+		return nil, err
+	}
+
+	progress := &models.TeamProgress{TeamID: teamID, XPPool: xpPool, Members: []models.TeamMemberProgress{}}
+	err = r.db.SelectContext(ctx, &progress.Members, `
+		SELECT m.user_id, u.username, m.role, m.xp_contributed,
+			(SELECT COUNT(*) FROM user_quests uq
+				WHERE uq.user_id = m.user_id AND uq.status = 'completed' AND uq.completed_at >= m.joined_at) AS quests_completed,
+			(SELECT COUNT(*) FROM user_quests uq
+				WHERE uq.user_id = m.user_id AND uq.status = 'started') AS quests_active,
+			(SELECT COUNT(*) FROM user_tasks ut
+				WHERE ut.user_id = m.user_id AND ut.status = 'completed' AND NOT ut.reward_held
+				AND ut.completed_at >= m.joined_at) AS tasks_completed,
+			(SELECT COUNT(*) FROM user_tasks ut
+				WHERE ut.user_id = m.user_id AND ut.status = 'completed' AND NOT ut.reward_held
+				AND ut.completed_at >= GREATEST(m.joined_at, NOW() - INTERVAL '7 days')) AS tasks_completed_week
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY m.xp_contributed DESC, m.joined_at`,
+		teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range progress.Members {
+		progress.QuestsCompleted += m.QuestsCompleted
+		progress.QuestsActive += m.QuestsActive
+		progress.TasksCompleted += m.TasksCompleted
+		progress.TasksCompletedWeek += m.TasksCompletedWeek
+	}
+
+	progress.TeamQuests, err = r.GetTeamQuests(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// contributeToTeam зачисляет опыт за завершенный квест в пул команды пользователя,
+// засчитывает командный квест и открывает достигнутые достижения
+func contributeToTeam(ctx context.Context, tx *sqlx.Tx, userID, questID, xp int) error {
+	teamID, _, err := teamMembership(ctx, tx, userID)
+	if errors.Is(err, ErrNotTeamMember) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	poolXP := xp * config.Economy().Teams.XPPoolPercent / 100
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE teams SET xp_pool = xp_pool + $2, quests_completed = quests_completed + 1 WHERE id = $1`,
+		teamID, poolXP)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE team_members SET xp_contributed = xp_contributed + $3 WHERE team_id = $1 AND user_id = $2`,
+		teamID, userID, poolXP)
+	if err != nil {
+		return err
+	}
+
+	var completedNow bool
+	err = tx.GetContext(ctx, &completedNow, `
+		UPDATE team_quests
+		SET completions = completions + 1,
+			completed_at = CASE WHEN completions + 1 >= target_completions THEN NOW() END
+		WHERE team_id = $1 AND quest_id = $2 AND completed_at IS NULL
+		RETURNING completed_at IS NOT NULL`,
+		teamID, questID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if completedNow {
+		err = notifyTeam(ctx, tx, teamID, models.NotificationTeamQuestCompleted, map[string]any{
+			"team_id":  teamID,
+			"quest_id": questID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return unlockTeamAchievements(ctx, tx, teamID, userID)
+}
+
+// unlockTeamAchievements открывает достигнутые достижения и уведомляет участников.
+// Открытие попадает в ленту активности участника userID, действие которого его вызвало.
+func unlockTeamAchievements(ctx context.Context, tx *sqlx.Tx, teamID, userID int) error {
+	var unlocked []struct {
+		Code string `db:"code"`
+		Name string `db:"name"`
+	}
+	if err := tx.SelectContext(ctx, &unlocked, queryUnlockTeamAchievements, teamID); err != nil {
+		return err
+	}
+
+	for _, a := range unlocked {
+		payload := map[string]any{
+			"team_id": teamID,
+			"code":    a.Code,
+			"name":    a.Name,
+		}
+		if err := notifyTeam(ctx, tx, teamID, models.NotificationTeamAchievement, payload); err != nil {
+			return err
+		}
+		if err := recordActivity(ctx, tx, userID, models.ActivityAchievementUnlocked, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func notifyTeam(ctx context.Context, tx *sqlx.Tx, teamID int, kind string, payload any) error {
+	var memberIDs []int
+	if err := tx.SelectContext(ctx, &memberIDs, `SELECT user_id FROM team_members WHERE team_id = $1`, teamID); err != nil {
+		return err
+	}
+	for _, userID := range memberIDs {
+		if err := notify(ctx, tx, userID, kind, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"BecomeOverMan/internal/models"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
 )
 
 type UserRepository struct {
@@ -41,21 +40,13 @@ func (r *UserRepository) GetProfile(userID int) (models.User, error) {
 	return user, nil
 }
 
-func (r *UserRepository) GetProfiles(userIDs []int) ([]models.UserProfile, error) {
-	if len(userIDs) == 0 {
-		return []models.UserProfile{}, nil
-	}
-
-	var usersProfiles []models.UserProfile
-	query := `
-		SELECT id, username, email, xp_points, coin_balance, level, created_at 
-		FROM users WHERE id = ANY($1)
-		ORDER BY array_position($1, id)
-		`
-	err := r.db.Select(&usersProfiles, query, pq.Array(userIDs))
-	if err != nil {
-		return nil, err
-	}
+func (r *UserRepository) UpdateTimezone(userID int, timezone string) error {
+	_, err := r.db.Exec(`UPDATE users SET timezone = $1 WHERE id = $2`, timezone, userID)
+	return err
+}
 
-	return usersProfiles, nil
+// TouchLastActive обновляет время последней активности пользователя
+func (r *UserRepository) TouchLastActive(userID int) error {
+	_, err := r.db.Exec(`UPDATE users SET last_active_at = NOW() WHERE id = $1`, userID)
+	return err
 }
@@ -0,0 +1,15 @@
+package services
+
+import "BecomeOverMan/internal/models"
+
+func (s *UserService) GetFriendsFeed(userID, limit, offset int) ([]models.ActivityEvent, error) {
+	return s.repo.GetFriendsFeed(userID, limit, offset)
+}
+
+func (s *UserService) GetPrivacySettings(userID int) (models.PrivacySettings, error) {
+	return s.repo.GetPrivacySettings(userID)
+}
+
+func (s *UserService) UpdatePrivacySettings(userID int, req models.UpdatePrivacySettingsRequest) (models.PrivacySettings, error) {
+	return s.repo.UpdatePrivacySettings(userID, req)
+}
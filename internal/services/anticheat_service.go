@@ -0,0 +1,15 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+)
+
+func (s *QuestService) GetTaskCompletionReviews(ctx context.Context, status string, limit, offset int) ([]models.TaskCompletionReview, error) {
+	return s.questRepo.GetTaskCompletionReviews(ctx, status, limit, offset)
+}
+
+// ResolveTaskCompletionReview одобряет (начисляет удержанную награду) или отклоняет подозрительное выполнение задачи
+func (s *QuestService) ResolveTaskCompletionReview(ctx context.Context, reviewID, adminID int, approve bool, comment string) (*models.TaskCompletionReview, error) {
+	return s.questRepo.ResolveTaskCompletionReview(ctx, reviewID, adminID, approve, comment)
+}
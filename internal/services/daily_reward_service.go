@@ -0,0 +1,15 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+)
+
+func (s *QuestService) GetDailyRewardStatus(ctx context.Context, userID int) (*models.DailyRewardStatus, error) {
+	return s.questRepo.GetDailyRewardStatus(ctx, userID)
+}
+
+// ClaimDailyReward выдает награду за вход (раз в локальный день пользователя)
+func (s *QuestService) ClaimDailyReward(ctx context.Context, userID int) (*models.DailyRewardClaim, error) {
+	return s.questRepo.ClaimDailyReward(ctx, userID)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+	"context"
+	"log/slog"
+)
+
+// FailExpiredQuests - задача планировщика: проваливает квесты с истекшим сроком.
+// Работает независимо от настройки затухания прогресса.
+func (s *QuestService) FailExpiredQuests(ctx context.Context) error {
+	failed, err := s.questRepo.FailExpiredQuests(ctx)
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		slog.Info("Expired quests failed", "failed_quests", failed)
+	}
+	return nil
+}
+
+// RunDecay - задача планировщика: снижает прогресс неактивных пользователей
+func (s *QuestService) RunDecay(ctx context.Context) error {
+	if !config.Economy().Decay.Enabled {
+		return nil
+	}
+
+	decayed, err := s.questRepo.DecayInactiveUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	if decayed > 0 {
+		slog.Info("Progress decay applied", "inactive_users", decayed)
+	}
+	return nil
+}
+
+func (s *QuestService) GetDecayHistory(ctx context.Context, userID, limit, offset int) ([]models.DecayHistoryEntry, error) {
+	return s.questRepo.GetDecayHistory(ctx, userID, limit, offset)
+}
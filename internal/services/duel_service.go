@@ -0,0 +1,64 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"log/slog"
+)
+
+func (s *QuestService) CreateDuel(ctx context.Context, userID int, req models.CreateDuelRequest) (int, error) {
+	duel := &models.Duel{
+		ChallengerID:  userID,
+		OpponentID:    req.OpponentID,
+		Kind:          req.Kind,
+		Stake:         req.Stake,
+		DurationHours: req.DurationHours,
+	}
+	if req.QuestID > 0 {
+		duel.QuestID = &req.QuestID
+	}
+	if req.Category != "" {
+		duel.Category = &req.Category
+	}
+	return s.questRepo.CreateDuel(ctx, duel)
+}
+
+func (s *QuestService) GetDuels(ctx context.Context, userID int, status string, limit, offset int) ([]models.DuelDetails, error) {
+	return s.questRepo.GetDuels(ctx, userID, status, limit, offset)
+}
+
+func (s *QuestService) GetDuel(ctx context.Context, userID, duelID int) (*models.DuelDetails, error) {
+	return s.questRepo.GetDuel(ctx, userID, duelID)
+}
+
+func (s *QuestService) AcceptDuel(ctx context.Context, userID, duelID int) error {
+	return s.questRepo.AcceptDuel(ctx, userID, duelID)
+}
+
+func (s *QuestService) DeclineDuel(ctx context.Context, userID, duelID int) error {
+	return s.questRepo.DeclineDuel(ctx, userID, duelID)
+}
+
+func (s *QuestService) CancelDuel(ctx context.Context, userID, duelID int) error {
+	return s.questRepo.CancelDuel(ctx, userID, duelID)
+}
+
+// ProcessDuels - задача планировщика: истекают непринятые вызовы и подводятся итоги завершившихся дуэлей
+func (s *QuestService) ProcessDuels(ctx context.Context) error {
+	expired, err := s.questRepo.ExpireDuels(ctx)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		slog.Info("Duel challenges expired", "count", expired)
+	}
+
+	resolved, err := s.questRepo.ResolveDuels(ctx)
+	if err != nil {
+		return err
+	}
+	if resolved > 0 {
+		slog.Info("Duels resolved", "count", resolved)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+)
+
+// GetLevelCurve возвращает пороги опыта для каждого уровня
+func (s *QuestService) GetLevelCurve() models.LevelCurveInfo {
+	economy := config.Economy()
+
+	info := models.LevelCurveInfo{
+		CurveType:  economy.LevelCurve.Type,
+		MaxLevel:   economy.MaxLevel,
+		Thresholds: make([]models.LevelThreshold, 0, economy.MaxLevel),
+	}
+	for level := 1; level <= economy.MaxLevel; level++ {
+		info.Thresholds = append(info.Thresholds, models.LevelThreshold{Level: level, XP: economy.XPForLevel(level)})
+	}
+	return info
+}
+
+// ReloadEconomy перечитывает economy.yaml и пересчитывает уровни пользователей по новой кривой.
+// Если файл невалиден - продолжает действовать прежняя конфигурация.
+func (s *QuestService) ReloadEconomy(ctx context.Context) (*config.EconomyConfig, int, error) {
+	if err := config.ReloadEconomy(); err != nil {
+		return nil, 0, err
+	}
+
+	changed, err := s.questRepo.RecalculateLevels(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	slog.Info("Economy config reloaded", "levels_changed", changed)
+
+	return config.Economy(), changed, nil
+}
+
+// applyQuestEconomy проверяет сгенерированный квест по конфигурации экономики
+// и пересчитывает сложность, награды и цену по ее правилам
+func applyQuestEconomy(quest *models.Quest, tasks []models.Task) error {
+	q := config.Economy().Quest
+	maxDifficulty := config.Economy().MaxQuestDifficulty()
+
+	if quest == nil {
+		return errors.New("quest is missing")
+	}
+	if len(tasks) < q.TasksCount.Min || len(tasks) > q.TasksCount.Max {
+		return fmt.Errorf("quest has %d tasks, expected %d-%d", len(tasks), q.TasksCount.Min, q.TasksCount.Max)
+	}
+
+	var difficultySum, xpSum, coinSum int
+	for i := range tasks {
+		t := &tasks[i]
+		t.Difficulty = min(q.TaskDifficulty.Clamp(t.Difficulty), maxDifficulty)
+		t.BaseXpReward = q.TaskXP.Clamp(t.BaseXpReward)
+		t.BaseCoinReward = q.TaskCoin.Clamp(t.BaseCoinReward)
+		t.TaskOrder = i + 1
+
+		difficultySum += t.Difficulty
+		xpSum += t.BaseXpReward
+		coinSum += t.BaseCoinReward
+	}
+
+	quest.TasksCount = len(tasks)
+	quest.Difficulty = min(q.Difficulty.Clamp(int(math.Round(float64(difficultySum)/float64(len(tasks))))), maxDifficulty)
+	quest.RewardXP = int(math.Round(float64(xpSum) * q.RewardToTasksRatio))
+	quest.RewardCoin = int(math.Round(float64(coinSum) * q.RewardToTasksRatio))
+	quest.Price = int(math.Round(float64(quest.RewardCoin) * q.PriceToRewardCoin))
+	quest.TimeLimitHours = q.TimeLimitHours.Clamp(quest.TimeLimitHours)
+
+	return nil
+}
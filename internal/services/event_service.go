@@ -0,0 +1,46 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"log/slog"
+)
+
+func (s *QuestService) GetEvents(ctx context.Context, includeEnded bool) ([]models.Event, error) {
+	return s.questRepo.GetEvents(ctx, includeEnded)
+}
+
+func (s *QuestService) GetEvent(ctx context.Context, eventID int) (*models.EventDetails, error) {
+	return s.questRepo.GetEvent(ctx, eventID)
+}
+
+// SaveEvent создает (eventID = 0) или изменяет событие; множители по умолчанию - x1
+func (s *QuestService) SaveEvent(ctx context.Context, eventID int, req models.EventRequest) (*models.Event, error) {
+	if req.XPMultiplier == 0 {
+		req.XPMultiplier = 100
+	}
+	if req.CoinMultiplier == 0 {
+		req.CoinMultiplier = 100
+	}
+	return s.questRepo.SaveEvent(ctx, eventID, req)
+}
+
+func (s *QuestService) DeleteEvent(ctx context.Context, eventID int) error {
+	return s.questRepo.DeleteEvent(ctx, eventID)
+}
+
+func (s *QuestService) GetEventLeaderboard(ctx context.Context, eventID, limit, offset int) ([]models.EventLeaderboardEntry, error) {
+	return s.questRepo.GetEventLeaderboard(ctx, eventID, limit, offset)
+}
+
+// RefreshEventStatuses - задача планировщика: включает и выключает события по расписанию
+func (s *QuestService) RefreshEventStatuses(ctx context.Context) error {
+	activated, ended, err := s.questRepo.RefreshEventStatuses(ctx)
+	if err != nil {
+		return err
+	}
+	if activated > 0 || ended > 0 {
+		slog.Info("Event statuses refreshed", "activated", activated, "ended", ended)
+	}
+	return nil
+}
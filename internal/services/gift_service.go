@@ -0,0 +1,27 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+)
+
+// GiftCoins переводит монеты принятому другу (с дневным лимитом)
+func (s *QuestService) GiftCoins(ctx context.Context, senderID, recipientID int, req models.GiftCoinsRequest) (*models.Gift, error) {
+	return s.questRepo.GiftCoins(ctx, senderID, recipientID, req.Amount, req.Message)
+}
+
+// GiftQuest покупает квест другу - он появляется в его квестах как купленный
+func (s *QuestService) GiftQuest(ctx context.Context, senderID, recipientID int, req models.GiftQuestRequest) (*models.Gift, error) {
+	gift, err := s.questRepo.GiftQuest(ctx, senderID, recipientID, req.QuestID, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.syncUserQuestsToRecommendationService(recipientID)
+
+	return gift, nil
+}
+
+func (s *QuestService) GetGifts(ctx context.Context, userID, limit, offset int) ([]models.Gift, error) {
+	return s.questRepo.GetGifts(ctx, userID, limit, offset)
+}
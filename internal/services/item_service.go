@@ -0,0 +1,31 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+)
+
+func (s *QuestService) GetItems(ctx context.Context) ([]models.Item, error) {
+	return s.questRepo.GetItems(ctx)
+}
+
+func (s *QuestService) GetInventory(ctx context.Context, userID int) ([]models.InventoryItem, error) {
+	return s.questRepo.GetInventory(ctx, userID)
+}
+
+func (s *QuestService) GetActiveEffects(ctx context.Context, userID int) ([]models.ActiveEffect, error) {
+	return s.questRepo.GetActiveEffects(ctx, userID)
+}
+
+// PurchaseItem покупает предметы из каталога (по умолчанию один)
+func (s *QuestService) PurchaseItem(ctx context.Context, userID int, code string, quantity int) (*models.InventoryItem, error) {
+	if quantity <= 0 {
+		quantity = 1
+	}
+	return s.questRepo.PurchaseItem(ctx, userID, code, quantity)
+}
+
+// UseItem применяет предмет из инвентаря: бустер опыта или продление квеста (questID)
+func (s *QuestService) UseItem(ctx context.Context, userID int, code string, questID int) (*models.UseItemResult, error) {
+	return s.questRepo.UseItem(ctx, userID, code, questID)
+}
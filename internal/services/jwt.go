@@ -7,7 +7,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey = []byte(config.Cfg.JWTSecret)
+func jwtKey() []byte {
+	return []byte(config.Get().JWTSecret)
+}
 
 type Claims struct {
 	UserID int `json:"user_id"`
@@ -24,13 +26,13 @@ func GenerateJWT(userID int) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return token.SignedString(jwtKey())
 }
 
 func ValidateJWT(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
+		return jwtKey(), nil
 	})
 
 	if err != nil || !token.Valid {
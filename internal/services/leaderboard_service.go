@@ -0,0 +1,24 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+)
+
+// GetLeaderboard возвращает таблицу лидеров; по умолчанию - глобальная по опыту за все время
+func (s *QuestService) GetLeaderboard(ctx context.Context, userID int, q models.LeaderboardQuery, limit, offset int) (*models.Leaderboard, error) {
+	if q.Metric == "" {
+		q.Metric = models.LeaderboardMetricXP
+	}
+	if q.Period == "" {
+		q.Period = models.LeaderboardPeriodAll
+	}
+	if q.Scope == "" {
+		q.Scope = models.LeaderboardScopeGlobal
+	}
+	return s.questRepo.GetLeaderboard(ctx, userID, q, limit, offset)
+}
+
+func (s *QuestService) SetLeaderboardOptOut(ctx context.Context, userID int, optOut bool) error {
+	return s.questRepo.SetLeaderboardOptOut(ctx, userID, optOut)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+type LedgerService struct {
+	repo *repositories.LedgerRepository
+}
+
+func NewLedgerService(repo *repositories.LedgerRepository) *LedgerService {
+	return &LedgerService{repo: repo}
+}
+
+// Reconcile сверяет coin_balance всех пользователей с суммой по журналу транзакций
+func (s *LedgerService) Reconcile(ctx context.Context) (*models.LedgerReconciliationReport, error) {
+	checked, err := s.repo.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts, err := s.repo.GetDrifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.LedgerReconciliationReport{
+		CheckedUsers: checked,
+		DriftedUsers: len(drifts),
+		Drifts:       drifts,
+	}
+	for _, d := range drifts {
+		report.TotalDrift += d.Drift
+	}
+
+	return report, nil
+}
+
+func (s *LedgerService) GetTransactions(ctx context.Context, userID int, filter models.TransactionFilter) (*models.TransactionHistory, error) {
+	items, total, err := s.repo.GetTransactions(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransactionHistory{
+		Items:  items,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// ExportTransactionsCSV пишет в w всю историю транзакций пользователя по фильтрам (без пагинации и ограничений).
+// Строки выгружаются потоком по мере чтения из БД.
+func (s *LedgerService) ExportTransactionsCSV(ctx context.Context, userID int, filter models.TransactionFilter, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{
+		"id", "created_at", "transaction_type", "amount", "balance_after",
+		"reference_type", "reference_id", "reference_title", "description",
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.ForEachTransaction(ctx, userID, filter, func(t models.CoinTransaction) error {
+		return cw.Write([]string{
+			strconv.Itoa(t.ID),
+			t.CreatedAt.Format(time.RFC3339),
+			t.TransactionType,
+			strconv.Itoa(t.Amount),
+			optionalInt(t.BalanceAfter),
+			t.ReferenceType,
+			optionalInt(t.ReferenceID),
+			optionalString(t.ReferenceTitle),
+			optionalString(t.Description),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optionalString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -17,8 +17,6 @@ const (
 	url = "https://api.intelligence.io.solutions/api/v1/chat/completions"
 )
 
-var apiKey = config.Cfg.APIKeyIntelligenceIO
-
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -39,6 +37,7 @@ type ChatResponse struct {
 }
 
 func requestAI(userMessage, systemPrompt, aiModel string) ([]byte, error) {
+	apiKey := config.Get().APIKeyIntelligenceIO
 	if apiKey == "" {
 		return nil, fmt.Errorf("API_KEY not found in environment variables")
 	}
@@ -110,11 +109,10 @@ func requestAI(userMessage, systemPrompt, aiModel string) ([]byte, error) {
 	return []byte(content), nil
 }
 
-// GenerateAIQuest - Генерация квеста по запросу пользователя в LLM
-func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestResponse, error) {
-	aiModel := "moonshotai/Kimi-K2-Thinking"
-
-	systemPrompt := `
+// questGenerationPrompt - системный промпт генерации квеста с диапазонами и правилами из конфигурации экономики
+func questGenerationPrompt(economy *config.EconomyConfig) string {
+	q := economy.Quest
+	return fmt.Sprintf(`
 	Ты помощник для генерации квестов в формате строгого JSON. 
 	ВОЗВРАЩАЙ ТОЛЬКО JSON БЕЗ ЛЮБЫХ ДОПОЛНИТЕЛЬНЫХ ТЕКСТОВ И КОММЕНТАРИЕВ!
 
@@ -125,22 +123,22 @@ func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestRespo
 			"description": "Описание квеста [GENERATED]",
 			"category": "health/willpower/intelligence/creativity/social",
 			"rarity": "common/rare/epic/legendary",
-			"difficulty": 1-5,
-			"price": 10-100,
-			"tasks_count": 3-7,
-			"reward_xp": 50-500,
-			"reward_coin": 25-250,
-			"time_limit_hours": 24-336
+			"difficulty": %d-%d,
+			"price": число,
+			"tasks_count": %d-%d,
+			"reward_xp": число,
+			"reward_coin": число,
+			"time_limit_hours": %d-%d
 		},
 		"tasks": [
 			{
 				"title": "Название задачи 1",
 				"description": "Описание задачи 1",
-				"difficulty": 1-3,
+				"difficulty": %d-%d,
 				"rarity": "common/rare/epic",
 				"category": "health/willpower/intelligence/creativity/social",
-				"base_xp_reward": 10-50,
-				"base_coin_reward": 5-25,
+				"base_xp_reward": %d-%d,
+				"base_coin_reward": %d-%d,
 				"task_order": 1
 			}
 		]
@@ -148,14 +146,38 @@ func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestRespo
 
 	Правила:
 	- difficulty квеста должен быть средним от difficulty задач
-	- price = reward_coin * 1.5 (округлить)
+	- price = reward_coin * %g (округлить)
 	- tasks_count должно соответствовать количеству задач в массиве
-	- time_limit_hours: 24-168 (1-7 дней)
-	- reward_xp = сумма base_xp_reward всех задач * 1.5
-	- reward_coin = сумма base_coin_reward всех задач * 1.5
-	`
+	- time_limit_hours: %d-%d
+	- reward_xp = сумма base_xp_reward всех задач * %g
+	- reward_coin = сумма base_coin_reward всех задач * %g
+	- difficulty квеста и задач не выше %d (максимальный уровень ветки)
+	- завершение квеста повышает ветку его категории на %d (уровень ветки не выше %d)
+	`,
+		q.Difficulty.Min, q.Difficulty.Max,
+		q.TasksCount.Min, q.TasksCount.Max,
+		q.TimeLimitHours.Min, q.TimeLimitHours.Max,
+		q.TaskDifficulty.Min, q.TaskDifficulty.Max,
+		q.TaskXP.Min, q.TaskXP.Max,
+		q.TaskCoin.Min, q.TaskCoin.Max,
+		q.PriceToRewardCoin,
+		q.TimeLimitHours.Min, q.TimeLimitHours.Max,
+		q.RewardToTasksRatio, q.RewardToTasksRatio,
+		economy.MaxQuestDifficulty(),
+		economy.AttributeGainPerQuest, economy.AttributeCap,
+	)
+}
+
+// GenerateAIQuest - Генерация квеста по запросу пользователя в LLM
+func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestResponse, error) {
+	aiModel := "moonshotai/Kimi-K2-Thinking"
+
+	systemPrompt := questGenerationPrompt(config.Economy())
 
 	answer, err := requestAI(userMessage, systemPrompt, aiModel)
+	if err != nil {
+		return nil, err
+	}
 
 	// Парсим финальный JSON
 	var aiResponse models.AIQuestResponse
@@ -164,6 +186,11 @@ func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestRespo
 		return nil, fmt.Errorf("error parsing AI quest response: %v", err)
 	}
 
+	// LLM плохо считает - приводим награды и цену к правилам экономики
+	if err := applyQuestEconomy(aiResponse.Quest, aiResponse.Tasks); err != nil {
+		return nil, fmt.Errorf("invalid AI quest: %v", err)
+	}
+
 	return &aiResponse, nil
 }
 
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+type NotificationService struct {
+	repo *repositories.NotificationRepository
+}
+
+func NewNotificationService(repo *repositories.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	return s.repo.GetNotifications(ctx, userID, unreadOnly, limit, offset)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID int) error {
+	return s.repo.MarkRead(ctx, userID, notificationID)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int) error {
+	return s.repo.MarkAllRead(ctx, userID)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Форматы файлов quest pack
+const (
+	QuestPackFormatJSON = "json"
+	QuestPackFormatYAML = "yaml"
+
+	DefaultQuestPackFormat = QuestPackFormatJSON // для CLI и HTTP
+)
+
+// questRarities - допустимые редкости квестов и задач (паки, шаблоны)
+var questRarities = []string{"free", "common", "rare", "epic", "legendary"}
+
+// ParseQuestPackFormat нормализует формат (json по умолчанию, yml == yaml)
+func ParseQuestPackFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "":
+		return DefaultQuestPackFormat, nil
+	case QuestPackFormatJSON:
+		return QuestPackFormatJSON, nil
+	case QuestPackFormatYAML, "yml":
+		return QuestPackFormatYAML, nil
+	default:
+		return "", fmt.Errorf("unsupported quest pack format %q (allowed: json, yaml)", format)
+	}
+}
+
+func DecodeQuestPack(data []byte, format string) (*models.QuestPack, error) {
+	var pack models.QuestPack
+	var err error
+	if format == QuestPackFormatYAML {
+		err = yaml.Unmarshal(data, &pack)
+	} else {
+		err = json.Unmarshal(data, &pack)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse quest pack: %w", err)
+	}
+	return &pack, nil
+}
+
+func EncodeQuestPack(pack *models.QuestPack, format string) ([]byte, error) {
+	if format == QuestPackFormatYAML {
+		return yaml.Marshal(pack)
+	}
+	return json.MarshalIndent(pack, "", "  ")
+}
+
+func (s *QuestService) ExportQuestPack(ctx context.Context) (*models.QuestPack, error) {
+	return s.questRepo.ExportQuestPack(ctx)
+}
+
+// ImportQuestPack валидирует пак и, если ошибок нет, идемпотентно загружает его.
+// Ошибки валидации возвращаются в отчете по каждой записи, в БД при этом ничего не пишется.
+func (s *QuestService) ImportQuestPack(ctx context.Context, pack *models.QuestPack, dryRun bool) (*models.QuestPackImportReport, error) {
+	report := &models.QuestPackImportReport{
+		DryRun:  dryRun,
+		Entries: ValidateQuestPack(pack),
+	}
+	if report.HasErrors() {
+		return report, nil
+	}
+
+	entries, err := s.questRepo.ImportQuestPack(ctx, pack, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Entries = entries
+	report.Applied = !dryRun
+	return report, nil
+}
+
+// ValidateQuestPack проверяет пак и возвращает результат по каждому квесту и цепочке
+func ValidateQuestPack(pack *models.QuestPack) []models.QuestPackEntryResult {
+	results := make([]models.QuestPackEntryResult, 0, len(pack.Quests)+len(pack.Chains))
+
+	if pack.Version != models.QuestPackVersion {
+		results = append(results, models.QuestPackEntryResult{
+			Kind:   "pack",
+			Index:  -1,
+			Action: models.QuestPackActionInvalid,
+			Errors: []string{fmt.Sprintf("unsupported version %d (expected %d)", pack.Version, models.QuestPackVersion)},
+		})
+	}
+
+	seenQuests := make(map[string]bool, len(pack.Quests))
+	for i, q := range pack.Quests {
+		var errs []string
+
+		if q.Key == "" {
+			errs = append(errs, "key is required")
+		} else if seenQuests[q.Key] {
+			errs = append(errs, fmt.Sprintf("duplicate key %q", q.Key))
+		}
+		seenQuests[q.Key] = true
+
+		errs = append(errs, validatePackQuest(q)...)
+
+		results = append(results, packEntryResult("quest", q.Key, i, errs))
+	}
+
+	seenChains := make(map[string]bool, len(pack.Chains))
+	for i, ch := range pack.Chains {
+		var errs []string
+
+		if ch.Key == "" {
+			errs = append(errs, "key is required")
+		} else if seenChains[ch.Key] {
+			errs = append(errs, fmt.Sprintf("duplicate key %q", ch.Key))
+		}
+		seenChains[ch.Key] = true
+
+		if strings.TrimSpace(ch.Title) == "" {
+			errs = append(errs, "title is required")
+		}
+		if len(ch.Quests) < 2 {
+			errs = append(errs, "chain must contain at least 2 quests")
+		}
+
+		inChain := make(map[string]bool, len(ch.Quests))
+		for _, key := range ch.Quests {
+			if !seenQuests[key] {
+				errs = append(errs, fmt.Sprintf("unknown quest key %q", key))
+			}
+			if inChain[key] {
+				errs = append(errs, fmt.Sprintf("quest %q appears twice", key))
+			}
+			inChain[key] = true
+		}
+
+		results = append(results, packEntryResult("chain", ch.Key, i, errs))
+	}
+
+	return results
+}
+
+func validatePackQuest(q models.QuestPackQuest) []string {
+	var errs []string
+	maxDifficulty := config.Economy().MaxQuestDifficulty()
+
+	if strings.TrimSpace(q.Title) == "" {
+		errs = append(errs, "title is required")
+	}
+	if strings.TrimSpace(q.Category) == "" {
+		errs = append(errs, "category is required")
+	}
+	if !slices.Contains(questRarities, q.Rarity) {
+		errs = append(errs, fmt.Sprintf("rarity must be one of %v", questRarities))
+	}
+	if q.Difficulty < 0 || q.Difficulty > maxDifficulty {
+		errs = append(errs, fmt.Sprintf("difficulty must be between 0 and %d", maxDifficulty))
+	}
+	if q.Price < 0 || q.RewardXP < 0 || q.RewardCoin < 0 {
+		errs = append(errs, "price and rewards must be >= 0")
+	}
+	if q.TimeLimitHours < 0 {
+		errs = append(errs, "time_limit_hours must be >= 0")
+	}
+	coop := q.Coop.Settings()
+	if coop.CoopBonusPercent < 100 {
+		errs = append(errs, "coop.bonus_percent must be >= 100")
+	}
+	if coop.CoopBonusWindowHours < 0 {
+		errs = append(errs, "coop.bonus_window_hours must be >= 0")
+	}
+	if coop.CoopPartialRewardPercent < 0 || coop.CoopPartialRewardPercent > 100 ||
+		coop.CoopFailPenaltyPercent < 0 || coop.CoopFailPenaltyPercent > 100 {
+		errs = append(errs, "coop.partial_reward_percent and coop.fail_penalty_percent must be between 0 and 100")
+	}
+	if len(q.Tasks) == 0 {
+		errs = append(errs, "quest must have at least one task")
+	}
+
+	seenOrders := make(map[int]bool, len(q.Tasks))
+	for j, t := range q.Tasks {
+		prefix := fmt.Sprintf("tasks[%d]: ", j)
+		if t.Order < 1 {
+			errs = append(errs, prefix+"order must be >= 1")
+		} else if seenOrders[t.Order] {
+			errs = append(errs, prefix+fmt.Sprintf("duplicate order %d", t.Order))
+		}
+		seenOrders[t.Order] = true
+
+		if strings.TrimSpace(t.Title) == "" {
+			errs = append(errs, prefix+"title is required")
+		}
+		if strings.TrimSpace(t.Category) == "" {
+			errs = append(errs, prefix+"category is required")
+		}
+		if !slices.Contains(questRarities, t.Rarity) {
+			errs = append(errs, prefix+fmt.Sprintf("rarity must be one of %v", questRarities))
+		}
+		if t.Difficulty < 0 || t.BaseXpReward < 0 || t.BaseCoinReward < 0 {
+			errs = append(errs, prefix+"difficulty and rewards must be >= 0")
+		}
+		if t.Difficulty > maxDifficulty {
+			errs = append(errs, prefix+fmt.Sprintf("difficulty must be <= %d", maxDifficulty))
+		}
+	}
+
+	return errs
+}
+
+func packEntryResult(kind, key string, index int, errs []string) models.QuestPackEntryResult {
+	result := models.QuestPackEntryResult{Kind: kind, Key: key, Index: index, Errors: errs}
+	if len(errs) > 0 {
+		result.Action = models.QuestPackActionInvalid
+	}
+	return result
+}
@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"slices"
 	"time"
 )
 
@@ -31,8 +30,8 @@ func (s *QuestService) GetAvailableQuests(ctx context.Context, userID int) ([]mo
 	return s.questRepo.GetAvailableQuests(ctx, userID)
 }
 
-func (s *QuestService) GetQuestShop(ctx context.Context, userID int) ([]models.Quest, error) {
-	return s.questRepo.GetQuestShop(ctx, userID)
+func (s *QuestService) GetQuestShop(ctx context.Context, userID int, sort string) ([]models.Quest, error) {
+	return s.questRepo.GetQuestShop(ctx, userID, sort)
 }
 
 func (s *QuestService) GetMyActiveQuests(ctx context.Context, userID int) ([]models.Quest, error) {
@@ -55,35 +54,38 @@ func (s *QuestService) PurchaseQuest(ctx context.Context, userID, questID int) e
 		return err
 	}
 
-	go func() {
-		questIDS, err := s.getUserQuestIDs(userID)
-		if err != nil {
-			slog.Error("Failed to get user quest IDs", "error", err, "user_id", userID)
-			return
-		}
+	go s.syncUserQuestsToRecommendationService(userID)
 
-		if len(questIDS) == 0 {
-			slog.Info("User has no quests", "user_id", userID)
-		}
+	return nil
+}
 
-		req := models.RecommendationService_AddUsers_Request{
-			Users: []models.UserWithQuestIDS{
-				{
-					UserID:   userID,
-					QuestIDs: questIDS,
-				},
-			},
-		}
+// syncUserQuestsToRecommendationService отправляет актуальный список квестов пользователя в Recommendation Service
+func (s *QuestService) syncUserQuestsToRecommendationService(userID int) {
+	questIDS, err := s.getUserQuestIDs(userID)
+	if err != nil {
+		slog.Error("Failed to get user quest IDs", "error", err, "user_id", userID)
+		return
+	}
 
-		response, err := s.sendUserQuestToRecommendationService(req)
-		if err != nil {
-			slog.Error("Failed to send user quest to recommendation service", "error", err, "user_id", userID)
-		}
+	if len(questIDS) == 0 {
+		slog.Info("User has no quests", "user_id", userID)
+	}
 
-		slog.Info("User quest sent to recommendation service", "user_id", userID, "response", response)
-	}()
+	req := models.RecommendationService_AddUsers_Request{
+		Users: []models.UserWithQuestIDS{
+			{
+				UserID:   userID,
+				QuestIDs: questIDS,
+			},
+		},
+	}
 
-	return nil
+	response, err := s.sendUserQuestToRecommendationService(req)
+	if err != nil {
+		slog.Error("Failed to send user quest to recommendation service", "error", err, "user_id", userID)
+	}
+
+	slog.Info("User quest sent to recommendation service", "user_id", userID, "response", response)
 }
 
 func (s *QuestService) getUserQuestIDs(userID int) ([]int, error) {
@@ -134,7 +136,7 @@ func (s *QuestService) StartQuest(ctx context.Context, userID, questID int) erro
 }
 
 // CompleteTask marks a task as completed by the user
-func (s *QuestService) CompleteTask(ctx context.Context, userID, questID, taskID int) error {
+func (s *QuestService) CompleteTask(ctx context.Context, userID, questID, taskID int) (*models.TaskCompletionResult, error) {
 	return s.questRepo.CompleteTask(ctx, userID, questID, taskID)
 }
 
@@ -147,10 +149,6 @@ func (s *QuestService) GetQuestDetails(ctx context.Context, questID int, userID
 	return s.questRepo.GetQuestDetails(ctx, questID, userID)
 }
 
-func (s *QuestService) CreateSharedQuest(user1ID, user2ID, questID int) error {
-	return s.questRepo.CreateSharedQuest(user1ID, user2ID, questID)
-}
-
 func (s *QuestService) SaveQuestToDB(quest *models.Quest, tasks []models.Task) (int, error) {
 	return s.questRepo.SaveQuestToDB(quest, tasks)
 }
@@ -266,8 +264,8 @@ func (s *QuestService) RecommendFriends(
 		explanations[result.UserID] = result.Explanation
 	}
 
-	// 8. Достаем профили потенциальных друзей (кроме пользователей с которыми уже дружба)
-	recommendedProfiles, err := s.userRepo.GetProfiles(userIDs)
+	// 8. Достаем профили потенциальных друзей (без друзей и заблокированных в любую сторону)
+	recommendedNotFriendsProfiles, err := s.userRepo.GetRecommendableProfiles(req.UserID, userIDs)
 	if err != nil {
 		slog.ErrorContext(ctx, "ошибка получения профилей из БД с указанными ids во время рекомендации друзей",
 			"error", err,
@@ -276,24 +274,7 @@ func (s *QuestService) RecommendFriends(
 		return nil, fmt.Errorf("В рекомендации друзей по запросу произошла внутренняя ошибка: %w", err)
 	}
 
-	// 9. Убираем оттуда пользователей с которыми уже дружба
-	friendsIDS, err := s.userRepo.GetAllAcceptedFriends(req.UserID)
-	if err != nil {
-		slog.ErrorContext(ctx, "ошибка получения друзей из БД с указанными ids во время рекомендации друзей",
-			"error", err,
-			"user_id", req.UserID,
-		)
-		return nil, fmt.Errorf("В рекомендации друзей по запросу произошла внутренняя ошибка: %w", err)
-	}
-
-	recommendedNotFriendsProfiles := make([]models.UserProfile, 0, len(recommendedProfiles))
-	for _, profile := range recommendedProfiles {
-		if !slices.Contains(friendsIDS, profile.ID) {
-			recommendedNotFriendsProfiles = append(recommendedNotFriendsProfiles, profile)
-		}
-	}
-
-	// 10. Возвращаем результат = []models.UserProfileWithSimilarityScore
+	// 9. Возвращаем результат = []models.UserProfileWithSimilarityScore
 	recommendedProfilesAndSimilarityResponse := make([]models.UserProfileWithSimilarityScore, 0, len(recommendedNotFriendsProfiles))
 	for _, profile := range recommendedNotFriendsProfiles {
 		recommendedProfilesAndSimilarityResponse = append(recommendedProfilesAndSimilarityResponse, models.UserProfileWithSimilarityScore{
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxTemplateTasks = 60
+
+var (
+	templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	templateParamName   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// TemplateValidationError - ошибки валидации шаблона или его параметров (по одной на пункт)
+type TemplateValidationError struct {
+	Errors []string
+}
+
+func (e *TemplateValidationError) Error() string {
+	return "quest template validation failed: " + strings.Join(e.Errors, "; ")
+}
+
+func (s *QuestService) GetQuestTemplates(ctx context.Context) ([]models.QuestTemplate, error) {
+	return s.questRepo.GetQuestTemplates(ctx)
+}
+
+func (s *QuestService) GetQuestTemplate(ctx context.Context, id int) (*models.QuestTemplate, error) {
+	return s.questRepo.GetQuestTemplate(ctx, id)
+}
+
+// CreateQuestTemplate проверяет шаблон (параметры, границы, формулы на значениях по умолчанию) и сохраняет его
+func (s *QuestService) CreateQuestTemplate(ctx context.Context, userID int, tpl *models.QuestTemplate) (int, error) {
+	var errs []string
+
+	if !slices.Contains(questRarities, tpl.Rarity) {
+		errs = append(errs, fmt.Sprintf("rarity must be one of %v", questRarities))
+	}
+	for i, t := range tpl.Tasks {
+		if !slices.Contains(questRarities, t.Rarity) {
+			errs = append(errs, fmt.Sprintf("tasks[%d]: rarity must be one of %v", i, questRarities))
+		}
+	}
+
+	seen := make(map[string]bool, len(tpl.Params))
+	for _, p := range tpl.Params {
+		if !templateParamName.MatchString(p.Name) || p.Name == "n" {
+			errs = append(errs, fmt.Sprintf("param %q: invalid name", p.Name))
+		}
+		if seen[p.Name] {
+			errs = append(errs, fmt.Sprintf("param %q: duplicate name", p.Name))
+		}
+		seen[p.Name] = true
+
+		if p.Type == models.TemplateParamString && len(p.Options) == 0 {
+			errs = append(errs, fmt.Sprintf("param %q: string params need options", p.Name))
+		}
+		for _, bound := range []*string{p.Min, p.Max} {
+			if bound == nil {
+				continue
+			}
+			if _, _, err := parseTemplateValue(p, *bound); err != nil {
+				errs = append(errs, fmt.Sprintf("param %q: invalid bound %q", p.Name, *bound))
+			}
+		}
+		if p.Default != nil {
+			if err := checkTemplateParam(p, *p.Default); err != nil {
+				errs = append(errs, fmt.Sprintf("param %q: default: %v", p.Name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return 0, &TemplateValidationError{Errors: errs}
+	}
+
+	// Пробный рендер на минимальных/дефолтных значениях проверяет плейсхолдеры и формулы
+	sample := make(map[string]any, len(tpl.Params))
+	for _, p := range tpl.Params {
+		switch {
+		case p.Default != nil:
+			sample[p.Name] = *p.Default
+		case p.Min != nil:
+			sample[p.Name] = *p.Min
+		case len(p.Options) > 0:
+			sample[p.Name] = p.Options[0]
+		}
+	}
+	if _, _, err := renderQuestTemplate(tpl, sample); err != nil {
+		return 0, err
+	}
+
+	tpl.CreatedBy = &userID
+	return s.questRepo.CreateQuestTemplate(ctx, tpl)
+}
+
+// InstantiateQuestTemplate создает конкретный квест из шаблона с выбранными параметрами.
+// Параметры проверяются по границам шаблона, квест сохраняется через SaveQuestToDB.
+func (s *QuestService) InstantiateQuestTemplate(ctx context.Context, templateID int, params map[string]any) (int, *models.AIQuestResponse, error) {
+	tpl, err := s.questRepo.GetQuestTemplate(ctx, templateID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	quest, tasks, err := renderQuestTemplate(tpl, params)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	questID, err := s.SaveQuestToDB(quest, tasks)
+	if err != nil {
+		return 0, nil, err
+	}
+	quest.ID = questID
+
+	go func() {
+		req := models.RecommendationService_AddQuests_Request{
+			Quests: []models.RecommendationService_questToAdd{
+				{ID: questID, Title: quest.Title, Description: quest.Description, Category: quest.Category},
+			},
+		}
+		if err := postToRecommendationService("/quests/add", req); err != nil {
+			slog.Error("Failed to send (add) templated quest to recommendation service", "error", err, "quest_id", questID)
+		}
+	}()
+
+	return questID, &models.AIQuestResponse{Quest: quest, Tasks: tasks}, nil
+}
+
+// renderQuestTemplate подставляет параметры в тексты и вычисляет формулы наград
+func renderQuestTemplate(tpl *models.QuestTemplate, raw map[string]any) (*models.Quest, []models.Task, error) {
+	vars, texts, errs := resolveTemplateParams(tpl.Params, raw)
+	if len(errs) > 0 {
+		return nil, nil, &TemplateValidationError{Errors: errs}
+	}
+
+	render := func(field, text string, extra map[string]string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+			name := templatePlaceholder.FindStringSubmatch(m)[1]
+			if v, ok := extra[name]; ok {
+				return v
+			}
+			if v, ok := texts[name]; ok {
+				return v
+			}
+			errs = append(errs, fmt.Sprintf("%s: unknown placeholder %q", field, name))
+			return m
+		})
+	}
+	formula := func(field, expr string, vars map[string]float64) int {
+		v, err := evalFormulaInt(expr, vars)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", field, err))
+		}
+		if v < 0 {
+			errs = append(errs, fmt.Sprintf("%s: must be >= 0, got %d", field, v))
+		}
+		return v
+	}
+
+	quest := &models.Quest{
+		Title:          render("title", tpl.Title, nil),
+		Description:    render("description", tpl.Description, nil),
+		Category:       tpl.Category,
+		Rarity:         tpl.Rarity,
+		Difficulty:     tpl.Difficulty,
+		IsSequential:   tpl.IsSequential,
+		Price:          formula("price_formula", tpl.PriceFormula, vars),
+		RewardXP:       formula("reward_xp_formula", tpl.RewardXPFormula, vars),
+		RewardCoin:     formula("reward_coin_formula", tpl.RewardCoinFormula, vars),
+		TimeLimitHours: formula("time_limit_hours_formula", tpl.TimeLimitHoursFormula, vars),
+	}
+
+	var tasks []models.Task
+	for i, tt := range tpl.Tasks {
+		field := fmt.Sprintf("tasks[%d]", i)
+
+		repeat := 1
+		if tt.RepeatFormula != "" {
+			repeat = formula(field+".repeat_formula", tt.RepeatFormula, vars)
+		}
+
+		for n := 1; n <= repeat && len(tasks) < maxTemplateTasks+1; n++ {
+			taskVars := make(map[string]float64, len(vars)+1)
+			for k, v := range vars {
+				taskVars[k] = v
+			}
+			taskVars["n"] = float64(n)
+			extra := map[string]string{"n": strconv.Itoa(n)}
+
+			tasks = append(tasks, models.Task{
+				Title:          render(field+".title", tt.Title, extra),
+				Description:    render(field+".description", tt.Description, extra),
+				Difficulty:     tt.Difficulty,
+				Rarity:         tt.Rarity,
+				Category:       tt.Category,
+				BaseXpReward:   formula(field+".base_xp_formula", tt.BaseXpFormula, taskVars),
+				BaseCoinReward: formula(field+".base_coin_formula", tt.BaseCoinFormula, taskVars),
+				TaskOrder:      len(tasks) + 1,
+			})
+		}
+	}
+
+	if len(tasks) == 0 {
+		errs = append(errs, "template produces no tasks")
+	}
+	maxDifficulty := config.Economy().MaxQuestDifficulty()
+	if quest.Difficulty > maxDifficulty || slices.ContainsFunc(tasks, func(t models.Task) bool { return t.Difficulty > maxDifficulty }) {
+		errs = append(errs, fmt.Sprintf("difficulty must be <= %d", maxDifficulty))
+	}
+	if len(tasks) > maxTemplateTasks {
+		errs = append(errs, fmt.Sprintf("template produces more than %d tasks", maxTemplateTasks))
+	}
+	if len(errs) > 0 {
+		return nil, nil, &TemplateValidationError{Errors: errs}
+	}
+
+	quest.TasksCount = len(tasks)
+	return quest, tasks, nil
+}
+
+// resolveTemplateParams проверяет значения по типам и границам.
+// Возвращает числовые значения для формул и текстовые - для плейсхолдеров.
+func resolveTemplateParams(params []models.QuestTemplateParam, raw map[string]any) (map[string]float64, map[string]string, []string) {
+	vars := make(map[string]float64, len(params))
+	texts := make(map[string]string, len(params))
+	var errs []string
+
+	for name := range raw {
+		if !slices.ContainsFunc(params, func(p models.QuestTemplateParam) bool { return p.Name == name }) {
+			errs = append(errs, fmt.Sprintf("param %q: unknown parameter", name))
+		}
+	}
+
+	for _, p := range params {
+		var value string
+		switch v := raw[p.Name].(type) {
+		case nil:
+			if p.Default == nil {
+				errs = append(errs, fmt.Sprintf("param %q: required", p.Name))
+				continue
+			}
+			value = *p.Default
+		case string:
+			value = v
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			errs = append(errs, fmt.Sprintf("param %q: must be a number or string", p.Name))
+			continue
+		}
+
+		if err := checkTemplateParam(p, value); err != nil {
+			errs = append(errs, fmt.Sprintf("param %q: %v", p.Name, err))
+			continue
+		}
+
+		num, text, _ := parseTemplateValue(p, value)
+		vars[p.Name] = num
+		texts[p.Name] = text
+	}
+
+	return vars, texts, errs
+}
+
+// checkTemplateParam проверяет значение параметра по типу, min/max и options
+func checkTemplateParam(p models.QuestTemplateParam, value string) error {
+	num, _, err := parseTemplateValue(p, value)
+	if err != nil {
+		return err
+	}
+
+	if p.Type == models.TemplateParamString {
+		if !slices.Contains(p.Options, value) {
+			return fmt.Errorf("must be one of %v", p.Options)
+		}
+		return nil
+	}
+
+	if p.Min != nil {
+		if min, _, err := parseTemplateValue(p, *p.Min); err == nil && num < min {
+			return fmt.Errorf("must be >= %s", *p.Min)
+		}
+	}
+	if p.Max != nil {
+		if max, _, err := parseTemplateValue(p, *p.Max); err == nil && num > max {
+			return fmt.Errorf("must be <= %s", *p.Max)
+		}
+	}
+	return nil
+}
+
+// parseTemplateValue разбирает значение параметра: число для формул и нормализованный текст
+func parseTemplateValue(p models.QuestTemplateParam, value string) (float64, string, error) {
+	value = strings.TrimSpace(value)
+
+	switch p.Type {
+	case models.TemplateParamInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, "", fmt.Errorf("%q is not an integer", value)
+		}
+		return float64(n), strconv.Itoa(n), nil
+	case models.TemplateParamFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("%q is not a number", value)
+		}
+		return f, strconv.FormatFloat(f, 'f', -1, 64), nil
+	case models.TemplateParamTime:
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			return 0, "", fmt.Errorf("%q is not a time in HH:MM format", value)
+		}
+		return float64(t.Hour()*60 + t.Minute()), t.Format("15:04"), nil
+	case models.TemplateParamString:
+		return 0, value, nil
+	default:
+		return 0, "", fmt.Errorf("unknown param type %q", p.Type)
+	}
+}
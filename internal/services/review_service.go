@@ -0,0 +1,116 @@
+package services
+
+import (
+	"BecomeOverMan/internal/integrations"
+	"BecomeOverMan/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ReviewQuest сохраняет оценку/отзыв пользователя о квесте и синхронизирует агрегаты с Recommendation Service
+func (s *QuestService) ReviewQuest(ctx context.Context, userID, questID int, req models.CreateQuestReviewRequest) (*models.QuestReview, error) {
+	review, err := s.questRepo.UpsertQuestReview(ctx, userID, questID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.syncQuestRatingsToRecommendationService(userID, questID)
+
+	return review, nil
+}
+
+func (s *QuestService) GetQuestReviews(ctx context.Context, questID, limit, offset int) ([]models.QuestReview, error) {
+	return s.questRepo.GetQuestReviews(ctx, questID, limit, offset)
+}
+
+func (s *QuestService) DeleteQuestReview(ctx context.Context, userID, questID int) error {
+	if err := s.questRepo.DeleteQuestReview(ctx, userID, questID); err != nil {
+		return err
+	}
+
+	go s.syncQuestRatingsToRecommendationService(userID, questID)
+
+	return nil
+}
+
+// syncQuestRatingsToRecommendationService отправляет в Recommendation Service
+// обновленные агрегаты квеста и оценки пользователя
+func (s *QuestService) syncQuestRatingsToRecommendationService(userID, questID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stats, err := s.questRepo.GetQuestRatingStats(ctx, questID)
+	if err != nil {
+		slog.Error("Failed to get quest rating stats", "error", err, "quest_id", questID)
+		return
+	}
+
+	questsReq := models.RecommendationService_AddQuests_Request{
+		Quests: []models.RecommendationService_questToAdd{
+			{
+				ID:                  stats.ID,
+				Title:               stats.Title,
+				Description:         stats.Description,
+				Category:            stats.Category,
+				AvgRating:           stats.AvgRating,
+				AvgDifficultyRating: stats.AvgDifficultyRating,
+				ReviewsCount:        stats.ReviewsCount,
+			},
+		},
+	}
+	if err := postToRecommendationService("/quests/add", questsReq); err != nil {
+		slog.Error("Failed to send quest ratings to recommendation service", "error", err, "quest_id", questID)
+	}
+
+	questIDs, err := s.getUserQuestIDs(userID)
+	if err != nil {
+		slog.Error("Failed to get user quest IDs", "error", err, "user_id", userID)
+		return
+	}
+
+	ratings, err := s.questRepo.GetUserQuestRatings(userID)
+	if err != nil {
+		slog.Error("Failed to get user quest ratings", "error", err, "user_id", userID)
+		return
+	}
+
+	usersReq := models.RecommendationService_AddUsers_Request{
+		Users: []models.UserWithQuestIDS{
+			{
+				UserID:       userID,
+				QuestIDs:     questIDs,
+				QuestRatings: ratings,
+			},
+		},
+	}
+	if _, err := s.sendUserQuestToRecommendationService(usersReq); err != nil {
+		slog.Error("Failed to send user ratings to recommendation service", "error", err, "user_id", userID)
+	}
+}
+
+func postToRecommendationService(path string, payload any) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Post(integrations.Recommendation_Service_BASE_URL+path, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error making POST request to recommendation service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("recommendation service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Срок ответа на приглашение в совместный квест по умолчанию
+const defaultSharedInviteTTL = 48 * time.Hour
+
+// CreateSharedQuest создает групповой квест и приглашает друзей,
+// квест стартует после ответа всех приглашенных
+func (s *QuestService) CreateSharedQuest(ctx context.Context, userID int, req models.CreateSharedQuestRequest) (int, error) {
+	ttl := defaultSharedInviteTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	sq := &models.SharedQuest{
+		QuestID:        req.QuestID,
+		OwnerID:        userID,
+		CompletionRule: req.CompletionRule,
+		PayForAll:      req.PayForAll || req.PayForBoth,
+	}
+	if sq.CompletionRule == "" {
+		sq.CompletionRule = models.SharedQuestRuleAll
+	}
+	if req.Quorum > 0 {
+		sq.Quorum = &req.Quorum
+	}
+
+	return s.questRepo.CreateSharedQuest(ctx, sq, req.Friends(), ttl)
+}
+
+func (s *QuestService) GetSharedQuestInvites(ctx context.Context, userID int, incoming bool) ([]models.SharedQuestDetails, error) {
+	return s.questRepo.GetSharedQuestInvites(ctx, userID, incoming)
+}
+
+func (s *QuestService) GetSharedQuest(ctx context.Context, userID, sharedQuestID int) (*models.SharedQuestDetails, error) {
+	return s.questRepo.GetSharedQuest(ctx, userID, sharedQuestID)
+}
+
+func (s *QuestService) GetSharedQuestHistory(ctx context.Context, userID int, status string, limit, offset int) ([]models.SharedQuestDetails, error) {
+	return s.questRepo.GetSharedQuestHistory(ctx, userID, status, limit, offset)
+}
+
+func (s *QuestService) AcceptSharedQuestInvite(ctx context.Context, userID, sharedQuestID int) error {
+	return s.questRepo.AcceptSharedQuestInvite(ctx, userID, sharedQuestID)
+}
+
+func (s *QuestService) DeclineSharedQuestInvite(ctx context.Context, userID, sharedQuestID int) error {
+	return s.questRepo.DeclineSharedQuestInvite(ctx, userID, sharedQuestID)
+}
+
+func (s *QuestService) CancelSharedQuest(ctx context.Context, userID, sharedQuestID int) error {
+	return s.questRepo.CancelSharedQuest(ctx, userID, sharedQuestID)
+}
+
+// ExpireSharedQuestInvites - задача планировщика: закрывает просроченные приглашения
+func (s *QuestService) ExpireSharedQuestInvites(ctx context.Context) error {
+	expired, err := s.questRepo.ExpireSharedQuestInvites(ctx)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		slog.Info("Shared quest invites expired", "count", expired)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"strings"
+)
+
+func (s *QuestService) AddReaction(ctx context.Context, userID int, targetType string, targetID int, kind string) error {
+	return s.questRepo.AddReaction(ctx, userID, targetType, targetID, kind)
+}
+
+func (s *QuestService) RemoveReaction(ctx context.Context, userID int, targetType string, targetID int, kind string) error {
+	return s.questRepo.RemoveReaction(ctx, userID, targetType, targetID, kind)
+}
+
+func (s *QuestService) GetComments(ctx context.Context, userID int, targetType string, targetID, limit, offset int) ([]models.SocialComment, error) {
+	return s.questRepo.GetComments(ctx, userID, targetType, targetID, limit, offset)
+}
+
+func (s *QuestService) AddComment(ctx context.Context, userID int, targetType string, targetID int, body string) (*models.SocialComment, error) {
+	return s.questRepo.AddComment(ctx, userID, targetType, targetID, strings.TrimSpace(body))
+}
+
+func (s *QuestService) DeleteComment(ctx context.Context, userID, commentID int) error {
+	return s.questRepo.DeleteComment(ctx, userID, commentID)
+}
+
+func (s *QuestService) ModerateDeleteComment(ctx context.Context, commentID int) error {
+	return s.questRepo.ModerateDeleteComment(ctx, commentID)
+}
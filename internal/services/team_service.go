@@ -0,0 +1,63 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"strings"
+)
+
+func (s *QuestService) CreateTeam(ctx context.Context, userID int, req models.CreateTeamRequest) (int, error) {
+	return s.questRepo.CreateTeam(ctx, userID, strings.TrimSpace(req.Name), strings.TrimSpace(req.Description))
+}
+
+func (s *QuestService) GetTeam(ctx context.Context, teamID, userID int) (*models.TeamDetails, error) {
+	return s.questRepo.GetTeam(ctx, teamID, userID)
+}
+
+func (s *QuestService) GetMyTeam(ctx context.Context, userID int) (*models.TeamDetails, error) {
+	return s.questRepo.GetMyTeam(ctx, userID)
+}
+
+func (s *QuestService) JoinTeam(ctx context.Context, userID int, inviteCode string) (int, error) {
+	return s.questRepo.JoinTeam(ctx, userID, inviteCode)
+}
+
+func (s *QuestService) LeaveTeam(ctx context.Context, userID, teamID int) error {
+	return s.questRepo.LeaveTeam(ctx, userID, teamID)
+}
+
+func (s *QuestService) GetTeamMembers(ctx context.Context, teamID int) ([]models.TeamMember, error) {
+	return s.questRepo.GetTeamMembers(ctx, teamID)
+}
+
+func (s *QuestService) SetTeamMemberRole(ctx context.Context, actorID, teamID, userID int, role string) error {
+	return s.questRepo.SetTeamMemberRole(ctx, actorID, teamID, userID, role)
+}
+
+func (s *QuestService) RemoveTeamMember(ctx context.Context, actorID, teamID, userID int) error {
+	return s.questRepo.RemoveTeamMember(ctx, actorID, teamID, userID)
+}
+
+func (s *QuestService) RegenerateTeamInviteCode(ctx context.Context, actorID, teamID int) (string, error) {
+	return s.questRepo.RegenerateTeamInviteCode(ctx, actorID, teamID)
+}
+
+func (s *QuestService) GetTeamQuests(ctx context.Context, teamID int) ([]models.TeamQuest, error) {
+	return s.questRepo.GetTeamQuests(ctx, teamID)
+}
+
+func (s *QuestService) AddTeamQuest(ctx context.Context, actorID, teamID int, req models.AddTeamQuestRequest) error {
+	target := req.TargetCompletions
+	if target == 0 {
+		target = 1
+	}
+	return s.questRepo.AddTeamQuest(ctx, actorID, teamID, req.QuestID, target)
+}
+
+func (s *QuestService) RemoveTeamQuest(ctx context.Context, actorID, teamID, questID int) error {
+	return s.questRepo.RemoveTeamQuest(ctx, actorID, teamID, questID)
+}
+
+func (s *QuestService) GetTeamProgress(ctx context.Context, teamID, userID int) (*models.TeamProgress, error) {
+	return s.questRepo.GetTeamProgress(ctx, teamID, userID)
+}
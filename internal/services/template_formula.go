@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// evalFormula вычисляет арифметическую формулу награды шаблона квеста.
+// Поддерживаются числа, параметры шаблона, + - * / ( ) и функции min, max, round, floor, ceil.
+// Пример: "round(days * 20 * (1 + (360 - wake_time) / 120))"
+func evalFormula(expr string, vars map[string]float64) (float64, error) {
+	p := &formulaParser{src: expr, vars: vars}
+	p.next()
+
+	value, err := p.parseExpr()
+	if err != nil {
+		return 0, fmt.Errorf("formula %q: %w", expr, err)
+	}
+	if p.tok != tokEOF {
+		return 0, fmt.Errorf("formula %q: unexpected %q", expr, p.text)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("formula %q: result is not a finite number", expr)
+	}
+
+	return value, nil
+}
+
+// evalFormulaInt - evalFormula с округлением до целого (пустая формула = 0)
+func evalFormulaInt(expr string, vars map[string]float64) (int, error) {
+	if strings.TrimSpace(expr) == "" {
+		return 0, nil
+	}
+	value, err := evalFormula(expr, vars)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(value)), nil
+}
+
+type formulaToken int
+
+const (
+	tokEOF formulaToken = iota
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type formulaParser struct {
+	src  string
+	pos  int
+	vars map[string]float64
+
+	tok  formulaToken
+	text string
+	num  float64
+	err  error
+}
+
+func (p *formulaParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.tok, p.text = tokEOF, ""
+		return
+	}
+
+	start := p.pos
+	ch := rune(p.src[p.pos])
+	switch {
+	case unicode.IsDigit(ch) || ch == '.':
+		for p.pos < len(p.src) && (unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok, p.text = tokNumber, p.src[start:p.pos]
+		p.num, p.err = strconv.ParseFloat(p.text, 64)
+	case unicode.IsLetter(ch) || ch == '_':
+		for p.pos < len(p.src) && (unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '_') {
+			p.pos++
+		}
+		p.tok, p.text = tokIdent, p.src[start:p.pos]
+	default:
+		p.pos++
+		p.tok, p.text = tokOp, p.src[start:p.pos]
+	}
+}
+
+// expr := term (('+' | '-') term)*
+func (p *formulaParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for p.tok == tokOp && (p.text == "+" || p.text == "-") {
+		op := p.text
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+	return left, nil
+}
+
+// term := factor (('*' | '/') factor)*
+func (p *formulaParser) parseTerm() (float64, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return 0, err
+	}
+	for p.tok == tokOp && (p.text == "*" || p.text == "/") {
+		op := p.text
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+		if op == "*" {
+			left *= right
+		} else {
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		}
+	}
+	return left, nil
+}
+
+// factor := number | ident | ident '(' args ')' | '(' expr ')' | '-' factor
+func (p *formulaParser) parseFactor() (float64, error) {
+	switch p.tok {
+	case tokNumber:
+		if p.err != nil {
+			return 0, fmt.Errorf("invalid number %q", p.text)
+		}
+		value := p.num
+		p.next()
+		return value, nil
+
+	case tokIdent:
+		name := p.text
+		p.next()
+		if p.tok == tokOp && p.text == "(" {
+			return p.parseCall(name)
+		}
+		value, ok := p.vars[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown parameter %q", name)
+		}
+		return value, nil
+
+	case tokOp:
+		switch p.text {
+		case "(":
+			p.next()
+			value, err := p.parseExpr()
+			if err != nil {
+				return 0, err
+			}
+			if p.tok != tokOp || p.text != ")" {
+				return 0, fmt.Errorf("expected )")
+			}
+			p.next()
+			return value, nil
+		case "-":
+			p.next()
+			value, err := p.parseFactor()
+			return -value, err
+		}
+	}
+
+	if p.tok == tokEOF {
+		return 0, fmt.Errorf("unexpected end of formula")
+	}
+	return 0, fmt.Errorf("unexpected %q", p.text)
+}
+
+func (p *formulaParser) parseCall(name string) (float64, error) {
+	p.next() // (
+
+	var args []float64
+	for !(p.tok == tokOp && p.text == ")") {
+		value, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, value)
+
+		if p.tok == tokOp && p.text == "," {
+			p.next()
+			continue
+		}
+		if p.tok != tokOp || p.text != ")" {
+			return 0, fmt.Errorf("expected , or ) in %s()", name)
+		}
+	}
+	p.next() // )
+
+	switch name {
+	case "min", "max":
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%s() needs at least one argument", name)
+		}
+		result := args[0]
+		for _, a := range args[1:] {
+			if name == "min" {
+				result = math.Min(result, a)
+			} else {
+				result = math.Max(result, a)
+			}
+		}
+		return result, nil
+	case "round", "floor", "ceil":
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%s() needs exactly one argument", name)
+		}
+		switch name {
+		case "round":
+			return math.Round(args[0]), nil
+		case "floor":
+			return math.Floor(args[0]), nil
+		default:
+			return math.Ceil(args[0]), nil
+		}
+	default:
+		return 0, fmt.Errorf("unknown function %q", name)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"BecomeOverMan/internal/repositories"
 	"errors"
 	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -48,21 +49,114 @@ func (s *UserService) Login(username, password string) (int, error) {
 	// Логируем успешный вход
 	log.Printf("User %s logged in successfully", username)
 
+	if err := s.repo.TouchLastActive(user.ID); err != nil {
+		log.Printf("Failed to update last_active_at for user %s: %v", username, err)
+	}
+
 	return user.ID, nil
 }
 
-func (s *UserService) AddFriend(userID, friendID int) error {
+func (s *UserService) AddFriend(userID, friendID int) (string, error) {
 	return s.repo.AddFriend(userID, friendID)
 }
 
-func (s *UserService) AddFriendByName(userID int, friendName string) error {
+func (s *UserService) AddFriendByName(userID int, friendName string) (string, error) {
 	return s.repo.AddFriendbyName(userID, friendName)
 }
 
+func (s *UserService) GetFriendRequests(userID int, incoming bool) ([]models.Friend, error) {
+	requests, err := s.repo.GetFriendRequests(userID, incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	// Вторая сторона входящей заявки - отправитель, исходящей - получатель
+	err = s.attachFriendProfiles(userID, requests, func(f models.Friend) int {
+		if incoming {
+			return f.UserID
+		}
+		return f.FriendID
+	})
+	return requests, err
+}
+
+func (s *UserService) AcceptFriendRequest(userID, requestID int) error {
+	return s.repo.AcceptFriendRequest(userID, requestID)
+}
+
+func (s *UserService) DeclineFriendRequest(userID, requestID int) error {
+	return s.repo.DeclineFriendRequest(userID, requestID)
+}
+
+func (s *UserService) CancelFriendRequest(userID, requestID int) error {
+	return s.repo.CancelFriendRequest(userID, requestID)
+}
+
 func (s *UserService) GetFriends(userID int) ([]models.Friend, error) {
-	return s.repo.GetFriends(userID)
+	friends, err := s.repo.GetFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.attachFriendProfiles(userID, friends, func(f models.Friend) int { return f.FriendID })
+	return friends, err
+}
+
+// attachFriendProfiles добавляет к записям о дружбе публичные профили второй стороны
+func (s *UserService) attachFriendProfiles(userID int, friends []models.Friend, otherID func(models.Friend) int) error {
+	ids := make([]int, len(friends))
+	for i, f := range friends {
+		ids[i] = otherID(f)
+	}
+
+	profiles, err := s.repo.GetPublicProfiles(userID, ids)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]*models.PublicProfile, len(profiles))
+	for i := range profiles {
+		byID[profiles[i].ID] = &profiles[i]
+	}
+	for i := range friends {
+		friends[i].Profile = byID[otherID(friends[i])]
+	}
+	return nil
+}
+
+// GetPublicProfile - профиль пользователя с учетом его настроек приватности
+func (s *UserService) GetPublicProfile(viewerID, userID int) (*models.PublicProfile, error) {
+	return s.repo.GetPublicProfile(viewerID, userID)
+}
+
+func (s *UserService) SearchUsers(viewerID int, query string, limit, offset int) ([]models.UserSearchResult, error) {
+	return s.repo.SearchUsers(viewerID, query, limit, offset)
+}
+
+func (s *UserService) RemoveFriend(userID, friendID int) error {
+	return s.repo.RemoveFriend(userID, friendID)
+}
+
+func (s *UserService) BlockUser(userID, blockedID int) error {
+	return s.repo.BlockUser(userID, blockedID)
+}
+
+func (s *UserService) UnblockUser(userID, blockedID int) error {
+	return s.repo.UnblockUser(userID, blockedID)
+}
+
+func (s *UserService) GetBlockedUsers(userID int) ([]models.BlockedUser, error) {
+	return s.repo.GetBlockedUsers(userID)
 }
 
 func (s *UserService) GetProfile(userID int) (models.User, error) {
 	return s.repo.GetProfile(userID)
 }
+
+// UpdateTimezone сохраняет часовой пояс пользователя (IANA, например "Europe/Moscow")
+func (s *UserService) UpdateTimezone(userID int, timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return errors.New("invalid timezone")
+	}
+	return s.repo.UpdateTimezone(userID, timezone)
+}
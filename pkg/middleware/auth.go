@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"BecomeOverMan/internal/config"
 	"BecomeOverMan/internal/services"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +32,25 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminOnlyMiddleware пропускает только пользователей из ADMIN_USER_IDS.
+// Должен идти после JWTAuthMiddleware.
+func AdminOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !slices.Contains(config.Get().AdminUserIDs, userID) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // ==== Helping Functions ====
 
 func GetUserID(c *gin.Context) (int, error) {